@@ -0,0 +1,115 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package admission
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// waitQueued waits until n statements are waiting in the queue
+func waitQueued(t *testing.T, c *Controller, n int) {
+	for i := 0; i < 1000; i++ {
+		if c.Stats().Queued() == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("%d statements are not queued", n)
+}
+
+func TestPriorityOrder(t *testing.T) {
+	c := NewController(1)
+	release, err := c.Acquire(context.Background(), PriorityNormal)
+	assert.NoError(t, err)
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	acquire := func(name string, p Priority) {
+		defer wg.Done()
+		release, err := c.Acquire(context.Background(), p)
+		assert.NoError(t, err)
+		mu.Lock()
+		order = append(order, name)
+		mu.Unlock()
+		release()
+	}
+
+	for i, w := range []struct {
+		name string
+		p    Priority
+	}{
+		{"low1", PriorityLow},
+		{"normal1", PriorityNormal},
+		{"low2", PriorityLow},
+		{"high1", PriorityHigh},
+		{"normal2", PriorityNormal},
+	} {
+		wg.Add(1)
+		go acquire(w.name, w.p)
+		waitQueued(t, c, i+1)
+	}
+
+	release()
+	// releasing twice has no effect
+	release()
+	wg.Wait()
+	assert.EqualValues(t, []string{"high1", "normal1", "normal2", "low1", "low2"}, order)
+
+	stats := c.Stats()
+	assert.EqualValues(t, 0, stats.InFlight)
+	assert.EqualValues(t, 0, stats.Queued())
+	assert.EqualValues(t, 2, stats.Priorities[PriorityLow].Admitted)
+	assert.EqualValues(t, 2, stats.Priorities[PriorityLow].Waited)
+	assert.EqualValues(t, 3, stats.Priorities[PriorityNormal].Admitted)
+	assert.EqualValues(t, 1, stats.Priorities[PriorityHigh].Admitted)
+	assert.True(t, stats.Priorities[PriorityLow].WaitTime > 0)
+}
+
+func TestQueueLimits(t *testing.T) {
+	c := NewController(1).SetMaxQueue(1).SetQueueTimeout(20 * time.Millisecond)
+	release, err := c.Acquire(context.Background(), PriorityHigh)
+	assert.NoError(t, err)
+
+	_, err = c.Acquire(context.Background(), PriorityNormal)
+	assert.EqualValues(t, ErrQueueTimeout, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := c.Acquire(ctx, PriorityLow)
+		done <- err
+	}()
+	waitQueued(t, c, 1)
+	_, err = c.Acquire(context.Background(), PriorityHigh)
+	assert.EqualValues(t, ErrQueueFull, err)
+	cancel()
+	assert.EqualValues(t, context.Canceled, <-done)
+
+	release()
+	release, err = c.Acquire(context.Background(), PriorityLow)
+	assert.NoError(t, err)
+	release()
+
+	stats := c.Stats()
+	assert.EqualValues(t, 0, stats.InFlight)
+	assert.EqualValues(t, 1, stats.Priorities[PriorityNormal].Timeouts)
+	assert.EqualValues(t, 1, stats.Priorities[PriorityHigh].Rejected)
+	assert.EqualValues(t, 1, stats.Priorities[PriorityLow].Canceled)
+	assert.EqualValues(t, 1, stats.Priorities[PriorityLow].Admitted)
+}
+
+func TestPriorityFromContext(t *testing.T) {
+	assert.EqualValues(t, PriorityNormal, PriorityFromContext(context.Background()))
+	ctx := WithPriority(context.Background(), PriorityLow)
+	assert.EqualValues(t, PriorityLow, PriorityFromContext(ctx))
+	assert.EqualValues(t, PriorityHigh, PriorityFromContext(WithPriority(ctx, 5)))
+	assert.EqualValues(t, "low", PriorityLow.String())
+}
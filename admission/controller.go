@@ -0,0 +1,301 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package admission limits the concurrent statements of an engine. The statements over
+// the limit wait in a queue, the ones of higher priority are admitted first and the ones
+// of the same priority are admitted in order, so the batch jobs running at low priority
+// don't starve the interactive requests of the connections during traffic spikes.
+package admission
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrQueueFull represents the queue of the controller is full
+	ErrQueueFull = errors.New("Admission queue is full")
+	// ErrQueueTimeout represents a statement waits in the queue longer than the queue timeout
+	ErrQueueTimeout = errors.New("Admission queue timeout")
+)
+
+// Priority is the priority class of the statements
+type Priority int
+
+// the priority classes
+const (
+	PriorityLow Priority = iota - 1
+	PriorityNormal
+	PriorityHigh
+)
+
+// Priorities are all the priority classes in ascending order
+var Priorities = []Priority{PriorityLow, PriorityNormal, PriorityHigh}
+
+func (p Priority) String() string {
+	switch {
+	case p < PriorityNormal:
+		return "low"
+	case p > PriorityNormal:
+		return "high"
+	}
+	return "normal"
+}
+
+// normalize clamps the priority to the priority classes
+func (p Priority) normalize() Priority {
+	switch {
+	case p < PriorityLow:
+		return PriorityLow
+	case p > PriorityHigh:
+		return PriorityHigh
+	}
+	return p
+}
+
+type priorityKey struct{}
+
+// WithPriority returns a context which carries the priority, the statements executed with
+// the context are admitted with the priority
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFromContext returns the priority carried by the context, PriorityNormal if none
+func PriorityFromContext(ctx context.Context) Priority {
+	p, ok := ctx.Value(priorityKey{}).(Priority)
+	if !ok {
+		return PriorityNormal
+	}
+	return p.normalize()
+}
+
+// PriorityStats are the statistics of a priority class
+type PriorityStats struct {
+	// Queued is the number of the statements waiting in the queue now
+	Queued int
+	// Admitted is the number of the admitted statements
+	Admitted int64
+	// Waited is the number of the statements admitted after waiting in the queue
+	Waited int64
+	// WaitTime is the total time waited by the admitted statements
+	WaitTime time.Duration
+	// Rejected is the number of the statements rejected since the queue is full
+	Rejected int64
+	// Timeouts is the number of the statements exceeding the queue timeout
+	Timeouts int64
+	// Canceled is the number of the statements whose contexts are done in the queue
+	Canceled int64
+}
+
+// Stats are the statistics of a controller
+type Stats struct {
+	MaxConcurrent int
+	MaxQueue      int
+	// InFlight is the number of the statements admitted and not released
+	InFlight   int
+	Priorities map[Priority]PriorityStats
+}
+
+// Queued returns the number of the statements waiting in the queue of all the priorities
+func (s Stats) Queued() int {
+	var n int
+	for _, ps := range s.Priorities {
+		n += ps.Queued
+	}
+	return n
+}
+
+type waiter struct {
+	priority Priority
+	seq      uint64
+	index    int
+	admitted bool
+	ready    chan struct{}
+}
+
+// waiterHeap orders the waiters by the priority descending and then by the arrival
+type waiterHeap []*waiter
+
+func (h waiterHeap) Len() int { return len(h) }
+
+func (h waiterHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h waiterHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *waiterHeap) Push(x interface{}) {
+	w := x.(*waiter)
+	w.index = len(*h)
+	*h = append(*h, w)
+}
+
+func (h *waiterHeap) Pop() interface{} {
+	old := *h
+	w := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	w.index = -1
+	return w
+}
+
+// Controller admits the statements up to the max concurrency
+type Controller struct {
+	mu            sync.Mutex
+	maxConcurrent int
+	maxQueue      int
+	queueTimeout  time.Duration
+	inFlight      int
+	queue         waiterHeap
+	seq           uint64
+	stats         map[Priority]*PriorityStats
+}
+
+// NewController creates a controller which admits max concurrent statements, 1 is used if
+// max is not positive
+func NewController(max int) *Controller {
+	if max <= 0 {
+		max = 1
+	}
+	c := &Controller{
+		maxConcurrent: max,
+		stats:         make(map[Priority]*PriorityStats, len(Priorities)),
+	}
+	for _, p := range Priorities {
+		c.stats[p] = &PriorityStats{}
+	}
+	return c
+}
+
+// SetMaxQueue sets the max number of the waiting statements, the statements over it are
+// rejected with ErrQueueFull, 0 means no limitation
+func (c *Controller) SetMaxQueue(max int) *Controller {
+	c.mu.Lock()
+	c.maxQueue = max
+	c.mu.Unlock()
+	return c
+}
+
+// SetQueueTimeout sets the max time of a statement waiting in the queue, the statements
+// waiting longer fail with ErrQueueTimeout, 0 means waiting until the context is done
+func (c *Controller) SetQueueTimeout(timeout time.Duration) *Controller {
+	c.mu.Lock()
+	c.queueTimeout = timeout
+	c.mu.Unlock()
+	return c
+}
+
+// Acquire waits until a statement of the priority is admitted, the returned function
+// should be called to release the admission when the statement is finished
+func (c *Controller) Acquire(ctx context.Context, p Priority) (func(), error) {
+	p = p.normalize()
+	c.mu.Lock()
+	stats := c.stats[p]
+	if c.inFlight < c.maxConcurrent && len(c.queue) == 0 {
+		c.inFlight++
+		stats.Admitted++
+		c.mu.Unlock()
+		return c.releaser(), nil
+	}
+	if c.maxQueue > 0 && len(c.queue) >= c.maxQueue {
+		stats.Rejected++
+		c.mu.Unlock()
+		return nil, ErrQueueFull
+	}
+	c.seq++
+	w := &waiter{
+		priority: p,
+		seq:      c.seq,
+		ready:    make(chan struct{}),
+	}
+	heap.Push(&c.queue, w)
+	stats.Queued++
+	timeout := c.queueTimeout
+	c.mu.Unlock()
+
+	start := time.Now()
+	var timer <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		timer = t.C
+	}
+
+	var err error
+	select {
+	case <-w.ready:
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timer:
+		err = ErrQueueTimeout
+	}
+
+	c.mu.Lock()
+	if err != nil && !w.admitted {
+		heap.Remove(&c.queue, w.index)
+		stats.Queued--
+		if err == ErrQueueTimeout {
+			stats.Timeouts++
+		} else {
+			stats.Canceled++
+		}
+		c.mu.Unlock()
+		return nil, err
+	}
+	// admitted, even if the context is done at the same time
+	stats.Admitted++
+	stats.Waited++
+	stats.WaitTime += time.Since(start)
+	c.mu.Unlock()
+	return c.releaser(), nil
+}
+
+// releaser returns the function releasing an admission once
+func (c *Controller) releaser() func() {
+	var once sync.Once
+	return func() {
+		once.Do(c.release)
+	}
+}
+
+// release passes the admission to the first waiter or frees it
+func (c *Controller) release() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.queue) == 0 {
+		c.inFlight--
+		return
+	}
+	w := heap.Pop(&c.queue).(*waiter)
+	w.admitted = true
+	c.stats[w.priority].Queued--
+	close(w.ready)
+}
+
+// Stats returns the statistics of the controller
+func (c *Controller) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := Stats{
+		MaxConcurrent: c.maxConcurrent,
+		MaxQueue:      c.maxQueue,
+		InFlight:      c.inFlight,
+		Priorities:    make(map[Priority]PriorityStats, len(c.stats)),
+	}
+	for p, ps := range c.stats {
+		stats.Priorities[p] = *ps
+	}
+	return stats
+}
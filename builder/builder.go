@@ -45,16 +45,25 @@ type setOp struct {
 	builder      *Builder
 }
 
+type cte struct {
+	name      string
+	recursive bool
+	builder   *Builder
+}
+
 type limit struct {
-	limitN int
-	offset int
+	limitN   int
+	offset   int
+	withTies bool
 }
 
 // Builder describes a SQL statement
 type Builder struct {
 	optype
 	dialect    string
+	version    string
 	isNested   bool
+	ctes       []cte
 	into       string
 	from       string
 	subQuery   *Builder
@@ -65,10 +74,16 @@ type Builder struct {
 	limitation *limit
 	insertCols []string
 	insertVals []interface{}
+	insertRows [][]interface{}
+	upsert     *upsert
 	updates    []UpdateCond
 	orderBy    string
 	groupBy    string
 	having     string
+
+	selectExprs []columnExpr
+	orderExprs  []columnExpr
+	lock        *lock
 }
 
 // Dialect sets the db dialect of Builder.
@@ -171,6 +186,9 @@ func (b *Builder) setOperation(opType, distinctType string, cond *Builder) *Buil
 		builder.optype = setOpType
 		builder.dialect = b.dialect
 		builder.selects = b.selects
+		// common table expressions belong to the whole set operation
+		builder.ctes = b.ctes
+		b.ctes = nil
 
 		currentSetOps := b.setOps
 		// erase sub setOps (actually append to new Builder.unions)
@@ -207,6 +225,13 @@ func (b *Builder) Limit(limitN int, offset ...int) *Builder {
 	return b
 }
 
+// LimitWithTies sets limitN condition which includes the rows that tie with
+// the last one according to ORDER BY
+func (b *Builder) LimitWithTies(limitN int) *Builder {
+	b.limitation = &limit{limitN: limitN, withTies: true}
+	return b
+}
+
 // Select sets select SQL
 func (b *Builder) Select(cols ...string) *Builder {
 	b.selects = cols
@@ -249,6 +274,27 @@ func (b *Builder) Delete(conds ...Cond) *Builder {
 
 // WriteTo implements Writer interface
 func (b *Builder) WriteTo(w Writer) error {
+	if len(b.ctes) > 0 {
+		if err := b.withWriteTo(w); err != nil {
+			return err
+		}
+		if b.optype == condType {
+			return nil
+		}
+
+		// erase common table expressions so that they will not be written again
+		// by the sub-queries which are generated from this builder
+		ctes := b.ctes
+		b.ctes = nil
+		defer func() {
+			b.ctes = ctes
+		}()
+
+		if _, err := fmt.Fprint(w, " "); err != nil {
+			return err
+		}
+	}
+
 	switch b.optype {
 	/*case condType:
 	return b.cond.WriteTo(w)*/
@@ -269,7 +315,7 @@ func (b *Builder) WriteTo(w Writer) error {
 
 // ToSQL convert a builder to SQL and args
 func (b *Builder) ToSQL() (string, []interface{}, error) {
-	w := NewWriter()
+	w := NewDialectWriter(b.dialect)
 	if err := b.WriteTo(w); err != nil {
 		return "", nil, err
 	}
@@ -312,7 +358,7 @@ func (b *Builder) ToSQL() (string, []interface{}, error) {
 
 // ToBoundSQL generated a bound SQL string
 func (b *Builder) ToBoundSQL() (string, error) {
-	w := NewWriter()
+	w := NewDialectWriter(b.dialect)
 	if err := b.WriteTo(w); err != nil {
 		return "", err
 	}
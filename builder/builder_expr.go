@@ -0,0 +1,67 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import "fmt"
+
+// columnExpr represents an expression which could be selected or ordered by,
+// i.e. window functions and portable functions
+type columnExpr interface {
+	writeExprTo(w Writer, b *Builder) error
+	exprAlias() string
+	isDesc() bool
+}
+
+func (b *Builder) selectExprsWriteTo(w Writer) error {
+	for i, e := range b.selectExprs {
+		if i > 0 || len(b.selects) > 0 {
+			if _, err := fmt.Fprint(w, ","); err != nil {
+				return err
+			}
+		}
+		if err := e.writeExprTo(w, b); err != nil {
+			return err
+		}
+		if alias := e.exprAlias(); len(alias) > 0 {
+			if _, err := fmt.Fprint(w, " AS ", alias); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (b *Builder) orderExprsWriteTo(w Writer) error {
+	for i, e := range b.orderExprs {
+		if i > 0 || len(b.orderBy) > 0 {
+			if _, err := fmt.Fprint(w, ","); err != nil {
+				return err
+			}
+		}
+		if err := e.writeExprTo(w, b); err != nil {
+			return err
+		}
+		if e.isDesc() {
+			if _, err := fmt.Fprint(w, " DESC"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// exprAliases returns the aliases of the selected expressions which are
+// required by the outer query when the limitation wraps the builder
+func (b *Builder) exprAliases() ([]string, error) {
+	var aliases = make([]string, 0, len(b.selectExprs))
+	for _, e := range b.selectExprs {
+		alias := e.exprAlias()
+		if len(alias) == 0 {
+			return nil, ErrUnnamedWindow
+		}
+		aliases = append(aliases, alias)
+	}
+	return aliases, nil
+}
@@ -0,0 +1,372 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Func describes a portable SQL function expression which will be rendered
+// to the native SQL of the dialect when it's written
+type Func struct {
+	render func(w Writer, dialect string) error
+	alias  string
+	desc   bool
+}
+
+var _ Cond = &Func{}
+
+type funcValue struct {
+	v interface{}
+}
+
+// Value wraps a value so that it will be passed as a parameter of a function,
+// since the string arguments of functions are treated as columns or raw SQL
+func Value(v interface{}) interface{} {
+	return funcValue{v}
+}
+
+// writeFuncArg writes an argument of the function, strings are columns or raw SQL,
+// functions, expressions and sub-queries are written inline and the others are parameters
+func writeFuncArg(w Writer, dialect string, arg interface{}) error {
+	switch t := arg.(type) {
+	case string:
+		if _, err := fmt.Fprint(w, t); err != nil {
+			return err
+		}
+	case *Func:
+		return t.writeTo(w, dialect)
+	case expr:
+		return t.WriteTo(w)
+	case *Builder:
+		if _, err := fmt.Fprint(w, "("); err != nil {
+			return err
+		}
+		if err := t.WriteTo(w); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprint(w, ")"); err != nil {
+			return err
+		}
+	case funcValue:
+		if _, err := fmt.Fprint(w, "?"); err != nil {
+			return err
+		}
+		w.Append(t.v)
+	default:
+		if _, err := fmt.Fprint(w, "?"); err != nil {
+			return err
+		}
+		w.Append(arg)
+	}
+	return nil
+}
+
+func writeFuncArgs(w Writer, dialect, sep string, args []interface{}) error {
+	for i, arg := range args {
+		if i > 0 {
+			if _, err := fmt.Fprint(w, sep); err != nil {
+				return err
+			}
+		}
+		if err := writeFuncArg(w, dialect, arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Fn creates a function expression which is the same on all the dialects,
+// i.e. Fn("UPPER", "name")
+func Fn(name string, args ...interface{}) *Func {
+	return &Func{render: func(w Writer, dialect string) error {
+		if _, err := fmt.Fprint(w, name, "("); err != nil {
+			return err
+		}
+		if err := writeFuncArgs(w, dialect, ",", args); err != nil {
+			return err
+		}
+		_, err := fmt.Fprint(w, ")")
+		return err
+	}}
+}
+
+// Coalesce creates a COALESCE function expression
+func Coalesce(args ...interface{}) *Func {
+	return Fn("COALESCE", args...)
+}
+
+// Now creates the current timestamp expression
+func Now() *Func {
+	return &Func{render: func(w Writer, dialect string) error {
+		_, err := fmt.Fprint(w, "CURRENT_TIMESTAMP")
+		return err
+	}}
+}
+
+// Concat creates a string concatenation expression
+func Concat(args ...interface{}) *Func {
+	return &Func{render: func(w Writer, dialect string) error {
+		switch dialect {
+		case SQLITE, ORACLE:
+			if _, err := fmt.Fprint(w, "("); err != nil {
+				return err
+			}
+			if err := writeFuncArgs(w, dialect, " || ", args); err != nil {
+				return err
+			}
+			_, err := fmt.Fprint(w, ")")
+			return err
+		default:
+			return Fn("CONCAT", args...).writeTo(w, dialect)
+		}
+	}}
+}
+
+// Random creates a random number expression which is usually used to order randomly
+func Random() *Func {
+	return &Func{render: func(w Writer, dialect string) error {
+		var fn string
+		switch dialect {
+		case MYSQL:
+			fn = "RAND()"
+		case POSTGRES, SQLITE:
+			fn = "RANDOM()"
+		case MSSQL:
+			fn = "NEWID()"
+		case ORACLE:
+			fn = "DBMS_RANDOM.VALUE"
+		default:
+			return ErrUnsupportedFunc
+		}
+		_, err := fmt.Fprint(w, fn)
+		return err
+	}}
+}
+
+// dateTruncFormats are the formats of MySQL and SQLite to truncate datetime by unit
+var dateTruncFormats = map[string][2]string{
+	"year":   {"%Y-01-01 00:00:00", "%Y-01-01 00:00:00"},
+	"month":  {"%Y-%m-01 00:00:00", "%Y-%m-01 00:00:00"},
+	"day":    {"%Y-%m-%d 00:00:00", "%Y-%m-%d 00:00:00"},
+	"hour":   {"%Y-%m-%d %H:00:00", "%Y-%m-%d %H:00:00"},
+	"minute": {"%Y-%m-%d %H:%i:00", "%Y-%m-%d %H:%M:00"},
+	"second": {"%Y-%m-%d %H:%i:%s", "%Y-%m-%d %H:%M:%S"},
+}
+
+var oracleTruncFormats = map[string]string{
+	"year":   "YYYY",
+	"month":  "MM",
+	"day":    "DD",
+	"hour":   "HH24",
+	"minute": "MI",
+}
+
+// DateTrunc creates an expression which truncates the datetime column to the unit,
+// the unit could be year, month, day, hour, minute or second
+func DateTrunc(unit string, col interface{}) *Func {
+	unit = strings.ToLower(unit)
+	return &Func{render: func(w Writer, dialect string) error {
+		formats, ok := dateTruncFormats[unit]
+		if !ok {
+			return ErrUnsupportedFunc
+		}
+
+		var prefix, suffix string
+		switch dialect {
+		case POSTGRES:
+			prefix, suffix = "DATE_TRUNC('"+unit+"',", ")"
+		case MYSQL:
+			prefix, suffix = "CAST(DATE_FORMAT(", ",'"+formats[0]+"') AS DATETIME)"
+		case SQLITE:
+			prefix, suffix = "strftime('"+formats[1]+"',", ")"
+		case MSSQL:
+			// DATEDIFF by second from 0 overflows the integer
+			base := "0"
+			if unit == "second" {
+				base = "'2000-01-01'"
+			}
+			prefix = fmt.Sprintf("DATEADD(%s,DATEDIFF(%s,%s,", unit, unit, base)
+			suffix = fmt.Sprintf("),%s)", base)
+		case ORACLE:
+			if format, ok := oracleTruncFormats[unit]; ok {
+				prefix, suffix = "TRUNC(", ",'"+format+"')"
+			} else {
+				prefix, suffix = "CAST(", " AS DATE)"
+			}
+		default:
+			return ErrUnsupportedFunc
+		}
+
+		if _, err := fmt.Fprint(w, prefix); err != nil {
+			return err
+		}
+		if err := writeFuncArg(w, dialect, col); err != nil {
+			return err
+		}
+		_, err := fmt.Fprint(w, suffix)
+		return err
+	}}
+}
+
+var jsonPathRegexp = regexp.MustCompile(`^\$(\.[A-Za-z_][A-Za-z0-9_]*|\[[0-9]+\])*$`)
+
+// JSONExtract creates an expression which extracts the scalar value at the path
+// of the JSON column as text, the path looks like $.a.b[0]
+func JSONExtract(col interface{}, path string) *Func {
+	return &Func{render: func(w Writer, dialect string) error {
+		if !jsonPathRegexp.MatchString(path) {
+			return ErrInvalidJSONPath
+		}
+
+		var prefix, suffix string
+		switch dialect {
+		case MYSQL:
+			prefix, suffix = "JSON_UNQUOTE(JSON_EXTRACT(", ",'"+path+"'))"
+		case POSTGRES:
+			prefix, suffix = "(", " #>> '{"+strings.Join(jsonPathKeys(path), ",")+"}')"
+		case SQLITE:
+			prefix, suffix = "json_extract(", ",'"+path+"')"
+		case MSSQL, ORACLE:
+			prefix, suffix = "JSON_VALUE(", ",'"+path+"')"
+		default:
+			return ErrUnsupportedFunc
+		}
+
+		if _, err := fmt.Fprint(w, prefix); err != nil {
+			return err
+		}
+		if err := writeFuncArg(w, dialect, col); err != nil {
+			return err
+		}
+		_, err := fmt.Fprint(w, suffix)
+		return err
+	}}
+}
+
+// jsonPathKeys converts a valid JSON path to the keys, i.e. $.a[0].b to a, 0, b
+func jsonPathKeys(path string) []string {
+	var keys []string
+	for _, part := range strings.FieldsFunc(path[1:], func(r rune) bool {
+		return r == '.' || r == '['
+	}) {
+		keys = append(keys, strings.TrimSuffix(part, "]"))
+	}
+	return keys
+}
+
+// As sets the alias of the function when it's selected
+func (f *Func) As(alias string) *Func {
+	f.alias = alias
+	return f
+}
+
+// Desc sorts descending when the function is used in ORDER BY
+func (f *Func) Desc() *Func {
+	f.desc = true
+	return f
+}
+
+// Compare creates a condition which compares the function with the value,
+// op could be =, <>, >, <, <=, >= and etc.
+func (f *Func) Compare(op string, value interface{}) Cond {
+	return condFunc{f, op, value}
+}
+
+func (f *Func) writeTo(w Writer, dialect string) error {
+	return f.render(w, dialect)
+}
+
+// WriteTo writes SQL to Writer according to the dialect of the writer
+func (f *Func) WriteTo(w Writer) error {
+	return f.writeTo(w, writerDialect(w))
+}
+
+// And implements And with other conditions
+func (f *Func) And(conds ...Cond) Cond {
+	return And(f, And(conds...))
+}
+
+// Or implements Or with other conditions
+func (f *Func) Or(conds ...Cond) Cond {
+	return Or(f, Or(conds...))
+}
+
+// IsValid tests if this function is valid
+func (f *Func) IsValid() bool {
+	return f != nil && f.render != nil
+}
+
+func (f *Func) writeExprTo(w Writer, b *Builder) error {
+	dialect := b.dialect
+	if dialect == "" {
+		dialect = writerDialect(w)
+	}
+	return f.writeTo(w, dialect)
+}
+
+func (f *Func) exprAlias() string {
+	return f.alias
+}
+
+func (f *Func) isDesc() bool {
+	return f.desc
+}
+
+// SelectExpr appends function expressions to the select columns
+func (b *Builder) SelectExpr(funcs ...*Func) *Builder {
+	for _, f := range funcs {
+		b.selectExprs = append(b.selectExprs, f)
+	}
+	if b.optype == condType {
+		b.optype = selectType
+	}
+	return b
+}
+
+// OrderByExpr appends function expressions to ORDER BY
+func (b *Builder) OrderByExpr(funcs ...*Func) *Builder {
+	for _, f := range funcs {
+		b.orderExprs = append(b.orderExprs, f)
+	}
+	return b
+}
+
+type condFunc struct {
+	f     *Func
+	op    string
+	value interface{}
+}
+
+var _ Cond = condFunc{}
+
+func (c condFunc) WriteTo(w Writer) error {
+	dialect := writerDialect(w)
+	if err := c.f.writeTo(w, dialect); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprint(w, c.op); err != nil {
+		return err
+	}
+	if s, ok := c.value.(string); ok {
+		// strings are compared as values rather than columns
+		return writeFuncArg(w, dialect, Value(s))
+	}
+	return writeFuncArg(w, dialect, c.value)
+}
+
+func (c condFunc) And(conds ...Cond) Cond {
+	return And(c, And(conds...))
+}
+
+func (c condFunc) Or(conds ...Cond) Cond {
+	return Or(c, Or(conds...))
+}
+
+func (c condFunc) IsValid() bool {
+	return c.f.IsValid() && len(c.op) > 0
+}
@@ -0,0 +1,103 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder_FuncDialect(t *testing.T) {
+	var cases = []struct {
+		f       *Func
+		dialect string
+		sql     string
+	}{
+		{DateTrunc("day", "created"), POSTGRES, "DATE_TRUNC('day',created)"},
+		{DateTrunc("day", "created"), MYSQL, "CAST(DATE_FORMAT(created,'%Y-%m-%d 00:00:00') AS DATETIME)"},
+		{DateTrunc("minute", "created"), SQLITE, "strftime('%Y-%m-%d %H:%M:00',created)"},
+		{DateTrunc("month", "created"), MSSQL, "DATEADD(month,DATEDIFF(month,0,created),0)"},
+		{DateTrunc("second", "created"), MSSQL, "DATEADD(second,DATEDIFF(second,'2000-01-01',created),'2000-01-01')"},
+		{DateTrunc("Year", "created"), ORACLE, "TRUNC(created,'YYYY')"},
+		{Concat("first", Value(" "), "last"), MYSQL, "CONCAT(first,?,last)"},
+		{Concat("first", Value(" "), "last"), SQLITE, "(first || ? || last)"},
+		{Concat("first", "last"), ORACLE, "(first || last)"},
+		{Coalesce("nickname", "name"), MSSQL, "COALESCE(nickname,name)"},
+		{Now(), ORACLE, "CURRENT_TIMESTAMP"},
+		{Random(), MYSQL, "RAND()"},
+		{Random(), MSSQL, "NEWID()"},
+		{Random(), ORACLE, "DBMS_RANDOM.VALUE"},
+		{JSONExtract("doc", "$.a.b[0]"), MYSQL, "JSON_UNQUOTE(JSON_EXTRACT(doc,'$.a.b[0]'))"},
+		{JSONExtract("doc", "$.a.b[0]"), POSTGRES, "(doc #>> '{a,b,0}')"},
+		{JSONExtract("doc", "$.a"), SQLITE, "json_extract(doc,'$.a')"},
+		{JSONExtract("doc", "$.a"), MSSQL, "JSON_VALUE(doc,'$.a')"},
+		{Fn("UPPER", Concat("a", "b")), SQLITE, "UPPER((a || b))"},
+	}
+
+	for _, c := range cases {
+		w := NewDialectWriter(c.dialect)
+		assert.NoError(t, c.f.WriteTo(w))
+		assert.EqualValues(t, c.sql, w.String())
+	}
+
+	w := NewDialectWriter(MYSQL)
+	assert.EqualValues(t, ErrInvalidJSONPath, JSONExtract("doc", "$.a') OR 1=1 --").WriteTo(w))
+	assert.EqualValues(t, ErrUnsupportedFunc, DateTrunc("week", "created").WriteTo(w))
+	assert.EqualValues(t, ErrUnsupportedFunc, Random().WriteTo(NewWriter()))
+}
+
+func TestBuilder_Func(t *testing.T) {
+	sql, args, err := SQLite().Select("id").From("account").
+		SelectExpr(Coalesce("nickname", "name").As("display"), DateTrunc("day", "created").As("day")).
+		Where(Eq{"status": 1}.And(DateTrunc("day", "created").Compare(">=", "2020-01-01"))).
+		OrderByExpr(Random()).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT id,COALESCE(nickname,name) AS display,strftime('%Y-%m-%d 00:00:00',created) AS day FROM account "+
+		"WHERE status=? AND strftime('%Y-%m-%d 00:00:00',created)>=? ORDER BY RANDOM()", sql)
+	assert.EqualValues(t, []interface{}{1, "2020-01-01"}, args)
+
+	sql, args, err = Postgres().Update(Eq{"updated": Now(), "name": Concat("name", Value("!"))}).
+		From("account").Where(Neq{"id": 1, "created": Now()}).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "UPDATE account SET name=CONCAT(name,$1),updated=CURRENT_TIMESTAMP WHERE created<>CURRENT_TIMESTAMP AND id<>$2", sql)
+	assert.EqualValues(t, []interface{}{"!", 1}, args)
+
+	sql, args, err = MySQL().Select("id").From("account").
+		Where(Eq{"a": 1}.And(Gt{"b": Concat("c", Value("d"))})).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT id FROM account WHERE a=? AND b>CONCAT(c,?)", sql)
+	assert.EqualValues(t, []interface{}{1, "d"}, args)
+}
+
+func TestBuilder_LimitWithTies(t *testing.T) {
+	sql, args, err := MsSQL().Select("id", "score").From("player").OrderBy("score DESC").LimitWithTies(3).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT TOP 3 WITH TIES id,score FROM player ORDER BY score DESC", sql)
+	assert.EqualValues(t, 0, len(args))
+
+	sql, _, err = Postgres().Select("id", "score").From("player").OrderBy("score DESC").LimitWithTies(3).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT id,score FROM player ORDER BY score DESC FETCH FIRST 3 ROWS WITH TIES", sql)
+
+	sql, _, err = Oracle().Select("id", "score").From("player").OrderBy("score DESC").LimitWithTies(3).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT id,score FROM player ORDER BY score DESC FETCH FIRST 3 ROWS WITH TIES", sql)
+
+	b := SQLite().Select("id", "score").From("player").Where(Gt{"score": 0}).OrderBy("score DESC").LimitWithTies(3)
+	sql, args, err = b.ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT id,score FROM (SELECT id,score,RANK() OVER (ORDER BY score DESC) AS tie_rank "+
+		"FROM player WHERE score>? ORDER BY score DESC) at WHERE at.tie_rank<=? ORDER BY at.tie_rank", sql)
+	assert.EqualValues(t, []interface{}{0, 3}, args)
+
+	// the builder could be written again
+	sql2, _, err := b.ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, sql, sql2)
+
+	_, _, err = MySQL().Select("id").From("player").LimitWithTies(3).ToSQL()
+	assert.EqualValues(t, ErrNoOrderForTies, err)
+}
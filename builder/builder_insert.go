@@ -5,9 +5,9 @@
 package builder
 
 import (
-	"bytes"
 	"fmt"
 	"sort"
+	"strings"
 )
 
 // Insert creates an insert Builder
@@ -44,51 +44,104 @@ func (b *Builder) insertWriteTo(w Writer) error {
 		return b.insertSelectWriteTo(w)
 	}
 
-	if _, err := fmt.Fprintf(w, "INSERT INTO %s (", b.into); err != nil {
+	rows, err := b.insertRowValues()
+	if err != nil {
 		return err
 	}
 
-	var args = make([]interface{}, 0)
-	var bs []byte
-	var valBuffer = bytes.NewBuffer(bs)
-
-	for i, col := range b.insertCols {
-		value := b.insertVals[i]
-		fmt.Fprint(w, col)
-		if e, ok := value.(expr); ok {
-			fmt.Fprintf(valBuffer, "(%s)", e.sql)
-			args = append(args, e.args...)
-		} else if value == nil {
-			fmt.Fprintf(valBuffer, `null`)
-		} else {
-			fmt.Fprint(valBuffer, "?")
-			args = append(args, value)
-		}
+	if b.upsert != nil && (b.dialect == MSSQL || b.dialect == ORACLE) {
+		return b.mergeWriteTo(w, rows)
+	}
+	if len(rows) > 1 && b.dialect == ORACLE {
+		return b.insertAllWriteTo(w, rows)
+	}
+
+	if _, err := fmt.Fprintf(w, "INSERT INTO %s (%s) Values ", b.into, strings.Join(b.insertCols, ",")); err != nil {
+		return err
+	}
 
-		if i != len(b.insertCols)-1 {
+	for i, row := range rows {
+		if i > 0 {
 			if _, err := fmt.Fprint(w, ","); err != nil {
 				return err
 			}
-			if _, err := fmt.Fprint(valBuffer, ","); err != nil {
-				return err
-			}
+		}
+		if err := writeInsertValues(w, row); err != nil {
+			return err
 		}
 	}
 
-	if _, err := fmt.Fprint(w, ") Values ("); err != nil {
-		return err
+	if b.upsert != nil {
+		return b.upsertWriteTo(w)
 	}
+	return nil
+}
 
-	if _, err := w.Write(valBuffer.Bytes()); err != nil {
-		return err
+// insertRowValues returns the rows of the values to be inserted
+func (b *Builder) insertRowValues() ([][]interface{}, error) {
+	if len(b.insertRows) == 0 {
+		if len(b.insertVals) != len(b.insertCols) {
+			return nil, ErrInsertValuesMismatch
+		}
+		return [][]interface{}{b.insertVals}, nil
 	}
-	if _, err := fmt.Fprint(w, ")"); err != nil {
-		return err
+
+	for _, row := range b.insertRows {
+		if len(row) != len(b.insertCols) {
+			return nil, ErrInsertValuesMismatch
+		}
 	}
+	return b.insertRows, nil
+}
 
-	w.Append(args...)
+func writeInsertValue(w Writer, value interface{}) error {
+	var err error
+	if e, ok := value.(expr); ok {
+		_, err = fmt.Fprintf(w, "(%s)", e.sql)
+		w.Append(e.args...)
+	} else if value == nil {
+		_, err = fmt.Fprint(w, "null")
+	} else {
+		_, err = fmt.Fprint(w, "?")
+		w.Append(value)
+	}
+	return err
+}
 
-	return nil
+// writeInsertValues writes a row of values, i.e. (?,?,null)
+func writeInsertValues(w Writer, row []interface{}) error {
+	if _, err := fmt.Fprint(w, "("); err != nil {
+		return err
+	}
+	for i, value := range row {
+		if i > 0 {
+			if _, err := fmt.Fprint(w, ","); err != nil {
+				return err
+			}
+		}
+		if err := writeInsertValue(w, value); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprint(w, ")")
+	return err
+}
+
+// insertAllWriteTo writes multiple rows for Oracle which doesn't support multi-row VALUES
+func (b *Builder) insertAllWriteTo(w Writer, rows [][]interface{}) error {
+	if _, err := fmt.Fprint(w, "INSERT ALL"); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if _, err := fmt.Fprintf(w, " INTO %s (%s) VALUES ", b.into, strings.Join(b.insertCols, ",")); err != nil {
+			return err
+		}
+		if err := writeInsertValues(w, row); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprint(w, " SELECT 1 FROM DUAL")
+	return err
 }
 
 type insertColsSorter struct {
@@ -147,3 +200,18 @@ func (b *Builder) Insert(eq ...interface{}) *Builder {
 	b.optype = insertType
 	return b
 }
+
+// Columns sets the columns of insert SQL, the values could be set by Values
+func (b *Builder) Columns(cols ...string) *Builder {
+	b.insertCols = cols
+	b.optype = insertType
+	return b
+}
+
+// Values appends a row of values to insert SQL, the values should be in the
+// same order of Columns
+func (b *Builder) Values(vals ...interface{}) *Builder {
+	b.insertRows = append(b.insertRows, vals)
+	b.optype = insertType
+	return b
+}
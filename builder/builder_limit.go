@@ -19,6 +19,9 @@ func (b *Builder) limitWriteTo(w Writer) error {
 		if limit.offset < 0 || limit.limitN <= 0 {
 			return ErrInvalidLimitation
 		}
+		if limit.withTies {
+			return b.tiesWriteTo(w)
+		}
 		// erase limit condition
 		b.limitation = nil
 		defer func() {
@@ -33,8 +36,11 @@ func (b *Builder) limitWriteTo(w Writer) error {
 			}
 
 			var final *Builder
-			selects := b.selects
-			b.selects = append(selects, "ROWNUM RN")
+			selects, err := b.limitSelects()
+			if err != nil {
+				return err
+			}
+			b.selects = append(b.selects, "ROWNUM RN")
 
 			var wb *Builder
 			if b.optype == setOpType {
@@ -75,7 +81,10 @@ func (b *Builder) limitWriteTo(w Writer) error {
 			}
 
 			var final *Builder
-			selects := b.selects
+			selects, err := b.limitSelects()
+			if err != nil {
+				return err
+			}
 			b.selects = append(append([]string{fmt.Sprintf("TOP %d %v", limit.limitN+limit.offset, b.selects[0])},
 				b.selects[1:]...), "ROW_NUMBER() OVER (ORDER BY (SELECT 1)) AS RN")
 
@@ -101,3 +110,61 @@ func (b *Builder) limitWriteTo(w Writer) error {
 
 	return nil
 }
+
+// limitSelects returns the columns which the outer query of the limitation selects
+func (b *Builder) limitSelects() ([]string, error) {
+	if len(b.selectExprs) == 0 || (len(b.selects) == 1 && b.selects[0] == "*") {
+		return b.selects, nil
+	}
+	aliases, err := b.exprAliases()
+	if err != nil {
+		return nil, err
+	}
+	return append(append(make([]string, 0, len(b.selects)+len(aliases)), b.selects...), aliases...), nil
+}
+
+// tiesWriteTo writes the limitation with ties of Postgres and Oracle,
+// MSSQL has written TOP n WITH TIES at the beginning
+func (b *Builder) tiesWriteTo(w Writer) error {
+	if b.optype == setOpType {
+		return ErrNotSupportType
+	}
+	switch b.dialect {
+	case POSTGRES, ORACLE:
+		_, err := fmt.Fprintf(w, " FETCH FIRST %d ROWS WITH TIES", b.limitation.limitN)
+		return err
+	case MSSQL:
+		return nil
+	default:
+		return ErrNotSupportType
+	}
+}
+
+// rankTiesWriteTo ranks the rows by ORDER BY and keeps the rows whose rank is
+// not greater than the limitation, the rank is selected as tie_rank
+func (b *Builder) rankTiesWriteTo(w Writer) error {
+	limit := b.limitation
+	if limit.limitN <= 0 {
+		return ErrInvalidLimitation
+	}
+
+	selects, exprs := b.selects, b.selectExprs
+	b.limitation = nil
+	defer func() {
+		b.limitation, b.selects, b.selectExprs = limit, selects, exprs
+	}()
+
+	if len(b.selects) == 0 {
+		b.selects = []string{"*"}
+	}
+	outerSelects, err := b.limitSelects()
+	if err != nil {
+		return err
+	}
+	b.selectExprs = append(exprs[:len(exprs):len(exprs)], Rank().OrderBy(b.orderBy).As("tie_rank"))
+
+	return Dialect(b.dialect).Version(b.version).Select(outerSelects...).From(b, "at").
+		Where(Lte{"at.tie_rank": limit.limitN}).
+		OrderBy("at.tie_rank").
+		WriteTo(w)
+}
@@ -0,0 +1,128 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import (
+	"fmt"
+	"strings"
+)
+
+type lock struct {
+	shared     bool
+	noWait     bool
+	skipLocked bool
+}
+
+func (b *Builder) setLock(f func(l *lock)) *Builder {
+	if b.lock == nil {
+		b.lock = &lock{}
+	}
+	f(b.lock)
+	return b
+}
+
+// ForUpdate locks the selected rows for update
+func (b *Builder) ForUpdate() *Builder {
+	return b.setLock(func(l *lock) {
+		l.shared = false
+	})
+}
+
+// ForShare locks the selected rows in share mode
+func (b *Builder) ForShare() *Builder {
+	return b.setLock(func(l *lock) {
+		l.shared = true
+	})
+}
+
+// NoWait makes the locking fail immediately when the rows are locked by others,
+// the rows are locked for update if no lock set
+func (b *Builder) NoWait() *Builder {
+	return b.setLock(func(l *lock) {
+		l.noWait = true
+	})
+}
+
+// SkipLocked skips the rows which are locked by others, the rows are locked
+// for update if no lock set
+func (b *Builder) SkipLocked() *Builder {
+	return b.setLock(func(l *lock) {
+		l.skipLocked = true
+	})
+}
+
+func (l *lock) validate(dialect string) error {
+	if l.noWait && l.skipLocked {
+		return ErrUnsupportedLockMode
+	}
+	switch dialect {
+	case SQLITE:
+		// SQLite locks the whole database in a write transaction
+		if l.shared || l.noWait || l.skipLocked {
+			return ErrUnsupportedLockMode
+		}
+	case ORACLE:
+		if l.shared {
+			return ErrUnsupportedLockMode
+		}
+	}
+	return nil
+}
+
+// lockHintWriteTo writes the table hint of MSSQL
+func (b *Builder) lockHintWriteTo(w Writer) error {
+	if b.lock == nil || b.dialect != MSSQL {
+		return nil
+	}
+	if err := b.lock.validate(b.dialect); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprint(w, LockHint(b.lock.shared, b.lock.noWait, b.lock.skipLocked))
+	return err
+}
+
+// LockHint returns the table hint of MSSQL for the lock, i.e. WITH (UPDLOCK, ROWLOCK)
+func LockHint(shared, noWait, skipLocked bool) string {
+	var hints = []string{"UPDLOCK", "ROWLOCK"}
+	if shared {
+		hints = []string{"HOLDLOCK", "ROWLOCK"}
+	}
+	if noWait {
+		hints = append(hints, "NOWAIT")
+	} else if skipLocked {
+		hints = append(hints, "READPAST")
+	}
+	return " WITH (" + strings.Join(hints, ", ") + ")"
+}
+
+// lockWriteTo writes the locking clause at the end of SELECT
+func (b *Builder) lockWriteTo(w Writer) error {
+	if b.lock == nil || b.dialect == MSSQL {
+		return nil
+	}
+	if err := b.lock.validate(b.dialect); err != nil {
+		return err
+	}
+	if b.dialect == SQLITE {
+		return nil
+	}
+
+	var clause = " FOR UPDATE"
+	if b.lock.shared {
+		if b.dialect == MYSQL && !b.lock.noWait && !b.lock.skipLocked {
+			clause = " LOCK IN SHARE MODE"
+		} else {
+			clause = " FOR SHARE"
+		}
+	}
+	if b.lock.noWait {
+		clause += " NOWAIT"
+	} else if b.lock.skipLocked {
+		clause += " SKIP LOCKED"
+	}
+	_, err := fmt.Fprint(w, clause)
+	return err
+}
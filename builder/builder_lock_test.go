@@ -0,0 +1,59 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder_Lock(t *testing.T) {
+	sql, args, err := MySQL().Select("id").From("job").Where(Eq{"status": 0}).
+		OrderBy("id").Limit(10).ForUpdate().SkipLocked().ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT id FROM job WHERE status=? ORDER BY id LIMIT 10 FOR UPDATE SKIP LOCKED", sql)
+	assert.EqualValues(t, []interface{}{0}, args)
+
+	sql, _, err = MySQL().Select("id").From("job").ForShare().ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT id FROM job LOCK IN SHARE MODE", sql)
+
+	sql, _, err = MySQL().Select("id").From("job").ForShare().NoWait().ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT id FROM job FOR SHARE NOWAIT", sql)
+
+	sql, _, err = Postgres().Select("id").From("job").Where(Eq{"status": 0}).NoWait().ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT id FROM job WHERE status=$1 FOR UPDATE NOWAIT", sql)
+
+	sql, _, err = Oracle().Select("id").From("job").SkipLocked().ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT id FROM job FOR UPDATE SKIP LOCKED", sql)
+
+	_, _, err = Oracle().Select("id").From("job").ForShare().ToSQL()
+	assert.EqualValues(t, ErrUnsupportedLockMode, err)
+
+	_, _, err = Oracle().Select("id").From("job").ForUpdate().Limit(1).ToSQL()
+	assert.EqualValues(t, ErrUnsupportedLockMode, err)
+
+	sql, _, err = MsSQL().Select("id").From("job").Where(Eq{"status": 0}).ForUpdate().SkipLocked().ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT id FROM job WITH (UPDLOCK, ROWLOCK, READPAST) WHERE status=@p1", sql)
+
+	sql, _, err = MsSQL().Select("id").From("job").ForShare().Limit(5).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT id FROM (SELECT TOP 5 id,ROW_NUMBER() OVER (ORDER BY (SELECT 1)) AS RN FROM job WITH (HOLDLOCK, ROWLOCK)) at", sql)
+
+	sql, _, err = SQLite().Select("id").From("job").ForUpdate().ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT id FROM job", sql)
+
+	_, _, err = SQLite().Select("id").From("job").SkipLocked().ToSQL()
+	assert.EqualValues(t, ErrUnsupportedLockMode, err)
+
+	_, _, err = Postgres().Select("id").From("job").NoWait().SkipLocked().ToSQL()
+	assert.EqualValues(t, ErrUnsupportedLockMode, err)
+}
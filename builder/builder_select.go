@@ -19,16 +19,33 @@ func (b *Builder) selectWriteTo(w Writer) error {
 		return ErrNoTableName
 	}
 
-	// perform limit before writing to writer when b.dialect between ORACLE and MSSQL
-	// this avoid a duplicate writing problem in simple limit query
-	if b.limitation != nil && (b.dialect == ORACLE || b.dialect == MSSQL) {
+	if b.limitation != nil && b.limitation.withTies {
+		if len(b.orderBy) == 0 {
+			return ErrNoOrderForTies
+		}
+		// MySQL and SQLite don't support WITH TIES, the rows are ranked instead
+		if b.dialect == MYSQL || b.dialect == SQLITE {
+			return b.rankTiesWriteTo(w)
+		}
+	} else if b.limitation != nil && (b.dialect == ORACLE || b.dialect == MSSQL) {
+		// perform limit before writing to writer when b.dialect between ORACLE and MSSQL
+		// this avoid a duplicate writing problem in simple limit query
+		// Oracle cannot lock the rows of the view which is generated by the limitation
+		if b.lock != nil && b.dialect == ORACLE {
+			return ErrUnsupportedLockMode
+		}
 		return b.limitWriteTo(w)
 	}
 
 	if _, err := fmt.Fprint(w, "SELECT "); err != nil {
 		return err
 	}
-	if len(b.selects) > 0 {
+	if b.limitation != nil && b.limitation.withTies && b.dialect == MSSQL {
+		if _, err := fmt.Fprintf(w, "TOP %d WITH TIES ", b.limitation.limitN); err != nil {
+			return err
+		}
+	}
+	if len(b.selects) > 0 || len(b.selectExprs) > 0 {
 		for i, s := range b.selects {
 			if _, err := fmt.Fprint(w, s); err != nil {
 				return err
@@ -39,6 +56,9 @@ func (b *Builder) selectWriteTo(w Writer) error {
 				}
 			}
 		}
+		if err := b.selectExprsWriteTo(w); err != nil {
+			return err
+		}
 	} else {
 		if _, err := fmt.Fprint(w, "*"); err != nil {
 			return err
@@ -49,6 +69,9 @@ func (b *Builder) selectWriteTo(w Writer) error {
 		if _, err := fmt.Fprint(w, " FROM ", b.from); err != nil {
 			return err
 		}
+		if err := b.lockHintWriteTo(w); err != nil {
+			return err
+		}
 	} else {
 		if b.cond.IsValid() && len(b.from) <= 0 {
 			return ErrUnnamedDerivedTable
@@ -61,6 +84,9 @@ func (b *Builder) selectWriteTo(w Writer) error {
 		if b.dialect != "" && b.subQuery.dialect == "" {
 			b.subQuery.dialect = b.dialect
 		}
+		if b.subQuery.version == "" {
+			b.subQuery.version = b.version
+		}
 
 		switch b.subQuery.optype {
 		case selectType, setOpType:
@@ -124,10 +150,13 @@ func (b *Builder) selectWriteTo(w Writer) error {
 		}
 	}
 
-	if len(b.orderBy) > 0 {
+	if len(b.orderBy) > 0 || len(b.orderExprs) > 0 {
 		if _, err := fmt.Fprint(w, " ORDER BY ", b.orderBy); err != nil {
 			return err
 		}
+		if err := b.orderExprsWriteTo(w); err != nil {
+			return err
+		}
 	}
 
 	if b.limitation != nil {
@@ -136,7 +165,7 @@ func (b *Builder) selectWriteTo(w Writer) error {
 		}
 	}
 
-	return nil
+	return b.lockWriteTo(w)
 }
 
 // OrderBy orderBy SQL
@@ -10,6 +10,11 @@ import (
 )
 
 func (b *Builder) setOpWriteTo(w Writer) error {
+	return b.writeSetOps(w, true)
+}
+
+// writeSetOps writes the set operations, the members are parenthesized if wrap is true
+func (b *Builder) writeSetOps(w Writer, wrap bool) error {
 	if b.limitation != nil || b.cond.IsValid() ||
 		b.orderBy != "" || b.having != "" || b.groupBy != "" {
 		return ErrNotUnexpectedUnionConditions
@@ -37,13 +42,17 @@ func (b *Builder) setOpWriteTo(w Writer) error {
 					fmt.Fprint(w, fmt.Sprintf(" %s %s ", strings.ToUpper(o.opType), strings.ToUpper(o.distinctType)))
 				}
 			}
-			fmt.Fprint(w, "(")
+			if wrap {
+				fmt.Fprint(w, "(")
+			}
 
 			if err := current.selectWriteTo(w); err != nil {
 				return err
 			}
 
-			fmt.Fprint(w, ")")
+			if wrap {
+				fmt.Fprint(w, ")")
+			}
 		}
 	}
 
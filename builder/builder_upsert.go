@@ -0,0 +1,231 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Excluded references the value of the column which was proposed for insertion,
+// it could be used as the value of DoUpdate, i.e. Eq{"name": Excluded("name")}
+type Excluded string
+
+type upsert struct {
+	cols      []string
+	sets      Eq
+	doNothing bool
+}
+
+func (b *Builder) getUpsert() *upsert {
+	if b.upsert == nil {
+		b.upsert = &upsert{sets: Eq{}}
+	}
+	return b.upsert
+}
+
+// OnConflict sets the unique columns by which the conflicts are detected, then DoUpdate
+// or DoNothing decides what to do with the conflicted rows. MySQL ignores the columns,
+// MSSQL and Oracle generate MERGE statement with the columns.
+func (b *Builder) OnConflict(cols ...string) *Builder {
+	b.getUpsert().cols = cols
+	return b
+}
+
+// DoUpdate updates the conflicted rows
+func (b *Builder) DoUpdate(sets ...Eq) *Builder {
+	u := b.getUpsert()
+	for _, set := range sets {
+		for k, v := range set {
+			u.sets[k] = v
+		}
+	}
+	u.doNothing = false
+	return b
+}
+
+// DoNothing keeps the conflicted rows
+func (b *Builder) DoNothing() *Builder {
+	b.getUpsert().doNothing = true
+	return b
+}
+
+// OnDuplicateKeyUpdate updates the conflicted rows, it's the same as DoUpdate and
+// the conflict columns should be set by OnConflict for the dialects except MySQL
+func (b *Builder) OnDuplicateKeyUpdate(sets ...Eq) *Builder {
+	return b.DoUpdate(sets...)
+}
+
+// writeSets writes the assignments of the conflicted rows, target is used to qualify
+// the columns of the existing rows and excluded formats the columns proposed for insertion
+func (u *upsert) writeSets(w Writer, target string, excluded func(col string) string) error {
+	for i, k := range u.sets.sortedKeys() {
+		if i > 0 {
+			if _, err := fmt.Fprint(w, ","); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		switch v := u.sets[k].(type) {
+		case Excluded:
+			_, err = fmt.Fprintf(w, "%s=%s", k, excluded(string(v)))
+		case Incr:
+			_, err = fmt.Fprintf(w, "%s=%s%s+?", k, target, k)
+			w.Append(int(v))
+		case Decr:
+			_, err = fmt.Fprintf(w, "%s=%s%s-?", k, target, k)
+			w.Append(int(v))
+		case *Builder:
+			if _, err = fmt.Fprintf(w, "%s=", k); err == nil {
+				err = writeSubQuery(w, v)
+			}
+		default:
+			if _, err = fmt.Fprintf(w, "%s=", k); err == nil {
+				err = writeInsertValue(w, v)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// upsertWriteTo writes the conflict clause after VALUES
+func (b *Builder) upsertWriteTo(w Writer) error {
+	u := b.upsert
+	if b.dialect == MYSQL {
+		if _, err := fmt.Fprint(w, " ON DUPLICATE KEY UPDATE "); err != nil {
+			return err
+		}
+		if u.doNothing || len(u.sets) == 0 {
+			_, err := fmt.Fprintf(w, "%s=%s", b.insertCols[0], b.insertCols[0])
+			return err
+		}
+		return u.writeSets(w, "", func(col string) string {
+			return "VALUES(" + col + ")"
+		})
+	}
+
+	if _, err := fmt.Fprint(w, " ON CONFLICT"); err != nil {
+		return err
+	}
+	if len(u.cols) > 0 {
+		if _, err := fmt.Fprintf(w, " (%s)", strings.Join(u.cols, ",")); err != nil {
+			return err
+		}
+	}
+	if u.doNothing || len(u.sets) == 0 {
+		_, err := fmt.Fprint(w, " DO NOTHING")
+		return err
+	}
+	if len(u.cols) == 0 {
+		return ErrNoConflictColumns
+	}
+
+	if _, err := fmt.Fprint(w, " DO UPDATE SET "); err != nil {
+		return err
+	}
+	return u.writeSets(w, b.into+".", func(col string) string {
+		return "excluded." + col
+	})
+}
+
+// mergeWriteTo writes MERGE statement for MSSQL and Oracle
+func (b *Builder) mergeWriteTo(w Writer, rows [][]interface{}) error {
+	u := b.upsert
+	if len(u.cols) == 0 {
+		return ErrNoConflictColumns
+	}
+
+	if b.dialect == MSSQL {
+		if _, err := fmt.Fprintf(w, "MERGE INTO %s WITH (HOLDLOCK) AS target USING (VALUES ", b.into); err != nil {
+			return err
+		}
+		for i, row := range rows {
+			if i > 0 {
+				if _, err := fmt.Fprint(w, ","); err != nil {
+					return err
+				}
+			}
+			if err := writeInsertValues(w, row); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, ") AS src (%s) ON ", strings.Join(b.insertCols, ",")); err != nil {
+			return err
+		}
+	} else {
+		if _, err := fmt.Fprintf(w, "MERGE INTO %s target USING (", b.into); err != nil {
+			return err
+		}
+		for i, row := range rows {
+			if i > 0 {
+				if _, err := fmt.Fprint(w, " UNION ALL "); err != nil {
+					return err
+				}
+			}
+			if _, err := fmt.Fprint(w, "SELECT "); err != nil {
+				return err
+			}
+			for j, value := range row {
+				if j > 0 {
+					if _, err := fmt.Fprint(w, ","); err != nil {
+						return err
+					}
+				}
+				if err := writeInsertValue(w, value); err != nil {
+					return err
+				}
+				if _, err := fmt.Fprint(w, " ", b.insertCols[j]); err != nil {
+					return err
+				}
+			}
+			if _, err := fmt.Fprint(w, " FROM DUAL"); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprint(w, ") src ON "); err != nil {
+			return err
+		}
+	}
+
+	var ons = make([]string, 0, len(u.cols))
+	for _, col := range u.cols {
+		ons = append(ons, fmt.Sprintf("target.%s=src.%s", col, col))
+	}
+	if _, err := fmt.Fprintf(w, "(%s)", strings.Join(ons, " AND ")); err != nil {
+		return err
+	}
+
+	if !u.doNothing && len(u.sets) > 0 {
+		if _, err := fmt.Fprint(w, " WHEN MATCHED THEN UPDATE SET "); err != nil {
+			return err
+		}
+		if err := u.writeSets(w, "target.", func(col string) string {
+			return "src." + col
+		}); err != nil {
+			return err
+		}
+	}
+
+	var srcCols = make([]string, 0, len(b.insertCols))
+	for _, col := range b.insertCols {
+		srcCols = append(srcCols, "src."+col)
+	}
+	if _, err := fmt.Fprintf(w, " WHEN NOT MATCHED THEN INSERT (%s) VALUES (%s)",
+		strings.Join(b.insertCols, ","), strings.Join(srcCols, ",")); err != nil {
+		return err
+	}
+
+	// MERGE statement of MSSQL must be terminated by a semicolon
+	if b.dialect == MSSQL {
+		if _, err := fmt.Fprint(w, ";"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
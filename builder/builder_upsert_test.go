@@ -0,0 +1,76 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilderInsert_MultiRows(t *testing.T) {
+	sql, args, err := Insert().Into("user").Columns("id", "name", "age").
+		Values(1, "a", nil).Values(2, "b", Expr("? + 1", 20)).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "INSERT INTO user (id,name,age) Values (?,?,null),(?,?,(? + 1))", sql)
+	assert.EqualValues(t, []interface{}{1, "a", 2, "b", 20}, args)
+
+	sql, args, err = Oracle().Insert().Into("user").Columns("id", "name").
+		Values(1, "a").Values(2, "b").ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "INSERT ALL INTO user (id,name) VALUES (:p1,:p2) INTO user (id,name) VALUES (:p3,:p4) SELECT 1 FROM DUAL", sql)
+	assert.EqualValues(t, 4, len(args))
+
+	_, _, err = Insert().Into("user").Columns("id", "name").Values(1).ToSQL()
+	assert.EqualValues(t, ErrInsertValuesMismatch, err)
+}
+
+func TestBuilderInsert_Upsert(t *testing.T) {
+	var newBuilder = func(dialect string) *Builder {
+		return Dialect(dialect).Insert().Into("stock").Columns("sku", "name", "qty").
+			Values("a", "apple", 1).Values("b", "banana", 2)
+	}
+
+	sql, args, err := newBuilder(MYSQL).OnDuplicateKeyUpdate(Eq{"name": Excluded("name"), "qty": Incr(1)}).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "INSERT INTO stock (sku,name,qty) Values (?,?,?),(?,?,?) ON DUPLICATE KEY UPDATE name=VALUES(name),qty=qty+?", sql)
+	assert.EqualValues(t, []interface{}{"a", "apple", 1, "b", "banana", 2, 1}, args)
+
+	sql, _, err = newBuilder(MYSQL).OnConflict("sku").DoNothing().ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "INSERT INTO stock (sku,name,qty) Values (?,?,?),(?,?,?) ON DUPLICATE KEY UPDATE sku=sku", sql)
+
+	sql, args, err = newBuilder(POSTGRES).OnConflict("sku").DoUpdate(Eq{"name": Excluded("name"), "qty": Incr(1)}).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "INSERT INTO stock (sku,name,qty) Values ($1,$2,$3),($4,$5,$6) ON CONFLICT (sku) DO UPDATE SET name=excluded.name,qty=stock.qty+$7", sql)
+	assert.EqualValues(t, 7, len(args))
+
+	sql, _, err = newBuilder(SQLITE).OnConflict().DoNothing().ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "INSERT INTO stock (sku,name,qty) Values (?,?,?),(?,?,?) ON CONFLICT DO NOTHING", sql)
+
+	_, _, err = newBuilder(SQLITE).DoUpdate(Eq{"qty": 0}).ToSQL()
+	assert.EqualValues(t, ErrNoConflictColumns, err)
+
+	sql, args, err = newBuilder(MSSQL).OnConflict("sku").DoUpdate(Eq{"qty": Excluded("qty"), "name": "x"}).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "MERGE INTO stock WITH (HOLDLOCK) AS target USING (VALUES (@p1,@p2,@p3),(@p4,@p5,@p6)) AS src (sku,name,qty) ON (target.sku=src.sku) "+
+		"WHEN MATCHED THEN UPDATE SET name=@p7,qty=src.qty WHEN NOT MATCHED THEN INSERT (sku,name,qty) VALUES (src.sku,src.name,src.qty);", sql)
+	assert.EqualValues(t, 7, len(args))
+
+	sql, _, err = newBuilder(ORACLE).OnConflict("sku").DoNothing().ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "MERGE INTO stock target USING (SELECT :p1 sku,:p2 name,:p3 qty FROM DUAL UNION ALL SELECT :p4 sku,:p5 name,:p6 qty FROM DUAL) src ON (target.sku=src.sku) "+
+		"WHEN NOT MATCHED THEN INSERT (sku,name,qty) VALUES (src.sku,src.name,src.qty)", sql)
+
+	_, _, err = newBuilder(MSSQL).DoNothing().ToSQL()
+	assert.EqualValues(t, ErrNoConflictColumns, err)
+
+	sql, args, err = Postgres().Insert(Eq{"sku": "a", "qty": 1}).Into("stock").
+		OnConflict("sku").DoUpdate(Eq{"qty": Excluded("qty")}).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "INSERT INTO stock (qty,sku) Values ($1,$2) ON CONFLICT (sku) DO UPDATE SET qty=excluded.qty", sql)
+	assert.EqualValues(t, []interface{}{1, "a"}, args)
+}
@@ -0,0 +1,279 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Frame bounds of window functions
+const (
+	UnboundedPreceding = "UNBOUNDED PRECEDING"
+	UnboundedFollowing = "UNBOUNDED FOLLOWING"
+	CurrentRow         = "CURRENT ROW"
+)
+
+// Preceding returns the frame bound which is n rows before the current row
+func Preceding(n int) string {
+	return fmt.Sprintf("%d PRECEDING", n)
+}
+
+// Following returns the frame bound which is n rows after the current row
+func Following(n int) string {
+	return fmt.Sprintf("%d FOLLOWING", n)
+}
+
+// Window describes a window function expression, i.e.
+// ROW_NUMBER() OVER (PARTITION BY ... ORDER BY ... ROWS BETWEEN ... AND ...)
+type Window struct {
+	fn          string
+	args        []interface{}
+	ranking     bool
+	analytic    bool
+	partitionBy []string
+	orderBy     []string
+	frameUnit   string
+	frameStart  string
+	frameEnd    string
+	alias       string
+	desc        bool
+}
+
+// RowNumber creates a ROW_NUMBER() window function
+func RowNumber() *Window {
+	return &Window{fn: "ROW_NUMBER()", ranking: true}
+}
+
+// Rank creates a RANK() window function
+func Rank() *Window {
+	return &Window{fn: "RANK()", ranking: true}
+}
+
+// DenseRank creates a DENSE_RANK() window function
+func DenseRank() *Window {
+	return &Window{fn: "DENSE_RANK()", ranking: true}
+}
+
+// NTile creates a NTILE(n) window function
+func NTile(n int) *Window {
+	return &Window{fn: fmt.Sprintf("NTILE(%d)", n), ranking: true}
+}
+
+// Lag creates a LAG window function which accesses the value of col offset rows
+// before the current row, the optional defaultValue is used when there is no such row
+func Lag(col string, offset int, defaultValue ...interface{}) *Window {
+	return offsetWindow("LAG", col, offset, defaultValue...)
+}
+
+// Lead creates a LEAD window function which accesses the value of col offset rows
+// after the current row, the optional defaultValue is used when there is no such row
+func Lead(col string, offset int, defaultValue ...interface{}) *Window {
+	return offsetWindow("LEAD", col, offset, defaultValue...)
+}
+
+func offsetWindow(fn, col string, offset int, defaultValue ...interface{}) *Window {
+	if len(defaultValue) > 0 {
+		return &Window{
+			fn:       fmt.Sprintf("%s(%s,%d,?)", fn, col, offset),
+			args:     defaultValue[:1],
+			analytic: true,
+		}
+	}
+	return &Window{fn: fmt.Sprintf("%s(%s,%d)", fn, col, offset), analytic: true}
+}
+
+// FirstValue creates a FIRST_VALUE window function
+func FirstValue(col string) *Window {
+	return &Window{fn: "FIRST_VALUE(" + col + ")", analytic: true}
+}
+
+// LastValue creates a LAST_VALUE window function
+func LastValue(col string) *Window {
+	return &Window{fn: "LAST_VALUE(" + col + ")", analytic: true}
+}
+
+// Over creates a window function from an aggregate expression, i.e. Over("SUM(amount)")
+func Over(aggregate string, args ...interface{}) *Window {
+	return &Window{fn: aggregate, args: args}
+}
+
+// PartitionBy sets the PARTITION BY columns of the window
+func (win *Window) PartitionBy(cols ...string) *Window {
+	win.partitionBy = append(win.partitionBy, cols...)
+	return win
+}
+
+// OrderBy sets the ORDER BY of the window, i.e. OrderBy("created DESC")
+func (win *Window) OrderBy(orders ...string) *Window {
+	win.orderBy = append(win.orderBy, orders...)
+	return win
+}
+
+// Rows sets the frame ROWS BETWEEN start AND end
+func (win *Window) Rows(start, end string) *Window {
+	win.frameUnit, win.frameStart, win.frameEnd = "ROWS", start, end
+	return win
+}
+
+// Range sets the frame RANGE BETWEEN start AND end
+func (win *Window) Range(start, end string) *Window {
+	win.frameUnit, win.frameStart, win.frameEnd = "RANGE", start, end
+	return win
+}
+
+// As sets the alias of the window function when it's selected
+func (win *Window) As(alias string) *Window {
+	win.alias = alias
+	return win
+}
+
+// Desc sorts descending when the window function is used in ORDER BY
+func (win *Window) Desc() *Window {
+	win.desc = true
+	return win
+}
+
+// minWindowVersions are the first versions which support window functions,
+// the second one is required by the analytic functions and frames
+var minWindowVersions = map[string][2]string{
+	MYSQL:    {"8.0", "8.0"},
+	SQLITE:   {"3.25.0", "3.25.0"},
+	POSTGRES: {"8.4", "8.4"},
+	MSSQL:    {"9.0", "11.0"},
+}
+
+func (win *Window) checkSupported(dialect, version string) error {
+	if len(version) == 0 {
+		return nil
+	}
+	minVersions, ok := minWindowVersions[dialect]
+	if !ok {
+		return nil
+	}
+
+	minVersion := minVersions[0]
+	if win.analytic || len(win.frameUnit) > 0 {
+		minVersion = minVersions[1]
+	}
+	if compareVersion(version, minVersion) < 0 {
+		return ErrWindowNotSupported
+	}
+	return nil
+}
+
+func (win *Window) writeTo(w Writer, dialect, version string) error {
+	if err := win.checkSupported(dialect, version); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprint(w, win.fn, " OVER ("); err != nil {
+		return err
+	}
+	w.Append(win.args...)
+
+	var clauses []string
+	if len(win.partitionBy) > 0 {
+		clauses = append(clauses, "PARTITION BY "+strings.Join(win.partitionBy, ","))
+	}
+	if len(win.orderBy) > 0 {
+		clauses = append(clauses, "ORDER BY "+strings.Join(win.orderBy, ","))
+	} else if dialect == MSSQL && win.ranking {
+		// ranking functions of MSSQL require ORDER BY
+		clauses = append(clauses, "ORDER BY (SELECT 1)")
+	}
+	if len(win.frameUnit) > 0 {
+		clauses = append(clauses, fmt.Sprintf("%s BETWEEN %s AND %s", win.frameUnit, win.frameStart, win.frameEnd))
+	}
+
+	if _, err := fmt.Fprint(w, strings.Join(clauses, " "), ")"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// compareVersion compares the numeric parts of two versions, i.e. "8.0.21-log" and "8.0"
+func compareVersion(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionParts(version string) []int {
+	// skip the leading product name, i.e. "PostgreSQL 12.3"
+	version = strings.TrimLeftFunc(version, func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+
+	var parts []int
+	for _, s := range strings.Split(version, ".") {
+		var end int
+		for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+			end++
+		}
+		n, err := strconv.Atoi(s[:end])
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+		if end < len(s) {
+			break
+		}
+	}
+	return parts
+}
+
+// Version sets the version of the database server so that the builder could reject
+// the features the server doesn't support
+func (b *Builder) Version(version string) *Builder {
+	b.version = version
+	return b
+}
+
+// SelectWindow appends window functions to the select columns
+func (b *Builder) SelectWindow(windows ...*Window) *Builder {
+	for _, win := range windows {
+		b.selectExprs = append(b.selectExprs, win)
+	}
+	if b.optype == condType {
+		b.optype = selectType
+	}
+	return b
+}
+
+// OrderByWindow appends window functions to ORDER BY
+func (b *Builder) OrderByWindow(windows ...*Window) *Builder {
+	for _, win := range windows {
+		b.orderExprs = append(b.orderExprs, win)
+	}
+	return b
+}
+
+func (win *Window) writeExprTo(w Writer, b *Builder) error {
+	return win.writeTo(w, b.dialect, b.version)
+}
+
+func (win *Window) exprAlias() string {
+	return win.alias
+}
+
+func (win *Window) isDesc() bool {
+	return win.desc
+}
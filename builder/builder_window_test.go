@@ -0,0 +1,84 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder_Window(t *testing.T) {
+	sql, args, err := Select("id", "category").From("product").
+		SelectWindow(RowNumber().PartitionBy("category").OrderBy("price DESC").As("rn")).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT id,category,ROW_NUMBER() OVER (PARTITION BY category ORDER BY price DESC) AS rn FROM product", sql)
+	assert.EqualValues(t, 0, len(args))
+
+	sql, args, err = Select("id").From("account").
+		SelectWindow(Over("SUM(amount)").PartitionBy("user_id").OrderBy("created").
+			Rows(UnboundedPreceding, CurrentRow).As("total"),
+			Lag("amount", 1, 0).OrderBy("created").As("prev"),
+			Lead("amount", 2).OrderBy("created").Rows(Preceding(1), Following(1))).
+		Where(Gt{"amount": 10}).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT id,SUM(amount) OVER (PARTITION BY user_id ORDER BY created ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW) AS total,"+
+		"LAG(amount,1,?) OVER (ORDER BY created) AS prev,"+
+		"LEAD(amount,2) OVER (ORDER BY created ROWS BETWEEN 1 PRECEDING AND 1 FOLLOWING) FROM account WHERE amount>?", sql)
+	assert.EqualValues(t, []interface{}{0, 10}, args)
+
+	sql, _, err = Select("id").From("product").OrderBy("id").
+		OrderByWindow(Rank().PartitionBy("category").OrderBy("price").Desc()).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT id FROM product ORDER BY id,RANK() OVER (PARTITION BY category ORDER BY price) DESC", sql)
+
+	sql, _, err = Select().From("product").OrderByWindow(DenseRank().OrderBy("price")).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT * FROM product ORDER BY DENSE_RANK() OVER (ORDER BY price)", sql)
+}
+
+func TestBuilder_WindowDialect(t *testing.T) {
+	sql, _, err := MsSQL().Select("id").From("product").SelectWindow(RowNumber().As("rn")).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT id,ROW_NUMBER() OVER (ORDER BY (SELECT 1)) AS rn FROM product", sql)
+
+	sql, _, err = MsSQL().Select("id").From("product").
+		SelectWindow(NTile(4).OrderBy("price").As("quartile")).Limit(10).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT id,quartile FROM (SELECT TOP 10 id,ROW_NUMBER() OVER (ORDER BY (SELECT 1)) AS RN,NTILE(4) OVER (ORDER BY price) AS quartile FROM product) at", sql)
+
+	sql, _, err = Oracle().Select("id").From("product").
+		SelectWindow(RowNumber().OrderBy("price").As("seq")).Limit(10).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT id,seq FROM (SELECT id,ROWNUM RN,ROW_NUMBER() OVER (ORDER BY price) AS seq FROM product) at WHERE at.RN<=:p1", sql)
+
+	_, _, err = Oracle().Select("id").From("product").
+		SelectWindow(RowNumber().OrderBy("price")).Limit(10).ToSQL()
+	assert.EqualValues(t, ErrUnnamedWindow, err)
+
+	_, _, err = MySQL().Version("5.7.31-log").Select("id").From("product").
+		SelectWindow(RowNumber().OrderBy("price")).ToSQL()
+	assert.EqualValues(t, ErrWindowNotSupported, err)
+
+	_, _, err = MySQL().Version("8.0.21").Select("id").From("product").
+		SelectWindow(RowNumber().OrderBy("price")).ToSQL()
+	assert.NoError(t, err)
+
+	_, _, err = SQLite().Version("3.24.0").Select("id").From("product").
+		OrderByWindow(RowNumber().OrderBy("price")).ToSQL()
+	assert.EqualValues(t, ErrWindowNotSupported, err)
+
+	_, _, err = MsSQL().Version("10.50.1600.1").Select("id").From("product").
+		SelectWindow(RowNumber().OrderBy("price")).ToSQL()
+	assert.NoError(t, err)
+
+	_, _, err = MsSQL().Version("10.50.1600.1").Select("id").From("product").
+		SelectWindow(Lag("price", 1).OrderBy("id")).ToSQL()
+	assert.EqualValues(t, ErrWindowNotSupported, err)
+
+	_, _, err = Postgres().Version("PostgreSQL 12.3 on x86_64-pc-linux-gnu").Select("id").From("product").
+		SelectWindow(Lag("price", 1).OrderBy("id")).ToSQL()
+	assert.NoError(t, err)
+}
@@ -0,0 +1,109 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// With creates a builder with a common table expression, name could contain
+// the column list, i.e. "tree(id, parent_id)". A builder which only contains
+// common table expressions writes the WITH clause.
+func With(name string, cte *Builder) *Builder {
+	builder := &Builder{cond: NewCond()}
+	return builder.With(name, cte)
+}
+
+// WithRecursive creates a builder with a recursive common table expression
+func WithRecursive(name string, cte *Builder) *Builder {
+	builder := &Builder{cond: NewCond()}
+	return builder.WithRecursive(name, cte)
+}
+
+// With sets a common table expression
+func (b *Builder) With(name string, cte *Builder) *Builder {
+	return b.with(name, false, cte)
+}
+
+// WithRecursive sets a recursive common table expression
+func (b *Builder) WithRecursive(name string, cte *Builder) *Builder {
+	return b.with(name, true, cte)
+}
+
+func (b *Builder) with(name string, recursive bool, builder *Builder) *Builder {
+	b.ctes = append(b.ctes, cte{
+		name:      name,
+		recursive: recursive,
+		builder:   builder,
+	})
+	return b
+}
+
+// inheritDialect sets the dialect of the builder and the members of its set operations
+func (b *Builder) inheritDialect(dialect string) {
+	b.dialect = dialect
+	for _, o := range b.setOps {
+		if o.builder.dialect == "" {
+			o.builder.dialect = dialect
+		}
+	}
+}
+
+func (b *Builder) withWriteTo(w Writer) error {
+	var recursive bool
+	for _, c := range b.ctes {
+		if len(strings.TrimSpace(c.name)) == 0 || c.builder == nil {
+			return ErrInvalidCTE
+		}
+		if c.builder.dialect != "" && b.dialect != "" && b.dialect != c.builder.dialect {
+			return ErrInconsistentDialect
+		}
+		recursive = recursive || c.recursive
+	}
+
+	if _, err := fmt.Fprint(w, "WITH "); err != nil {
+		return err
+	}
+
+	// MSSQL and Oracle recognize the recursive common table expressions by themselves
+	if recursive && b.dialect != MSSQL && b.dialect != ORACLE {
+		if _, err := fmt.Fprint(w, "RECURSIVE "); err != nil {
+			return err
+		}
+	}
+
+	for i, c := range b.ctes {
+		// dialect of common table expression will inherit from the main one (if not set up)
+		if b.dialect != "" && c.builder.dialect == "" {
+			c.builder.inheritDialect(b.dialect)
+		}
+
+		if i > 0 {
+			if _, err := fmt.Fprint(w, ", "); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%s AS (", c.name); err != nil {
+			return err
+		}
+		var err error
+		if b.dialect == SQLITE && c.builder.optype == setOpType && len(c.builder.ctes) == 0 {
+			// SQLite doesn't accept the parenthesized members of the compound select
+			// in a recursive common table expression
+			err = c.builder.writeSetOps(w, false)
+		} else {
+			err = c.builder.WriteTo(w)
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprint(w, ")"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
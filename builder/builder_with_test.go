@@ -0,0 +1,69 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder_With(t *testing.T) {
+	sql, args, err := With("t", Select("id", "name").From("table1").Where(Eq{"status": 1})).
+		Select("*").From("t").Where(Gt{"id": 10}).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "WITH t AS (SELECT id,name FROM table1 WHERE status=?) SELECT * FROM t WHERE id>?", sql)
+	assert.EqualValues(t, []interface{}{1, 10}, args)
+
+	sql, args, err = Postgres().With("a", Select("id").From("table1").Where(Eq{"x": 1})).
+		With("b", Select("id").From("table2").Where(Eq{"y": 2})).
+		Select("a.id").From("a").InnerJoin("b", "a.id = b.id").ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "WITH a AS (SELECT id FROM table1 WHERE x=$1), b AS (SELECT id FROM table2 WHERE y=$2) SELECT a.id FROM a INNER JOIN b ON a.id = b.id", sql)
+	assert.EqualValues(t, []interface{}{1, 2}, args)
+
+	sql, err = With("t", Select("id").From("table1")).ToBoundSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "WITH t AS (SELECT id FROM table1)", sql)
+
+	_, _, err = With("", Select("id").From("table1")).Select("*").From("t").ToSQL()
+	assert.EqualValues(t, ErrInvalidCTE, err)
+
+	_, _, err = MySQL().With("t", Postgres().Select("id").From("table1")).Select("*").From("t").ToSQL()
+	assert.EqualValues(t, ErrInconsistentDialect, err)
+}
+
+func TestBuilder_WithRecursive(t *testing.T) {
+	tree := Select("id", "parent_id").From("category").Where(Eq{"id": 1}).
+		Union("all", Select("c.id", "c.parent_id").From("category c").InnerJoin("tree", "c.parent_id = tree.id"))
+
+	sql, args, err := MySQL().WithRecursive("tree(id, parent_id)", tree).Select("*").From("tree").ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "WITH RECURSIVE tree(id, parent_id) AS ((SELECT id,parent_id FROM category WHERE id=?) UNION ALL (SELECT c.id,c.parent_id FROM category c INNER JOIN tree ON c.parent_id = tree.id)) SELECT * FROM tree", sql)
+	assert.EqualValues(t, []interface{}{1}, args)
+
+	tree = Select("id", "parent_id").From("category").Where(Eq{"id": 1}).
+		Union("all", Select("c.id", "c.parent_id").From("category c").InnerJoin("tree", "c.parent_id = tree.id"))
+	sql, args, err = SQLite().WithRecursive("tree(id, parent_id)", tree).Select("*").From("tree").ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "WITH RECURSIVE tree(id, parent_id) AS (SELECT id,parent_id FROM category WHERE id=? UNION ALL SELECT c.id,c.parent_id FROM category c INNER JOIN tree ON c.parent_id = tree.id) SELECT * FROM tree", sql)
+	assert.EqualValues(t, []interface{}{1}, args)
+
+	// the set operations out of the common table expressions are parenthesized as before
+	sql, _, err = SQLite().Select("id").From("a").Union("all", SQLite().Select("id").From("b")).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "(SELECT id FROM a) UNION ALL (SELECT id FROM b)", sql)
+
+	sql, args, err = MsSQL().WithRecursive("tree(id, parent_id)", Select("id", "parent_id").From("category").Where(Eq{"id": 1})).
+		Select("*").From("tree").ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "WITH tree(id, parent_id) AS (SELECT id,parent_id FROM category WHERE id=@p1) SELECT * FROM tree", sql)
+	assert.EqualValues(t, 1, len(args))
+
+	// common table expressions are written once even if the builder is wrapped by limitation
+	sql, _, err = Oracle().With("t", Select("id").From("table1")).Select("id").From("t").Limit(5).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "WITH t AS (SELECT id FROM table1) SELECT id FROM (SELECT id,ROWNUM RN FROM t) at WHERE at.RN<=:p1", sql)
+}
@@ -0,0 +1,68 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import (
+	"fmt"
+	"strings"
+)
+
+type condQuantified struct {
+	col        string
+	op         string
+	quantifier string
+	subQuery   *Builder
+}
+
+var _ Cond = condQuantified{}
+
+// Any generates a comparison against any row of the sub-query, i.e. Any("a", ">", sub)
+// generates a > ANY (sub)
+func Any(col, op string, subQuery *Builder) Cond {
+	return condQuantified{col, strings.TrimSpace(op), "ANY", subQuery}
+}
+
+// All generates a comparison against all rows of the sub-query, i.e. All("a", ">", sub)
+// generates a > ALL (sub)
+func All(col, op string, subQuery *Builder) Cond {
+	return condQuantified{col, strings.TrimSpace(op), "ALL", subQuery}
+}
+
+func (q condQuantified) WriteTo(w Writer) error {
+	if writerDialect(w) == SQLITE {
+		// SQLite has no quantified comparison, but the two common forms equal to IN and NOT IN
+		switch {
+		case q.quantifier == "ANY" && q.op == "=":
+			if _, err := fmt.Fprint(w, q.col, " IN "); err != nil {
+				return err
+			}
+			return writeSubQuery(w, q.subQuery)
+		case q.quantifier == "ALL" && (q.op == "<>" || q.op == "!="):
+			if _, err := fmt.Fprint(w, q.col, " NOT IN "); err != nil {
+				return err
+			}
+			return writeSubQuery(w, q.subQuery)
+		default:
+			return ErrNotSupportDialectType
+		}
+	}
+
+	if _, err := fmt.Fprintf(w, "%s%s%s ", q.col, q.op, q.quantifier); err != nil {
+		return err
+	}
+	return writeSubQuery(w, q.subQuery)
+}
+
+func (q condQuantified) And(conds ...Cond) Cond {
+	return And(q, And(conds...))
+}
+
+func (q condQuantified) Or(conds ...Cond) Cond {
+	return Or(q, Or(conds...))
+}
+
+func (q condQuantified) IsValid() bool {
+	return len(q.col) > 0 && len(q.op) > 0 && q.subQuery != nil
+}
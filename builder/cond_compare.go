@@ -42,6 +42,16 @@ func WriteMap(w Writer, data map[string]interface{}, op string) error {
 			if _, err := fmt.Fprintf(w, ")"); err != nil {
 				return err
 			}
+		case *Func:
+			// the function may append its own args, so flush the previous ones
+			w.Append(args...)
+			args = args[:0]
+			if _, err := fmt.Fprintf(w, "%s%s", k, op); err != nil {
+				return err
+			}
+			if err := v.(*Func).WriteTo(w); err != nil {
+				return err
+			}
 		default:
 			if _, err := fmt.Fprintf(w, "%s%s?", k, op); err != nil {
 				return err
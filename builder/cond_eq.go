@@ -54,6 +54,13 @@ func (eq Eq) OpWriteTo(op string, w Writer) error {
 			if _, err := fmt.Fprintf(w, ")"); err != nil {
 				return err
 			}
+		case *Func:
+			if _, err := fmt.Fprintf(w, "%s=", k); err != nil {
+				return err
+			}
+			if err := v.(*Func).WriteTo(w); err != nil {
+				return err
+			}
 		case Incr:
 			if _, err := fmt.Fprintf(w, "%s=%s+?", k, k); err != nil {
 				return err
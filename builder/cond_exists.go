@@ -0,0 +1,72 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import "fmt"
+
+type condExists struct {
+	subQuery *Builder
+	not      bool
+}
+
+var _ Cond = condExists{}
+
+// Exists generates EXISTS condition
+func Exists(subQuery *Builder) Cond {
+	return condExists{subQuery: subQuery}
+}
+
+// NotExists generates NOT EXISTS condition
+func NotExists(subQuery *Builder) Cond {
+	return condExists{subQuery: subQuery, not: true}
+}
+
+// writeSubQuery writes the sub-query into parentheses, the dialect of the
+// sub-query will inherit from the writer (if not set up)
+func writeSubQuery(w Writer, subQuery *Builder) error {
+	if subQuery == nil {
+		return ErrNoSubQuery
+	}
+
+	dialect := writerDialect(w)
+	if subQuery.dialect == "" {
+		subQuery.inheritDialect(dialect)
+	} else if dialect != "" && subQuery.dialect != dialect {
+		return ErrInconsistentDialect
+	}
+
+	if _, err := fmt.Fprint(w, "("); err != nil {
+		return err
+	}
+	if err := subQuery.WriteTo(w); err != nil {
+		return err
+	}
+	_, err := fmt.Fprint(w, ")")
+	return err
+}
+
+func (exists condExists) WriteTo(w Writer) error {
+	if exists.not {
+		if _, err := fmt.Fprint(w, "NOT "); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprint(w, "EXISTS "); err != nil {
+		return err
+	}
+	return writeSubQuery(w, exists.subQuery)
+}
+
+func (exists condExists) And(conds ...Cond) Cond {
+	return And(exists, And(conds...))
+}
+
+func (exists condExists) Or(conds ...Cond) Cond {
+	return Or(exists, Or(conds...))
+}
+
+func (exists condExists) IsValid() bool {
+	return exists.subQuery != nil
+}
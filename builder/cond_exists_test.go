@@ -0,0 +1,54 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCond_Exists(t *testing.T) {
+	sub := Select("1").From("orders").Where(Expr("orders.user_id = users.id").And(Gt{"orders.amount": 10}))
+	sql, args, err := Select("id").From("users").Where(Exists(sub)).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT id FROM users WHERE EXISTS (SELECT 1 FROM orders WHERE (orders.user_id = users.id) AND orders.amount>?)", sql)
+	assert.EqualValues(t, []interface{}{10}, args)
+
+	sql, args, err = Postgres().Select("id").From("users").
+		Where(Eq{"status": 1}.And(NotExists(Select("1").From("orders").Where(Expr("orders.user_id = users.id"))))).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT id FROM users WHERE status=$1 AND NOT EXISTS (SELECT 1 FROM orders WHERE orders.user_id = users.id)", sql)
+	assert.EqualValues(t, []interface{}{1}, args)
+
+	_, _, err = Postgres().Select("id").From("users").Where(Exists(MySQL().Select("1").From("orders"))).ToSQL()
+	assert.EqualValues(t, ErrInconsistentDialect, err)
+
+	assert.False(t, Exists(nil).IsValid())
+}
+
+func TestCond_AnyAll(t *testing.T) {
+	sub := Select("price").From("products").Where(Eq{"category": "book"})
+	sql, args, err := ToSQL(Any("price", ">", sub))
+	assert.NoError(t, err)
+	assert.EqualValues(t, "price>ANY (SELECT price FROM products WHERE category=?)", sql)
+	assert.EqualValues(t, []interface{}{"book"}, args)
+
+	sql, args, err = MySQL().Select("id").From("products").Where(All("price", ">=", sub)).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT id FROM products WHERE price>=ALL (SELECT price FROM products WHERE category=?)", sql)
+	assert.EqualValues(t, []interface{}{"book"}, args)
+
+	sql, _, err = ToDialectSQL(SQLITE, Any("id", "=", Select("product_id").From("orders")))
+	assert.NoError(t, err)
+	assert.EqualValues(t, "id IN (SELECT product_id FROM orders)", sql)
+
+	sql, _, err = ToDialectSQL(SQLITE, All("id", "<>", Select("product_id").From("orders")))
+	assert.NoError(t, err)
+	assert.EqualValues(t, "id NOT IN (SELECT product_id FROM orders)", sql)
+
+	_, _, err = ToDialectSQL(SQLITE, All("id", ">", Select("product_id").From("orders")))
+	assert.EqualValues(t, ErrNotSupportDialectType, err)
+}
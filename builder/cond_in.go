@@ -31,6 +31,9 @@ func (condIn condIn) WriteTo(w Writer) error {
 	if len(condIn.vals) <= 0 {
 		return condIn.handleBlank(w)
 	}
+	if ok, err := writeLimitedIn(w, condIn.col, condIn.vals, false); ok {
+		return err
+	}
 
 	switch condIn.vals[0].(type) {
 	case []int8:
@@ -0,0 +1,266 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// InStrategy defines how IN and NOT IN conditions are written when the number
+// of the values exceeds the threshold of the dialect
+type InStrategy int
+
+const (
+	// InSplit splits the values into OR'ed IN groups (AND'ed NOT IN groups)
+	InSplit InStrategy = iota
+	// InArray passes the values as one array parameter, i.e. a = ANY(?), it's
+	// only supported by Postgres, the other dialects fallback to InSplit
+	InArray
+	// InInline writes the values as literals so that they don't consume the
+	// parameters, it fallbacks to InSplit when any value cannot be written safely
+	InInline
+	// InTempTable loads the values into a temporary table and writes a sub-query,
+	// it requires a connection so it's only supported by Session.In and Session.NotIn
+	// in a transaction, the builder fallbacks to InSplit
+	InTempTable
+)
+
+type inLimit struct {
+	threshold int
+	strategy  InStrategy
+}
+
+var (
+	inLimitsLock sync.RWMutex
+	// MySQL has no limitation by default, huge lists could be loaded into temporary
+	// tables by SetInLimit(MYSQL, 1000, InTempTable)
+	inLimits = map[string]inLimit{
+		ORACLE:   {1000, InSplit},  // ORA-01795
+		MSSQL:    {2000, InInline}, // at most 2100 parameters
+		POSTGRES: {30000, InArray}, // at most 65535 parameters
+	}
+
+	// maxParams are the max numbers of the parameters of a statement, the values
+	// which could only be split are rejected if they exceed the number
+	maxParams = map[string]int{
+		MSSQL:    2100,
+		POSTGRES: 65535,
+	}
+)
+
+// SetInLimit sets the max number of values of IN and NOT IN conditions for the dialect
+// and how the values are written when the number exceeds it, a threshold no more than
+// zero disables the limitation
+func SetInLimit(dialect string, threshold int, strategy InStrategy) {
+	inLimitsLock.Lock()
+	defer inLimitsLock.Unlock()
+	if threshold <= 0 {
+		delete(inLimits, dialect)
+		return
+	}
+	inLimits[dialect] = inLimit{threshold, strategy}
+}
+
+// InLimit returns the max number of values of IN and NOT IN conditions for the dialect
+// and the strategy, zero means there is no limitation
+func InLimit(dialect string) (int, InStrategy) {
+	limit, _ := getInLimit(dialect)
+	return limit.threshold, limit.strategy
+}
+
+func getInLimit(dialect string) (inLimit, bool) {
+	inLimitsLock.RLock()
+	defer inLimitsLock.RUnlock()
+	limit, ok := inLimits[dialect]
+	return limit, ok
+}
+
+// FlattenInValues returns the values of IN condition, false will be returned if
+// the values come from a sub-query
+func FlattenInValues(vals ...interface{}) ([]interface{}, bool) {
+	if len(vals) != 1 {
+		return vals, true
+	}
+
+	switch vals[0].(type) {
+	case expr, *Builder:
+		return nil, false
+	case []interface{}:
+		return vals[0].([]interface{}), true
+	}
+
+	v := reflect.ValueOf(vals[0])
+	if v.Kind() != reflect.Slice {
+		return vals, true
+	}
+	res := make([]interface{}, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		res = append(res, v.Index(i).Interface())
+	}
+	return res, true
+}
+
+// writeLimitedIn writes IN or NOT IN condition according to the limitation of
+// the writer's dialect, false will be returned if the condition is not limited
+func writeLimitedIn(w Writer, col string, values []interface{}, not bool) (bool, error) {
+	dialect := writerDialect(w)
+	if dialect == "" {
+		return false, nil
+	}
+	limit, ok := getInLimit(dialect)
+	if !ok {
+		return false, nil
+	}
+	vals, ok := FlattenInValues(values...)
+	if !ok || len(vals) <= limit.threshold {
+		return false, nil
+	}
+
+	switch limit.strategy {
+	case InArray:
+		if dialect == POSTGRES {
+			if array, ok := postgresArray(vals); ok {
+				return true, writeArrayIn(w, col, array, not)
+			}
+		}
+	case InInline:
+		if literals, ok := inlineValues(dialect, vals); ok {
+			return true, writeInlineIn(w, col, literals, not)
+		}
+	}
+	// the split values are still bound as the parameters of one statement
+	if max, ok := maxParams[dialect]; ok && len(vals) > max {
+		return true, ErrTooManyInValues
+	}
+	return true, writeSplitIn(w, col, vals, limit.threshold, not)
+}
+
+func writeSplitIn(w Writer, col string, vals []interface{}, size int, not bool) error {
+	op, sep := " IN (", " OR "
+	if not {
+		op, sep = " NOT IN (", " AND "
+	}
+
+	if _, err := fmt.Fprint(w, "("); err != nil {
+		return err
+	}
+	for start := 0; start < len(vals); start += size {
+		end := start + size
+		if end > len(vals) {
+			end = len(vals)
+		}
+		if start > 0 {
+			if _, err := fmt.Fprint(w, sep); err != nil {
+				return err
+			}
+		}
+		questionMark := strings.Repeat("?,", end-start)
+		if _, err := fmt.Fprint(w, col, op, questionMark[:len(questionMark)-1], ")"); err != nil {
+			return err
+		}
+		w.Append(vals[start:end]...)
+	}
+	_, err := fmt.Fprint(w, ")")
+	return err
+}
+
+func writeArrayIn(w Writer, col, array string, not bool) error {
+	var format = "%s = ANY(?)"
+	if not {
+		format = "%s <> ALL(?)"
+	}
+	if _, err := fmt.Fprintf(w, format, col); err != nil {
+		return err
+	}
+	w.Append(array)
+	return nil
+}
+
+func writeInlineIn(w Writer, col string, literals []string, not bool) error {
+	op := " IN ("
+	if not {
+		op = " NOT IN ("
+	}
+	_, err := fmt.Fprint(w, col, op, strings.Join(literals, ","), ")")
+	return err
+}
+
+// postgresArray formats the values as a Postgres array literal, i.e. {1,2,3}
+func postgresArray(vals []interface{}) (string, bool) {
+	var buf strings.Builder
+	buf.WriteString("{")
+	for i, val := range vals {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		switch t := val.(type) {
+		case string:
+			buf.WriteString(`"`)
+			buf.WriteString(strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(t))
+			buf.WriteString(`"`)
+		default:
+			literal, ok := numberLiteral(val)
+			if !ok {
+				return "", false
+			}
+			buf.WriteString(literal)
+		}
+	}
+	buf.WriteString("}")
+	return buf.String(), true
+}
+
+// inlineValues formats the values as SQL literals, strings are only written for
+// the dialects which don't treat backslash as an escape character, and the ones
+// containing backslashes are not written since ConvertPlaceholder treats \' as an
+// escaped quote
+func inlineValues(dialect string, vals []interface{}) ([]string, bool) {
+	var literals = make([]string, 0, len(vals))
+	for _, val := range vals {
+		if s, ok := val.(string); ok {
+			if (dialect != MSSQL && dialect != ORACLE) || strings.ContainsRune(s, '\\') {
+				return nil, false
+			}
+			var prefix string
+			if dialect == MSSQL {
+				prefix = "N"
+			}
+			literals = append(literals, prefix+"'"+strings.Replace(s, "'", "''", -1)+"'")
+			continue
+		}
+		literal, ok := numberLiteral(val)
+		if !ok {
+			return nil, false
+		}
+		literals = append(literals, literal)
+	}
+	return literals, true
+}
+
+// numberLiteral formats integers and floats, the other values are not accepted
+func numberLiteral(val interface{}) (string, bool) {
+	if val == nil {
+		return "", false
+	}
+	v := reflect.ValueOf(val)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return "", false
+		}
+		return strconv.FormatFloat(f, 'g', -1, 64), true
+	}
+	return "", false
+}
@@ -0,0 +1,113 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCond_InLimit(t *testing.T) {
+	var ids = make([]int64, 0, 2500)
+	for i := 1; i <= 2500; i++ {
+		ids = append(ids, int64(i))
+	}
+
+	sql, args, err := Oracle().Select("id").From("t").Where(In("id", ids)).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2500, len(args))
+	assert.EqualValues(t, 3, strings.Count(sql, "id IN ("))
+	assert.True(t, strings.HasPrefix(sql, "SELECT id FROM t WHERE (id IN (:p1,"))
+	assert.True(t, strings.Contains(sql, ":p1000) OR id IN (:p1001,"))
+
+	sql, args, err = ToDialectSQL(ORACLE, NotIn("id", ids))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2500, len(args))
+	assert.EqualValues(t, 3, strings.Count(sql, "id NOT IN ("))
+	assert.EqualValues(t, 2, strings.Count(sql, ") AND id NOT IN ("))
+
+	sql, args, err = ToDialectSQL(MSSQL, In("id", ids))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, len(args))
+	assert.True(t, strings.HasPrefix(sql, "id IN (1,2,3,"))
+
+	var names = make([]interface{}, 0, 2200)
+	for i := 0; i < 2200; i++ {
+		names = append(names, "it's")
+	}
+	sql, args, err = ToDialectSQL(MSSQL, In("name", names...))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, len(args))
+	assert.True(t, strings.HasPrefix(sql, "name IN (N'it''s',N'it''s',"))
+
+	// the strings with backslashes are not inlined, and the split values exceed the
+	// max number of parameters
+	names[2199] = `a\`
+	_, _, err = ToDialectSQL(MSSQL, In("name", names...))
+	assert.EqualValues(t, ErrTooManyInValues, err)
+	sql, args, err = MsSQL().Select("id").From("t").Where(In("name", names[:1000]...).And(In("id", ids))).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1000, len(args))
+	assert.True(t, strings.Contains(sql, "@p1000) AND id IN (1,2,"), sql)
+	sql, args, err = MsSQL().Select("id").From("t").Where(In("name", names[150:]...).And(Eq{"id": 1})).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2051, len(args))
+	assert.True(t, strings.HasSuffix(sql, "@p2050)) AND id=@p2051"), sql)
+
+	threshold, strategy := InLimit(POSTGRES)
+	assert.EqualValues(t, 30000, threshold)
+	assert.EqualValues(t, InArray, strategy)
+	SetInLimit(POSTGRES, 3, InArray)
+	defer SetInLimit(POSTGRES, threshold, strategy)
+
+	sql, args, err = Postgres().Select("id").From("t").Where(In("name", "a", `b"c`, `d\e`, "f")).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT id FROM t WHERE name = ANY($1)", sql)
+	assert.EqualValues(t, []interface{}{`{"a","b\"c","d\\e","f"}`}, args)
+
+	sql, args, err = ToDialectSQL(POSTGRES, NotIn("id", []int{1, 2, 3, 4}))
+	assert.NoError(t, err)
+	assert.EqualValues(t, "id <> ALL(?)", sql)
+	assert.EqualValues(t, []interface{}{"{1,2,3,4}"}, args)
+
+	sql, args, err = ToDialectSQL(POSTGRES, In("id", []int{1, 2, 3}))
+	assert.NoError(t, err)
+	assert.EqualValues(t, "id IN (?,?,?)", sql)
+	assert.EqualValues(t, 3, len(args))
+
+	// MySQL has no limitation unless it's configured
+	threshold, strategy = InLimit(MYSQL)
+	assert.EqualValues(t, 0, threshold)
+	sql, args, err = ToDialectSQL(MYSQL, In("id", ids))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2500, len(args))
+	assert.EqualValues(t, 1, strings.Count(sql, "id IN ("))
+	defer SetInLimit(MYSQL, threshold, strategy)
+
+	// the builder writes the values like InSplit for InTempTable
+	SetInLimit(MYSQL, 1000, InTempTable)
+	sql, args, err = ToDialectSQL(MYSQL, In("id", ids))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2500, len(args))
+	assert.EqualValues(t, 3, strings.Count(sql, "id IN ("))
+
+	SetInLimit(MYSQL, 2, InInline)
+
+	sql, args, err = ToDialectSQL(MYSQL, In("name", "a", "b", "c"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, "(name IN (?,?) OR name IN (?))", sql)
+	assert.EqualValues(t, 3, len(args))
+
+	sql, args, err = ToDialectSQL(MYSQL, Eq{"a": 1}.And(In("id", 1, 2, 3)))
+	assert.NoError(t, err)
+	assert.EqualValues(t, "a=? AND id IN (1,2,3)", sql)
+	assert.EqualValues(t, []interface{}{1}, args)
+
+	sql, _, err = ToSQL(In("id", ids))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, strings.Count(sql, "IN ("))
+}
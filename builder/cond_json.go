@@ -0,0 +1,141 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type jsonOp int
+
+const (
+	jsonHasKey jsonOp = iota
+	jsonContains
+)
+
+type condJSON struct {
+	op    jsonOp
+	col   string
+	path  string
+	value interface{}
+}
+
+var _ Cond = condJSON{}
+
+// JSONEq generates a condition which the scalar value at the path of the JSON column
+// equals to the value, the path looks like $.a.b[0]
+func JSONEq(col, path string, value interface{}) Cond {
+	return JSONExtract(col, path).Compare("=", value)
+}
+
+// JSONHasKey generates a condition which the path exists in the JSON column,
+// the column should be jsonb on Postgres
+func JSONHasKey(col, path string) Cond {
+	return condJSON{op: jsonHasKey, col: col, path: path}
+}
+
+// JSONContains generates a condition which the array at the path of the JSON column
+// contains the value, the column should be jsonb on Postgres
+func JSONContains(col, path string, value interface{}) Cond {
+	return condJSON{op: jsonContains, col: col, path: path, value: value}
+}
+
+func (j condJSON) WriteTo(w Writer) error {
+	if !jsonPathRegexp.MatchString(j.path) {
+		return ErrInvalidJSONPath
+	}
+	if j.op == jsonHasKey {
+		return j.hasKeyWriteTo(w)
+	}
+	return j.containsWriteTo(w)
+}
+
+func (j condJSON) hasKeyWriteTo(w Writer) error {
+	var err error
+	switch writerDialect(w) {
+	case MYSQL:
+		_, err = fmt.Fprintf(w, "JSON_CONTAINS_PATH(%s,'one','%s')", j.col, j.path)
+	case POSTGRES:
+		keys := jsonPathKeys(j.path)
+		if len(keys) == 0 {
+			_, err = fmt.Fprintf(w, "%s IS NOT NULL", j.col)
+			break
+		}
+		last := keys[len(keys)-1]
+		if _, e := strconv.Atoi(last); e == nil {
+			// the elements of arrays are not keys
+			_, err = fmt.Fprintf(w, "(%s #> '{%s}') IS NOT NULL", j.col, strings.Join(keys, ","))
+		} else if len(keys) == 1 {
+			// jsonb_exists is the function of operator ? which conflicts with the placeholder
+			_, err = fmt.Fprintf(w, "jsonb_exists(%s,'%s')", j.col, last)
+		} else {
+			_, err = fmt.Fprintf(w, "jsonb_exists(%s #> '{%s}','%s')", j.col, strings.Join(keys[:len(keys)-1], ","), last)
+		}
+	case SQLITE:
+		_, err = fmt.Fprintf(w, "json_type(%s,'%s') IS NOT NULL", j.col, j.path)
+	case MSSQL:
+		_, err = fmt.Fprintf(w, "(JSON_VALUE(%s,'%s') IS NOT NULL OR JSON_QUERY(%s,'%s') IS NOT NULL)",
+			j.col, j.path, j.col, j.path)
+	case ORACLE:
+		_, err = fmt.Fprintf(w, "JSON_EXISTS(%s,'%s')", j.col, j.path)
+	default:
+		return ErrNotSupportDialectType
+	}
+	return err
+}
+
+func (j condJSON) containsWriteTo(w Writer) error {
+	switch writerDialect(w) {
+	case MYSQL:
+		bs, err := writerJSONHandler(w).Marshal(j.value)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "JSON_CONTAINS(%s,?,'%s')", j.col, j.path); err != nil {
+			return err
+		}
+		w.Append(string(bs))
+	case POSTGRES:
+		bs, err := writerJSONHandler(w).Marshal([]interface{}{j.value})
+		if err != nil {
+			return err
+		}
+		target := j.col
+		if keys := jsonPathKeys(j.path); len(keys) > 0 {
+			target = fmt.Sprintf("(%s #> '{%s}')", j.col, strings.Join(keys, ","))
+		}
+		if _, err := fmt.Fprint(w, target, " @> CAST(? AS jsonb)"); err != nil {
+			return err
+		}
+		w.Append(string(bs))
+	case SQLITE:
+		if _, err := fmt.Fprintf(w, "EXISTS (SELECT 1 FROM json_each(%s,'%s') WHERE value=?)", j.col, j.path); err != nil {
+			return err
+		}
+		w.Append(j.value)
+	case MSSQL:
+		if _, err := fmt.Fprintf(w, "EXISTS (SELECT 1 FROM OPENJSON(%s,'%s') WHERE value=?)", j.col, j.path); err != nil {
+			return err
+		}
+		w.Append(j.value)
+	default:
+		return ErrNotSupportDialectType
+	}
+	return nil
+}
+
+func (j condJSON) And(conds ...Cond) Cond {
+	return And(j, And(conds...))
+}
+
+func (j condJSON) Or(conds ...Cond) Cond {
+	return Or(j, Or(conds...))
+}
+
+func (j condJSON) IsValid() bool {
+	return len(j.col) > 0 && len(j.path) > 0
+}
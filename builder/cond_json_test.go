@@ -0,0 +1,70 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import (
+	"testing"
+
+	"github.com/laixyz/xormplus/internal/json"
+	"github.com/stretchr/testify/assert"
+)
+
+type upperJSON struct {
+	json.StdJSON
+}
+
+func (upperJSON) Marshal(v interface{}) ([]byte, error) {
+	return []byte(`"GO"`), nil
+}
+
+func TestCond_JSON(t *testing.T) {
+	var cases = []struct {
+		cond    Cond
+		dialect string
+		sql     string
+		args    []interface{}
+	}{
+		{JSONEq("doc", "$.name", "lunny"), MYSQL, "JSON_UNQUOTE(JSON_EXTRACT(doc,'$.name'))=?", []interface{}{"lunny"}},
+		{JSONEq("doc", "$.name", "lunny"), POSTGRES, "(doc #>> '{name}')=?", []interface{}{"lunny"}},
+		{JSONEq("doc", "$.age", 18), SQLITE, "json_extract(doc,'$.age')=?", []interface{}{18}},
+		{JSONEq("doc", "$.name", "lunny"), MSSQL, "JSON_VALUE(doc,'$.name')=?", []interface{}{"lunny"}},
+		{JSONHasKey("doc", "$.a.b"), MYSQL, "JSON_CONTAINS_PATH(doc,'one','$.a.b')", nil},
+		{JSONHasKey("doc", "$.a"), POSTGRES, "jsonb_exists(doc,'a')", nil},
+		{JSONHasKey("doc", "$.a.b"), POSTGRES, "jsonb_exists(doc #> '{a}','b')", nil},
+		{JSONHasKey("doc", "$.a[1]"), POSTGRES, "(doc #> '{a,1}') IS NOT NULL", nil},
+		{JSONHasKey("doc", "$.a"), SQLITE, "json_type(doc,'$.a') IS NOT NULL", nil},
+		{JSONHasKey("doc", "$.a"), MSSQL, "(JSON_VALUE(doc,'$.a') IS NOT NULL OR JSON_QUERY(doc,'$.a') IS NOT NULL)", nil},
+		{JSONHasKey("doc", "$.a"), ORACLE, "JSON_EXISTS(doc,'$.a')", nil},
+		{JSONContains("doc", "$.tags", "go"), MYSQL, "JSON_CONTAINS(doc,?,'$.tags')", []interface{}{`"go"`}},
+		{JSONContains("doc", "$.tags", "go"), POSTGRES, "(doc #> '{tags}') @> CAST(? AS jsonb)", []interface{}{`["go"]`}},
+		{JSONContains("doc", "$", 1), POSTGRES, "doc @> CAST(? AS jsonb)", []interface{}{`[1]`}},
+		{JSONContains("doc", "$.tags", "go"), SQLITE, "EXISTS (SELECT 1 FROM json_each(doc,'$.tags') WHERE value=?)", []interface{}{"go"}},
+		{JSONContains("doc", "$.tags", "go"), MSSQL, "EXISTS (SELECT 1 FROM OPENJSON(doc,'$.tags') WHERE value=?)", []interface{}{"go"}},
+	}
+
+	for _, c := range cases {
+		sql, args, err := ToDialectSQL(c.dialect, c.cond)
+		assert.NoError(t, err)
+		assert.EqualValues(t, c.sql, sql)
+		assert.EqualValues(t, c.args, args)
+	}
+
+	_, _, err := ToDialectSQL(MYSQL, JSONHasKey("doc", "$.a'"))
+	assert.EqualValues(t, ErrInvalidJSONPath, err)
+
+	_, _, err = ToDialectSQL(ORACLE, JSONContains("doc", "$.tags", "go"))
+	assert.EqualValues(t, ErrNotSupportDialectType, err)
+
+	sql, args, err := Postgres().Select("id").From("doc").
+		Where(Eq{"kind": 1}.And(JSONContains("content", "$.tags", "go"), JSONEq("content", "$.name", "xorm"))).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT id FROM doc WHERE kind=$1 AND (content #> '{tags}') @> CAST($2 AS jsonb) AND (content #>> '{name}')=$3", sql)
+	assert.EqualValues(t, []interface{}{1, `["go"]`, "xorm"}, args)
+
+	// the values are marshaled by the handler of the writer
+	w := NewDialectWriter(MYSQL).SetJSONHandler(upperJSON{})
+	assert.NoError(t, JSONContains("doc", "$.tags", "go").WriteTo(w))
+	assert.EqualValues(t, []interface{}{`"GO"`}, w.Args())
+}
@@ -0,0 +1,426 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// condNode is the JSON representation of conditions, i.e.
+//
+//	{"op":"and","conds":[{"op":"eq","col":"a","value":1},{"op":"in","col":"b","values":[1,2]}]}
+//
+// the conditions with multiple columns like Eq{"a": 1, "b": 2} are represented as
+// "and" of the single column conditions ordered by the columns.
+type condNode struct {
+	Op     string          `json:"op"`
+	Col    string          `json:"col,omitempty"`
+	Cols   []string        `json:"cols,omitempty"`
+	Value  interface{}     `json:"value,omitempty"`
+	Values []interface{}   `json:"values,omitempty"`
+	Rows   [][]interface{} `json:"rows,omitempty"`
+	SQL    string          `json:"sql,omitempty"`
+	Path   string          `json:"path,omitempty"`
+	Query  string          `json:"query,omitempty"`
+	Mode   MatchMode       `json:"mode,omitempty"`
+	Table  string          `json:"table,omitempty"`
+	Index  string          `json:"index,omitempty"`
+	Conds  []*condNode     `json:"conds,omitempty"`
+}
+
+// MarshalCond marshals the condition to JSON. The values of the conditions are marshaled
+// as JSON values, so they should be strings, numbers, booleans or nil to be unmarshaled
+// as they are. The conditions with sub-queries or function expressions can't be marshaled.
+func MarshalCond(cond Cond) ([]byte, error) {
+	node, err := encodeCond(cond)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(node)
+}
+
+// UnmarshalCond unmarshals the condition from JSON without restricting the columns and the
+// operators except expr which is raw SQL, use CondDecoder to restrict the columns and the
+// operators if the JSON comes from untrusted sources or to allow expr
+func UnmarshalCond(data []byte) (Cond, error) {
+	return NewCondDecoder().Decode(data)
+}
+
+func encodeMap(op string, m map[string]interface{}) (*condNode, error) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	nodes := make([]*condNode, 0, len(keys))
+	for _, k := range keys {
+		v := m[k]
+		switch v.(type) {
+		case expr, *Builder, *Func, Incr, Decr:
+			return nil, ErrCondNotSerializable
+		}
+		if op == "eq" && isSlice(v) {
+			node, err := encodeIn("in", k, []interface{}{v})
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, node)
+			continue
+		}
+		nodes = append(nodes, &condNode{Op: op, Col: k, Value: v})
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return &condNode{Op: "and", Conds: nodes}, nil
+}
+
+func isSlice(v interface{}) bool {
+	if _, ok := v.([]byte); ok {
+		return false
+	}
+	return v != nil && reflect.TypeOf(v).Kind() == reflect.Slice
+}
+
+func encodeIn(op, col string, vals []interface{}) (*condNode, error) {
+	if len(vals) == 1 {
+		switch vals[0].(type) {
+		case expr, *Builder:
+			return nil, ErrCondNotSerializable
+		}
+		if isSlice(vals[0]) {
+			v := reflect.ValueOf(vals[0])
+			vals = make([]interface{}, 0, v.Len())
+			for i := 0; i < v.Len(); i++ {
+				vals = append(vals, v.Index(i).Interface())
+			}
+		}
+	}
+	return &condNode{Op: op, Col: col, Values: vals}, nil
+}
+
+func encodeConds(op string, conds []Cond) (*condNode, error) {
+	node := &condNode{Op: op, Conds: make([]*condNode, 0, len(conds))}
+	for _, cond := range conds {
+		sub, err := encodeCond(cond)
+		if err != nil {
+			return nil, err
+		}
+		if sub != nil {
+			node.Conds = append(node.Conds, sub)
+		}
+	}
+	return node, nil
+}
+
+// encodeCond converts the condition to condNode, nil means an empty condition
+func encodeCond(cond Cond) (*condNode, error) {
+	switch c := cond.(type) {
+	case nil, condEmpty:
+		return nil, nil
+	case Eq:
+		return encodeMap("eq", c)
+	case Neq:
+		return encodeMap("neq", c)
+	case Lt:
+		return encodeMap("lt", c)
+	case Lte:
+		return encodeMap("lte", c)
+	case Gt:
+		return encodeMap("gt", c)
+	case Gte:
+		return encodeMap("gte", c)
+	case Like:
+		return &condNode{Op: "like", Col: c[0], Value: c[1]}, nil
+	case Between:
+		for _, v := range []interface{}{c.LessVal, c.MoreVal} {
+			switch v.(type) {
+			case expr, *Builder, *Func:
+				return nil, ErrCondNotSerializable
+			}
+		}
+		return &condNode{Op: "between", Col: c.Col, Values: []interface{}{c.LessVal, c.MoreVal}}, nil
+	case condIn:
+		return encodeIn("in", c.col, c.vals)
+	case condNotIn:
+		return encodeIn("notin", c.col, c.vals)
+	case IsNull:
+		return &condNode{Op: "null", Col: c[0]}, nil
+	case NotNull:
+		return &condNode{Op: "notnull", Col: c[0]}, nil
+	case condAnd:
+		return encodeConds("and", c)
+	case condOr:
+		return encodeConds("or", c)
+	case Not:
+		return encodeConds("not", c[:])
+	case expr:
+		return &condNode{Op: "expr", SQL: c.sql, Values: c.args}, nil
+	case condIf:
+		// only the chosen condition is kept
+		if c.condition {
+			return encodeCond(c.condTrue)
+		}
+		return encodeCond(c.condFalse)
+	case condInTuple:
+		return &condNode{Op: "intuple", Cols: c.cols, Rows: c.vals}, nil
+	case condJSON:
+		if c.op == jsonHasKey {
+			return &condNode{Op: "jsonhaskey", Col: c.col, Path: c.path}, nil
+		}
+		return &condNode{Op: "jsoncontains", Col: c.col, Path: c.path, Value: c.value}, nil
+	case *FullTextMatch:
+		return &condNode{Op: "match", Cols: c.cols, Query: c.query, Mode: c.mode, Table: c.table, Index: c.index}, nil
+	}
+	return nil, ErrCondNotSerializable
+}
+
+// CondDecoder unmarshals the conditions from JSON and restricts the columns and the
+// operators of them. The operators are and, or, not, eq, neq, lt, lte, gt, gte, like,
+// between, in, notin, null, notnull, expr, intuple, jsonhaskey, jsoncontains and match.
+type CondDecoder struct {
+	columns   map[string]bool
+	operators map[string]bool
+	allowExpr bool
+}
+
+// NewCondDecoder creates a decoder which accepts all the columns and the operators except
+// expr, see AllowExpr
+func NewCondDecoder() *CondDecoder {
+	return &CondDecoder{}
+}
+
+// AllowExpr accepts expr which is raw SQL, it could also be accepted by AllowOperators.
+// expr is always rejected if the columns are restricted since the columns in the SQL
+// can't be checked.
+func (d *CondDecoder) AllowExpr() *CondDecoder {
+	d.allowExpr = true
+	return d
+}
+
+// AllowColumns restricts the columns of the conditions, the columns are case-insensitive
+func (d *CondDecoder) AllowColumns(cols ...string) *CondDecoder {
+	if d.columns == nil {
+		d.columns = make(map[string]bool, len(cols))
+	}
+	for _, col := range cols {
+		d.columns[strings.ToLower(col)] = true
+	}
+	return d
+}
+
+// AllowOperators restricts the operators of the conditions. Note that expr is raw SQL,
+// so it should not be allowed if the JSON comes from untrusted sources.
+func (d *CondDecoder) AllowOperators(ops ...string) *CondDecoder {
+	if d.operators == nil {
+		d.operators = make(map[string]bool, len(ops))
+	}
+	for _, op := range ops {
+		d.operators[strings.ToLower(op)] = true
+	}
+	return d
+}
+
+// Decode unmarshals the condition from JSON
+func (d *CondDecoder) Decode(data []byte) (Cond, error) {
+	var node *condNode
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&node); err != nil {
+		return nil, err
+	}
+	if node == nil {
+		return NewCond(), nil
+	}
+	return d.decode(node)
+}
+
+func (d *CondDecoder) checkExpr() error {
+	if d.columns != nil || (!d.allowExpr && !d.operators["expr"]) {
+		return ErrOperatorNotAllowed
+	}
+	return nil
+}
+
+// isIdentifier returns true if s is empty or a name which could be written into SQL
+// without quoting, i.e. a table name with the schema
+func isIdentifier(s string) bool {
+	for _, part := range strings.Split(s, ".") {
+		if len(s) > 0 && len(part) == 0 {
+			return false
+		}
+		for i, c := range part {
+			switch {
+			case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+			case c >= '0' && c <= '9' && i > 0:
+			default:
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (d *CondDecoder) checkColumns(cols ...string) error {
+	if len(cols) == 0 {
+		return ErrInvalidCondJSON
+	}
+	for _, col := range cols {
+		if len(col) == 0 {
+			return ErrInvalidCondJSON
+		}
+		if d.columns != nil && !d.columns[strings.ToLower(col)] {
+			return ErrColumnNotAllowed
+		}
+	}
+	return nil
+}
+
+// decodeValue converts the JSON value to string, int64, float64, bool or nil
+func decodeValue(v interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case nil, string, bool:
+		return t, nil
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i, nil
+		}
+		return t.Float64()
+	}
+	return nil, ErrInvalidCondJSON
+}
+
+func decodeValues(vs []interface{}) ([]interface{}, error) {
+	vals := make([]interface{}, 0, len(vs))
+	for _, v := range vs {
+		val, err := decodeValue(v)
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, val)
+	}
+	return vals, nil
+}
+
+func (d *CondDecoder) decode(node *condNode) (Cond, error) {
+	if node == nil {
+		return nil, ErrInvalidCondJSON
+	}
+	op := strings.ToLower(node.Op)
+	if d.operators != nil && !d.operators[op] {
+		return nil, ErrOperatorNotAllowed
+	}
+
+	switch op {
+	case "and", "or", "not":
+		conds := make([]Cond, 0, len(node.Conds))
+		for _, sub := range node.Conds {
+			cond, err := d.decode(sub)
+			if err != nil {
+				return nil, err
+			}
+			conds = append(conds, cond)
+		}
+		switch op {
+		case "and":
+			return And(conds...), nil
+		case "or":
+			return Or(conds...), nil
+		}
+		if len(conds) != 1 {
+			return nil, ErrInvalidCondJSON
+		}
+		return Not{conds[0]}, nil
+	case "expr":
+		if err := d.checkExpr(); err != nil {
+			return nil, err
+		}
+		if len(node.SQL) == 0 {
+			return nil, ErrInvalidCondJSON
+		}
+		args, err := decodeValues(node.Values)
+		if err != nil {
+			return nil, err
+		}
+		return Expr(node.SQL, args...), nil
+	case "intuple":
+		if err := d.checkColumns(node.Cols...); err != nil {
+			return nil, err
+		}
+		rows := make([][]interface{}, 0, len(node.Rows))
+		for _, row := range node.Rows {
+			vals, err := decodeValues(row)
+			if err != nil {
+				return nil, err
+			}
+			rows = append(rows, vals)
+		}
+		return InTuple(node.Cols, rows), nil
+	case "match":
+		if err := d.checkColumns(node.Cols...); err != nil {
+			return nil, err
+		}
+		if !isIdentifier(node.Table) || !isIdentifier(node.Index) {
+			return nil, ErrInvalidCondJSON
+		}
+		return Match(node.Cols, node.Query, node.Mode).Index(node.Table, node.Index), nil
+	}
+
+	if err := d.checkColumns(node.Col); err != nil {
+		return nil, err
+	}
+	value, err := decodeValue(node.Value)
+	if err != nil {
+		return nil, err
+	}
+	values, err := decodeValues(node.Values)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "eq":
+		return Eq{node.Col: value}, nil
+	case "neq":
+		return Neq{node.Col: value}, nil
+	case "lt":
+		return Lt{node.Col: value}, nil
+	case "lte":
+		return Lte{node.Col: value}, nil
+	case "gt":
+		return Gt{node.Col: value}, nil
+	case "gte":
+		return Gte{node.Col: value}, nil
+	case "like":
+		s, ok := value.(string)
+		if !ok || len(s) == 0 {
+			return nil, ErrInvalidCondJSON
+		}
+		return Like{node.Col, s}, nil
+	case "between":
+		if len(values) != 2 {
+			return nil, ErrInvalidCondJSON
+		}
+		return Between{Col: node.Col, LessVal: values[0], MoreVal: values[1]}, nil
+	case "in":
+		return In(node.Col, values...), nil
+	case "notin":
+		return NotIn(node.Col, values...), nil
+	case "null":
+		return IsNull{node.Col}, nil
+	case "notnull":
+		return NotNull{node.Col}, nil
+	case "jsonhaskey":
+		return JSONHasKey(node.Col, node.Path), nil
+	case "jsoncontains":
+		return JSONContains(node.Col, node.Path, value), nil
+	}
+	return nil, ErrInvalidCondJSON
+}
@@ -0,0 +1,130 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalCond(t *testing.T) {
+	var conds = []Cond{
+		NewCond(),
+		Eq{"a": 1, "b": "c", "d": nil},
+		Eq{"a": []int{1, 2}},
+		Neq{"a": 1.5},
+		Lt{"a": 1},
+		Lte{"a": 1},
+		Gt{"a": true},
+		Gte{"a": "2020-01-01"},
+		Like{"name", "%foo"},
+		Between{"a", 1, 10},
+		In("a", 1, 2, 3),
+		In("a", []string{"b", "c"}),
+		NotIn("a", []int64{1, 2}),
+		IsNull{"a"},
+		NotNull{"a"},
+		Expr("a=? OR b=?", 1, "c"),
+		Not{Eq{"a": 1, "b": 2}},
+		Or(Eq{"a": 1}, And(Gt{"b": 2}, Lt{"c": 3}), Not{IsNull{"d"}}),
+		If(true, Eq{"a": 1}),
+		InTuple([]string{"a", "b"}, [][]interface{}{{1, "c"}, {2, "d"}}),
+		JSONHasKey("doc", "$.a.b"),
+		JSONContains("doc", "$.tags", "go"),
+		Match([]string{"title", "body"}, "foo bar", MatchPhrase).Index("post", "content"),
+	}
+
+	for _, cond := range conds {
+		bs, err := MarshalCond(cond)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		decoded, err := NewCondDecoder().AllowExpr().Decode(bs)
+		if !assert.NoError(t, err, string(bs)) {
+			continue
+		}
+
+		for _, dialect := range []string{MYSQL, SQLITE} {
+			sql, args, err := ToDialectSQL(dialect, cond)
+			assert.NoError(t, err)
+			sql2, args2, err := ToDialectSQL(dialect, decoded)
+			assert.NoError(t, err)
+			assert.EqualValues(t, sql, sql2, string(bs))
+			assert.EqualValues(t, len(args), len(args2), string(bs))
+			for i := range args {
+				assert.EqualValues(t, args[i], args2[i], string(bs))
+			}
+		}
+	}
+
+	bs, err := MarshalCond(And(Eq{"a": 1}, In("b", "c", "d")))
+	assert.NoError(t, err)
+	assert.EqualValues(t, `{"op":"and","conds":[{"op":"eq","col":"a","value":1},{"op":"in","col":"b","values":["c","d"]}]}`, string(bs))
+
+	for _, cond := range []Cond{
+		Exists(Select("id").From("t")),
+		Eq{"a": Now()},
+		Eq{"a": Expr("b+1")},
+		In("a", Select("id").From("t")),
+		JSONEq("doc", "$.a", 1),
+	} {
+		_, err = MarshalCond(cond)
+		assert.EqualValues(t, ErrCondNotSerializable, err)
+	}
+}
+
+func TestCondDecoder(t *testing.T) {
+	decoder := NewCondDecoder().AllowColumns("a", "B").AllowOperators("and", "or", "eq", "in")
+
+	cond, err := decoder.Decode([]byte(`{"op":"or","conds":[{"op":"eq","col":"a","value":1},{"op":"in","col":"b","values":[1.5,"c"]}]}`))
+	assert.NoError(t, err)
+	sql, args, err := ToSQL(cond)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "a=? OR b IN (?,?)", sql)
+	assert.EqualValues(t, []interface{}{int64(1), 1.5, "c"}, args)
+
+	var cases = []struct {
+		json string
+		err  error
+	}{
+		{`{"op":"eq","col":"c","value":1}`, ErrColumnNotAllowed},
+		{`{"op":"and","conds":[{"op":"eq","col":"a","value":1},{"op":"in","col":"c","values":[1]}]}`, ErrColumnNotAllowed},
+		{`{"op":"expr","sql":"1=1"}`, ErrOperatorNotAllowed},
+		{`{"op":"not","conds":[{"op":"eq","col":"a","value":1}]}`, ErrOperatorNotAllowed},
+		{`{"op":"eq","col":"a","value":{"b":1}}`, ErrInvalidCondJSON},
+		{`{"op":"eq","value":1}`, ErrInvalidCondJSON},
+		{`{"op":"and","conds":[null]}`, ErrInvalidCondJSON},
+	}
+	for _, c := range cases {
+		_, err := decoder.Decode([]byte(c.json))
+		assert.EqualValues(t, c.err, err, c.json)
+	}
+
+	// expr is raw SQL which should be allowed explicitly
+	_, err = UnmarshalCond([]byte(`{"op":"expr","sql":"1=1"}`))
+	assert.EqualValues(t, ErrOperatorNotAllowed, err)
+	cond, err = NewCondDecoder().AllowOperators("expr").Decode([]byte(`{"op":"expr","sql":"1=1"}`))
+	assert.NoError(t, err)
+	sql, _, err = ToSQL(cond)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "1=1", sql)
+	_, err = NewCondDecoder().AllowExpr().AllowColumns("a").Decode([]byte(`{"op":"expr","sql":"1=1"}`))
+	assert.EqualValues(t, ErrOperatorNotAllowed, err)
+
+	for _, index := range []string{`"table":"post;DROP TABLE post","index":"content"`, `"table":"post","index":"content)--"`, `"table":".post","index":"content"`} {
+		_, err = UnmarshalCond([]byte(`{"op":"match","cols":["title"],"query":"foo",` + index + `}`))
+		assert.EqualValues(t, ErrInvalidCondJSON, err, index)
+	}
+	_, err = UnmarshalCond([]byte(`{"op":"match","cols":["title"],"query":"foo","table":"main.post","index":"FTS_post_content"}`))
+	assert.NoError(t, err)
+
+	_, err = UnmarshalCond([]byte(`{"op":"unknown","col":"a"}`))
+	assert.EqualValues(t, ErrInvalidCondJSON, err)
+	_, err = UnmarshalCond([]byte(`{"op":"between","col":"a","values":[1]}`))
+	assert.EqualValues(t, ErrInvalidCondJSON, err)
+	_, err = UnmarshalCond([]byte(`{"op":`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,214 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MatchMode is the way the full-text query is parsed
+type MatchMode int
+
+// all the match modes
+const (
+	// MatchNatural matches the words in the query
+	MatchNatural MatchMode = iota
+	// MatchBoolean passes the query with the boolean syntax of the database
+	MatchBoolean
+	// MatchPhrase matches the query as a phrase
+	MatchPhrase
+)
+
+// FullTextMatch is a full-text search condition, the columns should have a full-text index
+// on MySQL, Postgres and SQLite which is created by the fulltext tag. MSSQL and Oracle require
+// the full-text indexes which are created manually.
+type FullTextMatch struct {
+	cols  []string
+	query string
+	mode  MatchMode
+	table string
+	index string
+}
+
+var _ Cond = &FullTextMatch{}
+
+// Match generates a full-text search condition on the columns
+func Match(cols []string, query string, mode MatchMode) *FullTextMatch {
+	return &FullTextMatch{cols: cols, query: query, mode: mode}
+}
+
+// Index sets the table and the name of the full-text index, it's required by SQLite
+// since the full-text indexes of SQLite are FTS5 virtual tables
+func (m *FullTextMatch) Index(table, name string) *FullTextMatch {
+	m.table, m.index = table, name
+	return m
+}
+
+func (m *FullTextMatch) ftsTable() (string, error) {
+	if len(m.table) == 0 || len(m.index) == 0 {
+		return "", ErrNoFullTextIndex
+	}
+	if strings.HasPrefix(m.index, "FTS_") {
+		return m.index, nil
+	}
+	return "FTS_" + m.table + "_" + m.index, nil
+}
+
+// tsvector is the same as the full-text indexes of Postgres
+func (m *FullTextMatch) tsvector() string {
+	var exprs = make([]string, 0, len(m.cols))
+	for _, col := range m.cols {
+		exprs = append(exprs, "COALESCE("+col+",'')")
+	}
+	return "to_tsvector('simple'," + strings.Join(exprs, " || ' ' || ") + ")"
+}
+
+func (m *FullTextMatch) tsquery() string {
+	switch m.mode {
+	case MatchBoolean:
+		return "to_tsquery('simple',?)"
+	case MatchPhrase:
+		return "phraseto_tsquery('simple',?)"
+	default:
+		return "plainto_tsquery('simple',?)"
+	}
+}
+
+func (m *FullTextMatch) against() string {
+	switch m.mode {
+	case MatchBoolean, MatchPhrase:
+		return "IN BOOLEAN MODE"
+	default:
+		return "IN NATURAL LANGUAGE MODE"
+	}
+}
+
+// quoteQuery quotes the query as a phrase or the words of the query so that
+// the punctuations in it are not treated as the syntax of SQLite and MSSQL
+func (m *FullTextMatch) quoteQuery() string {
+	switch m.mode {
+	case MatchBoolean:
+		return m.query
+	case MatchPhrase:
+		return `"` + strings.Replace(m.query, `"`, `""`, -1) + `"`
+	default:
+		words := strings.Fields(m.query)
+		for i, word := range words {
+			words[i] = `"` + strings.Replace(word, `"`, `""`, -1) + `"`
+		}
+		return strings.Join(words, " ")
+	}
+}
+
+// WriteTo writes SQL to Writer according to the dialect of the writer
+func (m *FullTextMatch) WriteTo(w Writer) error {
+	if len(m.cols) == 0 {
+		return ErrNoColumnToMatch
+	}
+
+	switch writerDialect(w) {
+	case MYSQL:
+		query := m.query
+		if m.mode == MatchPhrase {
+			query = `"` + strings.Replace(query, `"`, "", -1) + `"`
+		}
+		if _, err := fmt.Fprintf(w, "MATCH (%s) AGAINST (? %s)", strings.Join(m.cols, ","), m.against()); err != nil {
+			return err
+		}
+		w.Append(query)
+	case POSTGRES:
+		if _, err := fmt.Fprint(w, m.tsvector(), " @@ ", m.tsquery()); err != nil {
+			return err
+		}
+		w.Append(m.query)
+	case SQLITE:
+		fts, err := m.ftsTable()
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%s.rowid IN (SELECT rowid FROM %s WHERE %s MATCH ?)", m.table, fts, fts); err != nil {
+			return err
+		}
+		w.Append(m.quoteQuery())
+	case MSSQL:
+		fn := "CONTAINS"
+		if m.mode == MatchNatural {
+			fn = "FREETEXT"
+		}
+		if _, err := fmt.Fprintf(w, "%s((%s),?)", fn, strings.Join(m.cols, ",")); err != nil {
+			return err
+		}
+		if m.mode == MatchPhrase {
+			w.Append(m.quoteQuery())
+		} else {
+			w.Append(m.query)
+		}
+	case ORACLE:
+		if len(m.cols) > 1 {
+			return ErrNotSupportDialectType
+		}
+		if _, err := fmt.Fprintf(w, "CONTAINS(%s,?,1)>0", m.cols[0]); err != nil {
+			return err
+		}
+		w.Append(m.query)
+	default:
+		return ErrNotSupportDialectType
+	}
+	return nil
+}
+
+// Score returns the relevance of the full-text search, the higher the more relevant
+func (m *FullTextMatch) Score() *Func {
+	return &Func{render: func(w Writer, dialect string) error {
+		if len(m.cols) == 0 {
+			return ErrNoColumnToMatch
+		}
+
+		switch dialect {
+		case MYSQL:
+			return m.WriteTo(w)
+		case POSTGRES:
+			if _, err := fmt.Fprintf(w, "ts_rank(%s,%s)", m.tsvector(), m.tsquery()); err != nil {
+				return err
+			}
+			w.Append(m.query)
+		case SQLITE:
+			fts, err := m.ftsTable()
+			if err != nil {
+				return err
+			}
+			// bm25 of FTS5 is the lower the more relevant
+			if _, err := fmt.Fprintf(w, "(SELECT -bm25(%s) FROM %s WHERE %s MATCH ? AND rowid=%s.rowid)",
+				fts, fts, fts, m.table); err != nil {
+				return err
+			}
+			w.Append(m.quoteQuery())
+		case ORACLE:
+			// the label is set by the condition
+			if _, err := fmt.Fprint(w, "SCORE(1)"); err != nil {
+				return err
+			}
+		default:
+			return ErrUnsupportedFunc
+		}
+		return nil
+	}}
+}
+
+// And implements And with other conditions
+func (m *FullTextMatch) And(conds ...Cond) Cond {
+	return And(m, And(conds...))
+}
+
+// Or implements Or with other conditions
+func (m *FullTextMatch) Or(conds ...Cond) Cond {
+	return Or(m, Or(conds...))
+}
+
+// IsValid tests if this condition is valid
+func (m *FullTextMatch) IsValid() bool {
+	return len(m.cols) > 0 && len(m.query) > 0
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCond_Match(t *testing.T) {
+	var cases = []struct {
+		cond    Cond
+		dialect string
+		sql     string
+		args    []interface{}
+	}{
+		{Match([]string{"title", "body"}, "go orm", MatchNatural), MYSQL,
+			"MATCH (title,body) AGAINST (? IN NATURAL LANGUAGE MODE)", []interface{}{"go orm"}},
+		{Match([]string{"title"}, "+go -java", MatchBoolean), MYSQL,
+			"MATCH (title) AGAINST (? IN BOOLEAN MODE)", []interface{}{"+go -java"}},
+		{Match([]string{"title"}, `go "orm"`, MatchPhrase), MYSQL,
+			"MATCH (title) AGAINST (? IN BOOLEAN MODE)", []interface{}{`"go orm"`}},
+		{Match([]string{"title", "body"}, "go orm", MatchNatural), POSTGRES,
+			"to_tsvector('simple',COALESCE(title,'') || ' ' || COALESCE(body,'')) @@ plainto_tsquery('simple',?)", []interface{}{"go orm"}},
+		{Match([]string{"title"}, "go & orm", MatchBoolean), POSTGRES,
+			"to_tsvector('simple',COALESCE(title,'')) @@ to_tsquery('simple',?)", []interface{}{"go & orm"}},
+		{Match([]string{"title"}, "go orm", MatchPhrase).Index("doc", "title"), SQLITE,
+			"doc.rowid IN (SELECT rowid FROM FTS_doc_title WHERE FTS_doc_title MATCH ?)", []interface{}{`"go orm"`}},
+		{Match([]string{"title"}, "go-orm xorm", MatchNatural).Index("doc", "FTS_doc_title"), SQLITE,
+			"doc.rowid IN (SELECT rowid FROM FTS_doc_title WHERE FTS_doc_title MATCH ?)", []interface{}{`"go-orm" "xorm"`}},
+		{Match([]string{"title", "body"}, "go orm", MatchNatural), MSSQL,
+			"FREETEXT((title,body),?)", []interface{}{"go orm"}},
+		{Match([]string{"title"}, "go orm", MatchPhrase), MSSQL,
+			"CONTAINS((title),?)", []interface{}{`"go orm"`}},
+		{Match([]string{"title"}, "go", MatchNatural), ORACLE,
+			"CONTAINS(title,?,1)>0", []interface{}{"go"}},
+	}
+
+	for _, c := range cases {
+		sql, args, err := ToDialectSQL(c.dialect, c.cond)
+		assert.NoError(t, err)
+		assert.EqualValues(t, c.sql, sql)
+		assert.EqualValues(t, c.args, args)
+	}
+
+	_, _, err := ToDialectSQL(SQLITE, Match([]string{"title"}, "go", MatchNatural))
+	assert.EqualValues(t, ErrNoFullTextIndex, err)
+
+	assert.EqualValues(t, ErrNoColumnToMatch, Match(nil, "go", MatchNatural).WriteTo(NewDialectWriter(MYSQL)))
+}
+
+func TestBuilder_MatchScore(t *testing.T) {
+	m := Match([]string{"title"}, "go", MatchNatural)
+	sql, args, err := MySQL().Select("id").SelectExpr(m.Score().As("score")).From("doc").
+		Where(m).OrderByExpr(m.Score().Desc()).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT id,MATCH (title) AGAINST (? IN NATURAL LANGUAGE MODE) AS score FROM doc "+
+		"WHERE MATCH (title) AGAINST (? IN NATURAL LANGUAGE MODE) ORDER BY MATCH (title) AGAINST (? IN NATURAL LANGUAGE MODE) DESC", sql)
+	assert.EqualValues(t, []interface{}{"go", "go", "go"}, args)
+
+	sql, args, err = Postgres().Select("id").SelectExpr(m.Score().As("score")).From("doc").Where(m).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT id,ts_rank(to_tsvector('simple',COALESCE(title,'')),plainto_tsquery('simple',$1)) AS score FROM doc "+
+		"WHERE to_tsvector('simple',COALESCE(title,'')) @@ plainto_tsquery('simple',$2)", sql)
+	assert.EqualValues(t, []interface{}{"go", "go"}, args)
+
+	m = Match([]string{"title"}, "go", MatchNatural).Index("doc", "title")
+	sql, _, err = SQLite().Select("id").SelectExpr(m.Score().As("score")).From("doc").Where(m).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT id,(SELECT -bm25(FTS_doc_title) FROM FTS_doc_title WHERE FTS_doc_title MATCH ? AND rowid=doc.rowid) AS score "+
+		"FROM doc WHERE doc.rowid IN (SELECT rowid FROM FTS_doc_title WHERE FTS_doc_title MATCH ?)", sql)
+
+	_, _, err = MsSQL().Select("id").SelectExpr(m.Score().As("score")).From("doc").ToSQL()
+	assert.EqualValues(t, ErrUnsupportedFunc, err)
+}
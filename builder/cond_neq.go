@@ -49,6 +49,16 @@ func (neq Neq) WriteTo(w Writer) error {
 			if _, err := fmt.Fprintf(w, ")"); err != nil {
 				return err
 			}
+		case *Func:
+			// the function may append its own args, so flush the previous ones
+			w.Append(args...)
+			args = args[:0]
+			if _, err := fmt.Fprintf(w, "%s<>", k); err != nil {
+				return err
+			}
+			if err := v.(*Func).WriteTo(w); err != nil {
+				return err
+			}
 		default:
 			if _, err := fmt.Fprintf(w, "%s<>?", k); err != nil {
 				return err
@@ -28,6 +28,9 @@ func (condNotIn condNotIn) WriteTo(w Writer) error {
 	if len(condNotIn.vals) <= 0 {
 		return condNotIn.handleBlank(w)
 	}
+	if ok, err := writeLimitedIn(w, condNotIn.col, condNotIn.vals, true); ok {
+		return err
+	}
 
 	switch condNotIn.vals[0].(type) {
 	case []int8:
@@ -0,0 +1,121 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import (
+	"fmt"
+	"strings"
+)
+
+type condInTuple struct {
+	cols []string
+	vals [][]interface{}
+}
+
+var _ Cond = condInTuple{}
+
+// InTuple generates row-value IN condition, i.e. (a,b) IN ((?,?),(?,?)). For the
+// dialects which don't support row-values it is written as (a=? AND b=?) OR (a=? AND b=?),
+// SQLite writes the values as (a,b) IN (VALUES (?,?),(?,?))
+func InTuple(cols []string, values [][]interface{}) Cond {
+	return condInTuple{cols, values}
+}
+
+func (tuple condInTuple) WriteTo(w Writer) error {
+	if len(tuple.vals) == 0 {
+		_, err := fmt.Fprint(w, "0=1")
+		return err
+	}
+	for _, row := range tuple.vals {
+		if len(row) != len(tuple.cols) {
+			return ErrTupleMismatch
+		}
+	}
+
+	if len(tuple.cols) == 1 {
+		vals := make([]interface{}, 0, len(tuple.vals))
+		for _, row := range tuple.vals {
+			vals = append(vals, row[0])
+		}
+		return In(tuple.cols[0], vals...).WriteTo(w)
+	}
+	if writerDialect(w) == MSSQL {
+		return tuple.expandWriteTo(w)
+	}
+
+	questionMark := "(" + strings.Repeat("?,", len(tuple.cols)-1) + "?)"
+	if _, err := fmt.Fprintf(w, "(%s) IN (", strings.Join(tuple.cols, ",")); err != nil {
+		return err
+	}
+	// SQLite only accepts row values of a sub-query in IN
+	if writerDialect(w) == SQLITE {
+		if _, err := fmt.Fprint(w, "VALUES "); err != nil {
+			return err
+		}
+	}
+	for i, row := range tuple.vals {
+		if i > 0 {
+			if _, err := fmt.Fprint(w, ","); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprint(w, questionMark); err != nil {
+			return err
+		}
+		w.Append(row...)
+	}
+	_, err := fmt.Fprint(w, ")")
+	return err
+}
+
+func (tuple condInTuple) expandWriteTo(w Writer) error {
+	if len(tuple.vals) > 1 {
+		if _, err := fmt.Fprint(w, "("); err != nil {
+			return err
+		}
+	}
+	for i, row := range tuple.vals {
+		if i > 0 {
+			if _, err := fmt.Fprint(w, " OR "); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprint(w, "("); err != nil {
+			return err
+		}
+		for j, col := range tuple.cols {
+			if j > 0 {
+				if _, err := fmt.Fprint(w, " AND "); err != nil {
+					return err
+				}
+			}
+			if _, err := fmt.Fprint(w, col, "=?"); err != nil {
+				return err
+			}
+		}
+		w.Append(row...)
+		if _, err := fmt.Fprint(w, ")"); err != nil {
+			return err
+		}
+	}
+	if len(tuple.vals) > 1 {
+		if _, err := fmt.Fprint(w, ")"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tuple condInTuple) And(conds ...Cond) Cond {
+	return And(tuple, And(conds...))
+}
+
+func (tuple condInTuple) Or(conds ...Cond) Cond {
+	return Or(tuple, Or(conds...))
+}
+
+func (tuple condInTuple) IsValid() bool {
+	return len(tuple.cols) > 0
+}
@@ -0,0 +1,45 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCond_InTuple(t *testing.T) {
+	cond := InTuple([]string{"a", "b"}, [][]interface{}{{1, "x"}, {2, "y"}})
+	sql, args, err := ToSQL(cond)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "(a,b) IN ((?,?),(?,?))", sql)
+	assert.EqualValues(t, []interface{}{1, "x", 2, "y"}, args)
+
+	sql, args, err = ToDialectSQL(MSSQL, Eq{"c": 3}.And(cond))
+	assert.NoError(t, err)
+	assert.EqualValues(t, "c=? AND ((a=? AND b=?) OR (a=? AND b=?))", sql)
+	assert.EqualValues(t, []interface{}{3, 1, "x", 2, "y"}, args)
+
+	sql, _, err = ToDialectSQL(SQLITE, cond)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "(a,b) IN (VALUES (?,?),(?,?))", sql)
+
+	sql, args, err = MsSQL().Select("*").From("t").Where(InTuple([]string{"a", "b"}, [][]interface{}{{1, "x"}})).ToSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT * FROM t WHERE (a=@p1 AND b=@p2)", sql)
+	assert.EqualValues(t, 2, len(args))
+
+	sql, args, err = ToSQL(InTuple([]string{"a"}, [][]interface{}{{1}, {2}}))
+	assert.NoError(t, err)
+	assert.EqualValues(t, "a IN (?,?)", sql)
+	assert.EqualValues(t, []interface{}{1, 2}, args)
+
+	sql, _, err = ToSQL(InTuple([]string{"a", "b"}, nil))
+	assert.NoError(t, err)
+	assert.EqualValues(t, "0=1", sql)
+
+	_, _, err = ToSQL(InTuple([]string{"a", "b"}, [][]interface{}{{1}}))
+	assert.EqualValues(t, ErrTupleMismatch, err)
+}
@@ -37,4 +37,40 @@ var (
 	ErrUnnamedDerivedTable = errors.New("Every derived table must have its own alias")
 	// ErrInconsistentDialect Inconsistent dialect in same builder
 	ErrInconsistentDialect = errors.New("Inconsistent dialect in same builder")
+	// ErrInvalidCTE common table expression needs a name and a query
+	ErrInvalidCTE = errors.New("Common table expression needs a name and a query")
+	// ErrWindowNotSupported window functions are not supported by the version of database
+	ErrWindowNotSupported = errors.New("Window functions are not supported by this database version")
+	// ErrUnnamedWindow window functions and expressions must have an alias when the query is wrapped by limitation
+	ErrUnnamedWindow = errors.New("Every selected window function or expression must have its own alias when limit is used")
+	// ErrNoSubQuery no sub-query in EXISTS, ANY or ALL condition
+	ErrNoSubQuery = errors.New("No sub-query indicated")
+	// ErrTupleMismatch tuple values don't match the columns
+	ErrTupleMismatch = errors.New("Number of tuple values doesn't match the columns")
+	// ErrUnsupportedLockMode lock mode is not supported by the dialect
+	ErrUnsupportedLockMode = errors.New("Unsupported lock mode")
+	// ErrInsertValuesMismatch number of values doesn't match the columns to insert
+	ErrInsertValuesMismatch = errors.New("Number of values doesn't match the columns to insert")
+	// ErrNoConflictColumns the columns to detect conflicts are required
+	ErrNoConflictColumns = errors.New("No conflict column(s) indicated")
+	// ErrUnsupportedFunc function expression is not supported by the dialect
+	ErrUnsupportedFunc = errors.New("Unsupported function expression")
+	// ErrInvalidJSONPath JSON path should look like $.a.b[0]
+	ErrInvalidJSONPath = errors.New("Invalid JSON path")
+	// ErrNoOrderForTies limit with ties requires ORDER BY
+	ErrNoOrderForTies = errors.New("Limit with ties requires ORDER BY")
+	// ErrNoColumnToMatch no columns indicated in full-text search condition
+	ErrNoColumnToMatch = errors.New("No column(s) to match")
+	// ErrNoFullTextIndex full-text search condition of SQLite requires the table and the index
+	ErrNoFullTextIndex = errors.New("The table and the full-text index are required")
+	// ErrCondNotSerializable condition with sub-queries or function expressions can't be marshaled
+	ErrCondNotSerializable = errors.New("Condition could not be serialized")
+	// ErrInvalidCondJSON the JSON representation of condition is not correct
+	ErrInvalidCondJSON = errors.New("Invalid JSON condition")
+	// ErrColumnNotAllowed column of the JSON condition is not allowed
+	ErrColumnNotAllowed = errors.New("Column is not allowed")
+	// ErrOperatorNotAllowed operator of the JSON condition is not allowed
+	ErrOperatorNotAllowed = errors.New("Operator is not allowed")
+	// ErrTooManyInValues values of IN condition exceed the max number of parameters of the dialect
+	ErrTooManyInValues = errors.New("Too many values of IN condition to be bound as parameters")
 )
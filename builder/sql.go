@@ -13,11 +13,15 @@ import (
 )
 
 func condToSQL(cond Cond) (string, []interface{}, error) {
+	return condToDialectSQL("", cond)
+}
+
+func condToDialectSQL(dialect string, cond Cond) (string, []interface{}, error) {
 	if cond == nil || !cond.IsValid() {
 		return "", nil, nil
 	}
 
-	w := NewWriter()
+	w := NewDialectWriter(dialect)
 	if err := cond.WriteTo(w); err != nil {
 		return "", nil, err
 	}
@@ -47,6 +51,18 @@ func ToSQL(cond interface{}) (string, []interface{}, error) {
 	return "", nil, ErrNotSupportType
 }
 
+// ToDialectSQL convert a builder or conditions to SQL and args, the conditions
+// which depend on the dialect will be written for the dialect
+func ToDialectSQL(dialect string, cond interface{}) (string, []interface{}, error) {
+	switch cond.(type) {
+	case Cond:
+		return condToDialectSQL(dialect, cond.(Cond))
+	case *Builder:
+		return cond.(*Builder).ToSQL()
+	}
+	return "", nil, ErrNotSupportType
+}
+
 // ToBoundSQL convert a builder or conditions to parameters bound SQL
 func ToBoundSQL(cond interface{}) (string, error) {
 	switch cond.(type) {
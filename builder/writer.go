@@ -7,6 +7,8 @@ package builder
 import (
 	"io"
 	"strings"
+
+	"github.com/laixyz/xormplus/internal/json"
 )
 
 // Writer defines the interface
@@ -20,7 +22,10 @@ var _ Writer = NewWriter()
 // BytesWriter implments Writer and save SQL in bytes.Buffer
 type BytesWriter struct {
 	*strings.Builder
-	args []interface{}
+	args    []interface{}
+	dialect string
+	// jsonHandler marshals the values of the JSON conditions, nil means the default one
+	jsonHandler json.JSONInterface
 }
 
 // NewWriter creates a new string writer
@@ -31,6 +36,50 @@ func NewWriter() *BytesWriter {
 	return w
 }
 
+// NewDialectWriter creates a new string writer, the conditions written to it
+// could generate dialect specific SQL
+func NewDialectWriter(dialect string) *BytesWriter {
+	w := NewWriter()
+	w.dialect = dialect
+	return w
+}
+
+// Dialect returns the dialect of the writer
+func (w *BytesWriter) Dialect() string {
+	return w.dialect
+}
+
+// SetJSONHandler sets the handler to marshal the values of the JSON conditions written
+// to the writer
+func (w *BytesWriter) SetJSONHandler(handler json.JSONInterface) *BytesWriter {
+	w.jsonHandler = handler
+	return w
+}
+
+// JSONHandler returns the handler to marshal the values of the JSON conditions
+func (w *BytesWriter) JSONHandler() json.JSONInterface {
+	if w.jsonHandler == nil {
+		return json.DefaultJSONHandler
+	}
+	return w.jsonHandler
+}
+
+// writerJSONHandler returns the JSON handler of the writer if it has
+func writerJSONHandler(w Writer) json.JSONInterface {
+	if jw, ok := w.(interface{ JSONHandler() json.JSONInterface }); ok {
+		return jw.JSONHandler()
+	}
+	return json.DefaultJSONHandler
+}
+
+// writerDialect returns the dialect of the writer if it knows
+func writerDialect(w Writer) string {
+	if dw, ok := w.(interface{ Dialect() string }); ok {
+		return dw.Dialect()
+	}
+	return ""
+}
+
 // Append appends args to Writer
 func (w *BytesWriter) Append(args ...interface{}) {
 	w.args = append(w.args, args...)
@@ -59,6 +59,8 @@ func (h *Hooks) BeforeProcess(c *ContextHook) (context.Context, error) {
 		if err != nil {
 			return nil, err
 		}
+		// the next hooks get the context of the previous ones
+		c.Ctx = ctx
 	}
 	return ctx, nil
 }
@@ -73,3 +75,17 @@ func (h *Hooks) AfterProcess(c *ContextHook) error {
 	}
 	return firstErr
 }
+
+// RowsHook is an optional interface of hooks which need the number of the rows
+// returned by the queries, it's called when the rows are closed
+type RowsHook interface {
+	AfterRows(c *ContextHook, rows int64)
+}
+
+func (h *Hooks) AfterRows(c *ContextHook, rows int64) {
+	for _, h := range h.hooks {
+		if rh, ok := h.(RowsHook); ok {
+			rh.AfterRows(c, rows)
+		}
+	}
+}
@@ -154,7 +154,7 @@ func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{
 		}
 		return nil, err
 	}
-	return &Rows{rows, db}, nil
+	return &Rows{Rows: rows, db: db, hookCtx: hookCtx}, nil
 }
 
 // Query overwrites sql.DB.Query
@@ -9,11 +9,72 @@ import (
 	"errors"
 	"reflect"
 	"sync"
+
+	"github.com/laixyz/xormplus/contexts"
 )
 
 type Rows struct {
 	*sql.Rows
 	db *DB
+
+	// hookCtx is the context of the query, the hooks get the number of the rows by it
+	hookCtx *contexts.ContextHook
+	count   int64
+	// cancel cancels the context of the query when the rows are closed
+	cancel func()
+	// wrapErr wraps the errors of iterating and scanning the rows, i.e. as timeout errors
+	wrapErr func(error) error
+}
+
+// SetCancel sets the function which cancels the context of the query, it's called when the
+// rows are closed
+func (rs *Rows) SetCancel(cancel func()) {
+	rs.cancel = cancel
+}
+
+// SetErrWrapper sets the function which wraps the errors returned by Err and the Scan methods
+func (rs *Rows) SetErrWrapper(wrapErr func(error) error) {
+	rs.wrapErr = wrapErr
+}
+
+func (rs *Rows) wrap(err error) error {
+	if err == nil || rs.wrapErr == nil {
+		return err
+	}
+	return rs.wrapErr(err)
+}
+
+// Err overwrites sql.Rows.Err to wrap the error of the iteration
+func (rs *Rows) Err() error {
+	return rs.wrap(rs.Rows.Err())
+}
+
+// Scan overwrites sql.Rows.Scan to wrap the error of the scan
+func (rs *Rows) Scan(dest ...interface{}) error {
+	return rs.wrap(rs.Rows.Scan(dest...))
+}
+
+// Next overwrites sql.Rows.Next to count the rows
+func (rs *Rows) Next() bool {
+	if rs.Rows.Next() {
+		rs.count++
+		return true
+	}
+	return false
+}
+
+// Close overwrites sql.Rows.Close to pass the number of the rows to the hooks
+func (rs *Rows) Close() error {
+	err := rs.Rows.Close()
+	if rs.hookCtx != nil {
+		rs.db.hooks.AfterRows(rs.hookCtx, rs.count)
+		rs.hookCtx = nil
+	}
+	if rs.cancel != nil {
+		rs.cancel()
+		rs.cancel = nil
+	}
+	return err
 }
 
 func (rs *Rows) ToMapString() ([]map[string]string, error) {
@@ -64,7 +125,7 @@ func (rs *Rows) ScanStructByIndex(dest ...interface{}) error {
 		}
 	}
 
-	return rs.Rows.Scan(newDest...)
+	return rs.Scan(newDest...)
 }
 
 var (
@@ -117,7 +178,7 @@ func (rs *Rows) ScanStructByName(dest interface{}) error {
 		}
 	}
 
-	return rs.Rows.Scan(newDest...)
+	return rs.Scan(newDest...)
 }
 
 // scan data to a slice's pointer, slice's length should equal to columns' number
@@ -143,7 +204,7 @@ func (rs *Rows) ScanSlice(dest interface{}) error {
 		}
 	}
 
-	err = rs.Rows.Scan(newDest...)
+	err = rs.Scan(newDest...)
 	if err != nil {
 		return err
 	}
@@ -174,7 +235,7 @@ func (rs *Rows) ScanMap(dest interface{}) error {
 		newDest[i] = rs.db.reflectNew(vvv.Type().Elem()).Interface()
 	}
 
-	err = rs.Rows.Scan(newDest...)
+	err = rs.Scan(newDest...)
 	if err != nil {
 		return err
 	}
@@ -105,7 +105,7 @@ func (s *Stmt) QueryContext(ctx context.Context, args ...interface{}) (*Rows, er
 	if err := s.db.afterProcess(hookCtx); err != nil {
 		return nil, err
 	}
-	return &Rows{rows, s.db}, nil
+	return &Rows{Rows: rows, db: s.db, hookCtx: hookCtx}, nil
 }
 
 func (s *Stmt) Query(args ...interface{}) (*Rows, error) {
@@ -7,8 +7,11 @@ package core
 import (
 	"context"
 	"database/sql"
+	"strconv"
+	"sync/atomic"
 
 	"github.com/laixyz/xormplus/contexts"
+	"github.com/laixyz/xormplus/log"
 )
 
 var (
@@ -22,7 +25,12 @@ type Tx struct {
 	ctx context.Context
 }
 
+var lastTxID uint64
+
 func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	// the ID identifies the statements of the transaction in the logs
+	txID := strconv.FormatUint(atomic.AddUint64(&lastTxID, 1), 10)
+	ctx = context.WithValue(ctx, log.TxIDKey, txID)
 	hookCtx := contexts.NewContextHook(ctx, "BEGIN TRANSACTION", nil)
 	ctx, err := db.beforeProcess(hookCtx)
 	if err != nil {
@@ -40,6 +48,11 @@ func (db *DB) Begin() (*Tx, error) {
 	return db.BeginTx(context.Background(), nil)
 }
 
+// Context returns the context returned by the hooks when beginning the transaction
+func (tx *Tx) Context() context.Context {
+	return tx.ctx
+}
+
 func (tx *Tx) Commit() error {
 	hookCtx := contexts.NewContextHook(tx.ctx, "COMMIT", nil)
 	ctx, err := tx.db.beforeProcess(hookCtx)
@@ -154,7 +167,7 @@ func (tx *Tx) QueryContext(ctx context.Context, query string, args ...interface{
 		}
 		return nil, err
 	}
-	return &Rows{rows, tx.db}, nil
+	return &Rows{Rows: rows, db: tx.db, hookCtx: hookCtx}, nil
 }
 
 func (tx *Tx) Query(query string, args ...interface{}) (*Rows, error) {
@@ -6,6 +6,7 @@ package dialects
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -14,6 +15,9 @@ import (
 	"github.com/laixyz/xormplus/schemas"
 )
 
+// ErrFulltextNotSupported represents an error that the full-text index cannot be created by the dialect
+var ErrFulltextNotSupported = errors.New("Fulltext index is not supported")
+
 // URI represents an uri to visit database
 type URI struct {
 	DBType  schemas.DBType
@@ -66,7 +70,9 @@ type Dialect interface {
 	AddColumnSQL(tableName string, col *schemas.Column) string
 	ModifyColumnSQL(tableName string, col *schemas.Column) string
 
+	// Deprecated: ForUpdateSQL is not called when generating SELECT, use LockSQL instead
 	ForUpdateSQL(query string) string
+	LockSQL(mode LockMode) (string, string, error)
 
 	Filters() []Filter
 	SetParams(params map[string]string)
@@ -143,6 +149,8 @@ func (db *Base) CreateIndexSQL(tableName string, index *schemas.Index) string {
 	var idxName string
 	if index.Type == schemas.UniqueType {
 		unique = " UNIQUE"
+	} else if index.Type == schemas.FulltextType && db.uri.DBType == schemas.MYSQL {
+		unique = " FULLTEXT"
 	}
 	idxName = index.XName(tableName)
 	return fmt.Sprintf("CREATE%s INDEX %v ON %v (%v)", unique,
@@ -150,6 +158,20 @@ func (db *Base) CreateIndexSQL(tableName string, index *schemas.Index) string {
 		quoter.Join(index.Cols, ","))
 }
 
+// CheckIndex returns ErrFulltextNotSupported if the index is a full-text index which cannot
+// be created by the dialect, the full-text indexes of MSSQL and Oracle need catalogs or
+// Oracle Text which should be set up manually
+func CheckIndex(dialect Dialect, index *schemas.Index) error {
+	if index.Type != schemas.FulltextType {
+		return nil
+	}
+	switch dialect.URI().DBType {
+	case schemas.MYSQL, schemas.POSTGRES, schemas.SQLITE:
+		return nil
+	}
+	return ErrFulltextNotSupported
+}
+
 func (db *Base) DropIndexSQL(tableName string, index *schemas.Index) string {
 	quote := db.dialect.Quoter().Quote
 	var name string
@@ -166,8 +188,15 @@ func (db *Base) ModifyColumnSQL(tableName string, col *schemas.Column) string {
 	return fmt.Sprintf("alter table %s MODIFY COLUMN %s", tableName, s)
 }
 
+// ForUpdateSQL appends the suffix of LockSQL for LockForUpdate to the query
+//
+// Deprecated: use LockSQL instead, the table hint cannot be written by ForUpdateSQL
 func (b *Base) ForUpdateSQL(query string) string {
-	return query + " FOR UPDATE"
+	_, suffix, err := b.dialect.LockSQL(LockForUpdate)
+	if err != nil {
+		return query
+	}
+	return query + suffix
 }
 
 func (b *Base) SetParams(params map[string]string) {
@@ -0,0 +1,66 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dialects
+
+import "errors"
+
+// ErrUnsupportedLockMode represents an error that the lock mode is not supported by the database
+var ErrUnsupportedLockMode = errors.New("Unsupported lock mode")
+
+// LockMode represents how the rows read by SELECT are locked, LockNoWait and
+// LockSkipLocked could be combined with LockForUpdate or LockForShare
+type LockMode int
+
+// enumerates all the lock modes
+const (
+	LockForUpdate LockMode = 1 << iota
+	LockForShare
+	LockNoWait
+	LockSkipLocked
+)
+
+// IsShared returns true if the rows are locked in share mode
+func (mode LockMode) IsShared() bool {
+	return mode&LockForShare == LockForShare
+}
+
+// IsNoWait returns true if the locking fails immediately when the rows are locked by others
+func (mode LockMode) IsNoWait() bool {
+	return mode&LockNoWait == LockNoWait
+}
+
+// IsSkipLocked returns true if the rows locked by others are skipped
+func (mode LockMode) IsSkipLocked() bool {
+	return mode&LockSkipLocked == LockSkipLocked
+}
+
+func (mode LockMode) validate() error {
+	if mode&LockForUpdate == LockForUpdate && mode.IsShared() {
+		return ErrUnsupportedLockMode
+	}
+	if mode.IsNoWait() && mode.IsSkipLocked() {
+		return ErrUnsupportedLockMode
+	}
+	return nil
+}
+
+// LockSQL returns the table hint and the suffix of SELECT for the lock mode,
+// i.e. FOR UPDATE SKIP LOCKED
+func (b *Base) LockSQL(mode LockMode) (string, string, error) {
+	if err := mode.validate(); err != nil {
+		return "", "", err
+	}
+
+	var suffix = " FOR UPDATE"
+	if mode.IsShared() {
+		suffix = " FOR SHARE"
+	}
+	if mode.IsNoWait() {
+		suffix += " NOWAIT"
+	} else if mode.IsSkipLocked() {
+		suffix += " SKIP LOCKED"
+	}
+	return "", suffix, nil
+}
@@ -12,6 +12,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/laixyz/xormplus/builder"
 	"github.com/laixyz/xormplus/core"
 	"github.com/laixyz/xormplus/schemas"
 )
@@ -583,8 +584,12 @@ func (db *mssql) CreateTableSQL(table *schemas.Table, tableName string) ([]strin
 	return []string{sql}, true
 }
 
-func (db *mssql) ForUpdateSQL(query string) string {
-	return query
+func (db *mssql) LockSQL(mode LockMode) (string, string, error) {
+	if err := mode.validate(); err != nil {
+		return "", "", err
+	}
+
+	return builder.LockHint(mode.IsShared(), mode.IsNoWait(), mode.IsSkipLocked()), "", nil
 }
 
 func (db *mssql) Filters() []Filter {
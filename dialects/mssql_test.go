@@ -7,6 +7,9 @@ package dialects
 import (
 	"reflect"
 	"testing"
+
+	"github.com/laixyz/xormplus/schemas"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestParseMSSQL(t *testing.T) {
@@ -31,3 +34,26 @@ func TestParseMSSQL(t *testing.T) {
 		}
 	}
 }
+
+func TestLockSQLMSSQL(t *testing.T) {
+	dialect, err := OpenDialect("mssql", "server=localhost;user id=sa;password=yourStrong(!)Password;database=db")
+	assert.NoError(t, err)
+
+	hint, suffix, err := dialect.LockSQL(LockForShare | LockSkipLocked)
+	assert.NoError(t, err)
+	assert.EqualValues(t, " WITH (HOLDLOCK, ROWLOCK, READPAST)", hint)
+	assert.EqualValues(t, "", suffix)
+
+	// the hint cannot be appended to the query
+	assert.EqualValues(t, "SELECT 1", dialect.ForUpdateSQL("SELECT 1"))
+}
+
+func TestFulltextIndexMSSQL(t *testing.T) {
+	dialect, err := OpenDialect("mssql", "server=localhost;user id=sa;password=yourStrong(!)Password;database=db")
+	assert.NoError(t, err)
+
+	index := schemas.NewIndex("search", schemas.FulltextType)
+	index.AddColumn("title")
+	assert.EqualValues(t, ErrFulltextNotSupported, CheckIndex(dialect, index))
+	assert.NoError(t, CheckIndex(dialect, schemas.NewIndex("title", schemas.IndexType)))
+}
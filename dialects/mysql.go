@@ -486,7 +486,7 @@ func (db *mysql) SetQuotePolicy(quotePolicy QuotePolicy) {
 
 func (db *mysql) GetIndexes(queryer core.Queryer, ctx context.Context, tableName string) (map[string]*schemas.Index, error) {
 	args := []interface{}{db.uri.DBName, tableName}
-	s := "SELECT `INDEX_NAME`, `NON_UNIQUE`, `COLUMN_NAME` FROM `INFORMATION_SCHEMA`.`STATISTICS` WHERE `TABLE_SCHEMA` = ? AND `TABLE_NAME` = ?"
+	s := "SELECT `INDEX_NAME`, `NON_UNIQUE`, `COLUMN_NAME`, `INDEX_TYPE` FROM `INFORMATION_SCHEMA`.`STATISTICS` WHERE `TABLE_SCHEMA` = ? AND `TABLE_NAME` = ?"
 
 	rows, err := queryer.QueryContext(ctx, s, args...)
 	if err != nil {
@@ -497,8 +497,8 @@ func (db *mysql) GetIndexes(queryer core.Queryer, ctx context.Context, tableName
 	indexes := make(map[string]*schemas.Index, 0)
 	for rows.Next() {
 		var indexType int
-		var indexName, colName, nonUnique string
-		err = rows.Scan(&indexName, &nonUnique, &colName)
+		var indexName, colName, nonUnique, idxType string
+		err = rows.Scan(&indexName, &nonUnique, &colName, &idxType)
 		if err != nil {
 			return nil, err
 		}
@@ -507,7 +507,9 @@ func (db *mysql) GetIndexes(queryer core.Queryer, ctx context.Context, tableName
 			continue
 		}
 
-		if "YES" == nonUnique || nonUnique == "1" {
+		if idxType == "FULLTEXT" {
+			indexType = schemas.FulltextType
+		} else if "YES" == nonUnique || nonUnique == "1" {
 			indexType = schemas.IndexType
 		} else {
 			indexType = schemas.UniqueType
@@ -515,7 +517,8 @@ func (db *mysql) GetIndexes(queryer core.Queryer, ctx context.Context, tableName
 
 		colName = strings.Trim(colName, "` ")
 		var isRegular bool
-		if strings.HasPrefix(indexName, "IDX_"+tableName) || strings.HasPrefix(indexName, "UQE_"+tableName) {
+		if strings.HasPrefix(indexName, "IDX_"+tableName) || strings.HasPrefix(indexName, "UQE_"+tableName) ||
+			strings.HasPrefix(indexName, "FTS_"+tableName) {
 			indexName = indexName[5+len(tableName):]
 			isRegular = true
 		}
@@ -591,6 +594,14 @@ func (db *mysql) CreateTableSQL(table *schemas.Table, tableName string) ([]strin
 	return []string{sql}, true
 }
 
+func (db *mysql) LockSQL(mode LockMode) (string, string, error) {
+	// LOCK IN SHARE MODE is supported by all the versions, NOWAIT and SKIP LOCKED need FOR SHARE of MySQL 8.0
+	if mode == LockForShare {
+		return "", " LOCK IN SHARE MODE", nil
+	}
+	return db.Base.LockSQL(mode)
+}
+
 func (db *mysql) Filters() []Filter {
 	return []Filter{}
 }
@@ -796,6 +796,13 @@ func (db *oracle) GetIndexes(queryer core.Queryer, ctx context.Context, tableNam
 	return indexes, nil
 }
 
+func (db *oracle) LockSQL(mode LockMode) (string, string, error) {
+	if mode.IsShared() {
+		return "", "", ErrUnsupportedLockMode
+	}
+	return db.Base.LockSQL(mode)
+}
+
 func (db *oracle) Filters() []Filter {
 	return []Filter{
 		&SeqFilter{Prefix: ":", Start: 1},
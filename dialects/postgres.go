@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -962,6 +963,26 @@ func (db *postgres) ModifyColumnSQL(tableName string, col *schemas.Column) strin
 		db.getSchema(), tableName, col.Name, db.SQLType(col))
 }
 
+// tsvectorExpr returns the text search vector of the columns, builder.Match generates
+// the same expression so that the full-text indexes could be used. The simple configuration
+// is used since MySQL and SQLite don't stem the words by default.
+func tsvectorExpr(quoter schemas.Quoter, cols []string) string {
+	var exprs = make([]string, 0, len(cols))
+	for _, col := range cols {
+		exprs = append(exprs, "COALESCE("+quoter.Quote(col)+",'')")
+	}
+	return "to_tsvector('simple'," + strings.Join(exprs, " || ' ' || ") + ")"
+}
+
+func (db *postgres) CreateIndexSQL(tableName string, index *schemas.Index) string {
+	if index.Type != schemas.FulltextType {
+		return db.Base.CreateIndexSQL(tableName, index)
+	}
+	quoter := db.dialect.Quoter()
+	return fmt.Sprintf("CREATE INDEX %v ON %v USING GIN (%s)", quoter.Quote(index.XName(tableName)),
+		quoter.Quote(tableName), tsvectorExpr(quoter, index.Cols))
+}
+
 func (db *postgres) DropIndexSQL(tableName string, index *schemas.Index) string {
 	idxName := index.Name
 
@@ -969,12 +990,9 @@ func (db *postgres) DropIndexSQL(tableName string, index *schemas.Index) string
 	tableName = tableParts[len(tableParts)-1]
 
 	if !strings.HasPrefix(idxName, "UQE_") &&
-		!strings.HasPrefix(idxName, "IDX_") {
-		if index.Type == schemas.UniqueType {
-			idxName = fmt.Sprintf("UQE_%v_%v", tableName, index.Name)
-		} else {
-			idxName = fmt.Sprintf("IDX_%v_%v", tableName, index.Name)
-		}
+		!strings.HasPrefix(idxName, "IDX_") &&
+		!strings.HasPrefix(idxName, "FTS_") {
+		idxName = index.XName(tableName)
 	}
 	if db.getSchema() != "" {
 		idxName = db.getSchema() + "." + idxName
@@ -1184,6 +1202,17 @@ func getIndexColName(indexdef string) []string {
 	return colNames
 }
 
+var tsVectorColRegexp = regexp.MustCompile(`COALESCE\("?([^",\s()]+)"?,`)
+
+// getTSVectorColNames returns the columns of the full-text index created by tsvectorExpr
+func getTSVectorColNames(indexdef string) []string {
+	var colNames []string
+	for _, match := range tsVectorColRegexp.FindAllStringSubmatch(indexdef, -1) {
+		colNames = append(colNames, match[1])
+	}
+	return colNames
+}
+
 func (db *postgres) GetIndexes(queryer core.Queryer, ctx context.Context, tableName string) (map[string]*schemas.Index, error) {
 	args := []interface{}{tableName}
 	s := fmt.Sprintf("SELECT indexname, indexdef FROM pg_indexes WHERE tablename=$1")
@@ -1217,12 +1246,17 @@ func (db *postgres) GetIndexes(queryer core.Queryer, ctx context.Context, tableN
 		}
 		if strings.HasPrefix(indexdef, "CREATE UNIQUE INDEX") {
 			indexType = schemas.UniqueType
+			colNames = getIndexColName(indexdef)
+		} else if strings.Contains(indexdef, "USING gin") && strings.Contains(indexdef, "to_tsvector(") {
+			indexType = schemas.FulltextType
+			colNames = getTSVectorColNames(indexdef)
 		} else {
 			indexType = schemas.IndexType
+			colNames = getIndexColName(indexdef)
 		}
-		colNames = getIndexColName(indexdef)
 		var isRegular bool
-		if strings.HasPrefix(indexName, "IDX_"+tableName) || strings.HasPrefix(indexName, "UQE_"+tableName) {
+		if strings.HasPrefix(indexName, "IDX_"+tableName) || strings.HasPrefix(indexName, "UQE_"+tableName) ||
+			strings.HasPrefix(indexName, "FTS_"+tableName) {
 			newIdxName := indexName[5+len(tableName):]
 			isRegular = true
 			if newIdxName != "" {
@@ -4,6 +4,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/laixyz/xormplus/schemas"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -114,3 +115,19 @@ func TestGetIndexColName(t *testing.T) {
 
 	t.Run("Indexes on Expressions", func(t *testing.T) {})
 }
+
+func TestFulltextIndexPostgres(t *testing.T) {
+	dialect, err := OpenDialect("postgres", "postgres://postgres@localhost/test?sslmode=disable")
+	assert.NoError(t, err)
+
+	index := schemas.NewIndex("search", schemas.FulltextType)
+	index.AddColumn("title", "description")
+	assert.EqualValues(t, `CREATE INDEX "FTS_product_search" ON "product" USING GIN `+
+		`(to_tsvector('simple',COALESCE("title",'') || ' ' || COALESCE("description",'')))`,
+		dialect.CreateIndexSQL("product", index))
+	assert.EqualValues(t, `DROP INDEX "public"."FTS_product_search"`, dialect.DropIndexSQL("product", index))
+
+	s := `CREATE INDEX "FTS_product_search" ON public.product USING gin (to_tsvector('simple'::regconfig, ` +
+		`(((COALESCE(title, ''::character varying))::text || ' '::text) || COALESCE(description, ''::text))))`
+	assert.EqualValues(t, []string{"title", "description"}, getTSVectorColNames(s))
+}
@@ -233,7 +233,42 @@ func (db *sqlite3) IsTableExist(queryer core.Queryer, ctx context.Context, table
 	return db.HasRecords(queryer, ctx, "SELECT name FROM sqlite_master WHERE type='table' and name = ?", tableName)
 }
 
+// CreateIndexSQL creates a FTS5 virtual table for the full-text index, the table is
+// kept in sync with the indexed table by triggers
+func (db *sqlite3) CreateIndexSQL(tableName string, index *schemas.Index) string {
+	if index.Type != schemas.FulltextType {
+		return db.Base.CreateIndexSQL(tableName, index)
+	}
+
+	quoter := db.Quoter()
+	ftsName := index.XName(tableName)
+	fts := quoter.Quote(ftsName)
+	cols := quoter.Join(index.Cols, ",")
+	var newCols, oldCols []string
+	for _, col := range index.Cols {
+		newCols = append(newCols, "new."+quoter.Quote(col))
+		oldCols = append(oldCols, "old."+quoter.Quote(col))
+	}
+	insertNew := fmt.Sprintf("INSERT INTO %s(rowid,%s) VALUES (new.rowid,%s);", fts, cols, strings.Join(newCols, ","))
+	deleteOld := fmt.Sprintf("INSERT INTO %s(%s,rowid,%s) VALUES ('delete',old.rowid,%s);", fts, fts, cols, strings.Join(oldCols, ","))
+
+	return strings.Join([]string{
+		fmt.Sprintf("CREATE VIRTUAL TABLE %s USING fts5(%s,content='%s')", fts, cols, strings.Replace(tableName, "'", "''", -1)),
+		fmt.Sprintf("CREATE TRIGGER %s AFTER INSERT ON %s BEGIN %s END", quoter.Quote(ftsName+"_ai"), quoter.Quote(tableName), insertNew),
+		fmt.Sprintf("CREATE TRIGGER %s AFTER DELETE ON %s BEGIN %s END", quoter.Quote(ftsName+"_ad"), quoter.Quote(tableName), deleteOld),
+		fmt.Sprintf("CREATE TRIGGER %s AFTER UPDATE ON %s BEGIN %s %s END", quoter.Quote(ftsName+"_au"), quoter.Quote(tableName), deleteOld, insertNew),
+		fmt.Sprintf("INSERT INTO %s(%s) VALUES ('rebuild')", fts, fts),
+	}, ";")
+}
+
 func (db *sqlite3) DropIndexSQL(tableName string, index *schemas.Index) string {
+	if index.Type == schemas.FulltextType {
+		quoter := db.Quoter()
+		ftsName := index.XName(tableName)
+		return fmt.Sprintf("DROP TRIGGER IF EXISTS %s;DROP TRIGGER IF EXISTS %s;DROP TRIGGER IF EXISTS %s;DROP TABLE IF EXISTS %s",
+			quoter.Quote(ftsName+"_ai"), quoter.Quote(ftsName+"_ad"), quoter.Quote(ftsName+"_au"), quoter.Quote(ftsName))
+	}
+
 	// var unique string
 	idxName := index.Name
 
@@ -283,8 +318,13 @@ func (db *sqlite3) CreateTableSQL(table *schemas.Table, tableName string) ([]str
 	return []string{sql}, true
 }
 
-func (db *sqlite3) ForUpdateSQL(query string) string {
-	return query
+// LockSQL returns nothing for FOR UPDATE since SQLite locks the whole database
+// in a write transaction, the other lock modes cannot be honored
+func (db *sqlite3) LockSQL(mode LockMode) (string, string, error) {
+	if mode != LockForUpdate {
+		return "", "", ErrUnsupportedLockMode
+	}
+	return "", "", nil
 }
 
 func (db *sqlite3) IsColumnExist(queryer core.Queryer, ctx context.Context, tableName, colName string) (bool, error) {
@@ -430,7 +470,7 @@ func (db *sqlite3) GetColumns(queryer core.Queryer, ctx context.Context, tableNa
 
 func (db *sqlite3) GetTables(queryer core.Queryer, ctx context.Context) ([]*schemas.Table, error) {
 	args := []interface{}{}
-	s := "SELECT name FROM sqlite_master WHERE type='table'"
+	s := "SELECT name, sql FROM sqlite_master WHERE type='table'"
 
 	rows, err := queryer.QueryContext(ctx, s, args...)
 	if err != nil {
@@ -439,18 +479,39 @@ func (db *sqlite3) GetTables(queryer core.Queryer, ctx context.Context) ([]*sche
 	defer rows.Close()
 
 	tables := make([]*schemas.Table, 0)
+	var virtualNames []string
 	for rows.Next() {
 		table := schemas.NewEmptyTable()
-		err = rows.Scan(&table.Name)
+		var tableSQL sql.NullString
+		err = rows.Scan(&table.Name, &tableSQL)
 		if err != nil {
 			return nil, err
 		}
 		if table.Name == "sqlite_sequence" {
 			continue
 		}
+		if strings.HasPrefix(tableSQL.String, "CREATE VIRTUAL TABLE") {
+			virtualNames = append(virtualNames, table.Name)
+			continue
+		}
 		tables = append(tables, table)
 	}
-	return tables, nil
+
+	// the shadow tables of the full-text indexes are not the tables of users
+	var userTables = make([]*schemas.Table, 0, len(tables))
+	for _, table := range tables {
+		var isShadow bool
+		for _, name := range virtualNames {
+			if strings.HasPrefix(table.Name, name+"_") {
+				isShadow = true
+				break
+			}
+		}
+		if !isShadow {
+			userTables = append(userTables, table)
+		}
+	}
+	return userTables, nil
 }
 
 func (db *sqlite3) GetIndexes(queryer core.Queryer, ctx context.Context, tableName string) (map[string]*schemas.Index, error) {
@@ -509,10 +570,66 @@ func (db *sqlite3) GetIndexes(queryer core.Queryer, ctx context.Context, tableNa
 		index.IsRegular = isRegular
 		indexes[index.Name] = index
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
+	ftsIndexes, err := db.getFulltextIndexes(queryer, ctx, tableName)
+	if err != nil {
+		return nil, err
+	}
+	for _, index := range ftsIndexes {
+		indexes[index.Name] = index
+	}
 	return indexes, nil
 }
 
+var fts5Regexp = regexp.MustCompile(`(?i)USING\s+fts5\s*\((.*)\)\s*$`)
+
+// getFulltextIndexes returns the full-text indexes which are FTS5 virtual tables
+func (db *sqlite3) getFulltextIndexes(queryer core.Queryer, ctx context.Context, tableName string) ([]*schemas.Index, error) {
+	s := "SELECT name, sql FROM sqlite_master WHERE type='table' AND sql LIKE 'CREATE VIRTUAL TABLE%'"
+	rows, err := queryer.QueryContext(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var indexes []*schemas.Index
+	for rows.Next() {
+		var name, tableSQL string
+		if err := rows.Scan(&name, &tableSQL); err != nil {
+			return nil, err
+		}
+		matches := fts5Regexp.FindStringSubmatch(tableSQL)
+		if matches == nil {
+			continue
+		}
+
+		index := &schemas.Index{Name: name, Type: schemas.FulltextType}
+		var content string
+		for _, arg := range strings.Split(matches[1], ",") {
+			arg = strings.TrimSpace(arg)
+			if kv := strings.SplitN(arg, "=", 2); len(kv) == 2 {
+				if strings.TrimSpace(kv[0]) == "content" {
+					content = strings.Trim(strings.TrimSpace(kv[1]), "'\"`")
+				}
+				continue
+			}
+			index.Cols = append(index.Cols, strings.Trim(arg, "` []'\""))
+		}
+		if content != tableName {
+			continue
+		}
+		if strings.HasPrefix(name, "FTS_"+tableName) {
+			index.Name = name[5+len(tableName):]
+			index.IsRegular = true
+		}
+		indexes = append(indexes, index)
+	}
+	return indexes, rows.Err()
+}
+
 func (db *sqlite3) Filters() []Filter {
 	return []Filter{}
 }
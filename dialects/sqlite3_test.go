@@ -7,6 +7,7 @@ package dialects
 import (
 	"testing"
 
+	"github.com/laixyz/xormplus/schemas"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -33,3 +34,32 @@ func TestSplitColStr(t *testing.T) {
 		assert.EqualValues(t, kase.fields, splitColStr(kase.colStr))
 	}
 }
+
+func TestFulltextIndexSQLite(t *testing.T) {
+	dialect, err := OpenDialect("sqlite3", "./test.db")
+	assert.NoError(t, err)
+
+	index := schemas.NewIndex("search", schemas.FulltextType)
+	index.AddColumn("title", "body")
+	assert.EqualValues(t, "CREATE VIRTUAL TABLE `FTS_doc_search` USING fts5(`title`,`body`,content='doc');"+
+		"CREATE TRIGGER `FTS_doc_search_ai` AFTER INSERT ON `doc` BEGIN "+
+		"INSERT INTO `FTS_doc_search`(rowid,`title`,`body`) VALUES (new.rowid,new.`title`,new.`body`); END;"+
+		"CREATE TRIGGER `FTS_doc_search_ad` AFTER DELETE ON `doc` BEGIN "+
+		"INSERT INTO `FTS_doc_search`(`FTS_doc_search`,rowid,`title`,`body`) VALUES ('delete',old.rowid,old.`title`,old.`body`); END;"+
+		"CREATE TRIGGER `FTS_doc_search_au` AFTER UPDATE ON `doc` BEGIN "+
+		"INSERT INTO `FTS_doc_search`(`FTS_doc_search`,rowid,`title`,`body`) VALUES ('delete',old.rowid,old.`title`,old.`body`); "+
+		"INSERT INTO `FTS_doc_search`(rowid,`title`,`body`) VALUES (new.rowid,new.`title`,new.`body`); END;"+
+		"INSERT INTO `FTS_doc_search`(`FTS_doc_search`) VALUES ('rebuild')", dialect.CreateIndexSQL("doc", index))
+
+	assert.EqualValues(t, "DROP TRIGGER IF EXISTS `FTS_doc_search_ai`;DROP TRIGGER IF EXISTS `FTS_doc_search_ad`;"+
+		"DROP TRIGGER IF EXISTS `FTS_doc_search_au`;DROP TABLE IF EXISTS `FTS_doc_search`", dialect.DropIndexSQL("doc", index))
+}
+
+func TestLockSQLSQLite(t *testing.T) {
+	dialect, err := OpenDialect("sqlite3", "./test.db")
+	assert.NoError(t, err)
+
+	_, _, err = dialect.LockSQL(LockForShare)
+	assert.EqualValues(t, ErrUnsupportedLockMode, err)
+	assert.EqualValues(t, "SELECT 1", dialect.ForUpdateSQL("SELECT 1"))
+}
@@ -15,23 +15,34 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/laixyz/xormplus/admission"
+	"github.com/laixyz/xormplus/builder"
 	"github.com/laixyz/xormplus/caches"
 	"github.com/laixyz/xormplus/contexts"
 	"github.com/laixyz/xormplus/core"
 	"github.com/laixyz/xormplus/dialects"
+	"github.com/laixyz/xormplus/internal/json"
 	"github.com/laixyz/xormplus/internal/utils"
 	"github.com/laixyz/xormplus/log"
+	"github.com/laixyz/xormplus/metrics"
 	"github.com/laixyz/xormplus/names"
+	"github.com/laixyz/xormplus/nplusone"
+	"github.com/laixyz/xormplus/querystats"
 	"github.com/laixyz/xormplus/schemas"
+	"github.com/laixyz/xormplus/sqlcomment"
 	"github.com/laixyz/xormplus/tags"
+	"github.com/laixyz/xormplus/tracing"
 )
 
 // Engine is the major struct of xorm, it means a database manager.
 // Commonly, an application only need one engine
 type Engine struct {
 	cacherMgr      *caches.Manager
+	jsonHandler    JSONHandler
 	defaultContext context.Context
 	dialect        dialects.Dialect
 	engineGroup    *EngineGroup
@@ -46,6 +57,20 @@ type Engine struct {
 	DatabaseTZ *time.Location // The timezone of the database
 
 	logSessionID bool // create session id
+
+	queryStats   *querystats.Registry
+	sqlCommenter *sqlcomment.Commenter
+	nPlusOne     *nplusone.Detector
+
+	safeMode    bool
+	largeTables map[string]bool
+
+	queryTimeout time.Duration
+	admission    *admission.Controller
+
+	// leakTracker holds the *LeakTracker, it's read by the sessions created concurrently
+	leakTracker     atomic.Value
+	leakTrackerLock sync.Mutex
 }
 
 // NewEngine new a db manager according to the parameter. Currently support four
@@ -256,6 +281,23 @@ func (engine *Engine) SetDefaultCacher(cacher caches.Cacher) {
 	engine.cacherMgr.SetDefaultCacher(cacher)
 }
 
+// JSONHandler represents an interface to marshal and unmarshal the fields tagged json
+type JSONHandler = json.JSONInterface
+
+// SetDefaultJSONHandler sets the handler to marshal and unmarshal the fields tagged json
+// and the values of JSON conditions for the engines without their own handlers, nil
+// restores encoding/json
+func SetDefaultJSONHandler(handler JSONHandler) {
+	json.SetDefaultJSONHandler(handler)
+}
+
+// SetJSONHandler sets the handler of the engine to marshal and unmarshal the fields tagged
+// json and the values of JSON conditions, nil means the default one. It should be called
+// before the engine is used.
+func (engine *Engine) SetJSONHandler(handler JSONHandler) {
+	engine.jsonHandler = handler
+}
+
 // GetDefaultCacher returns the default cacher
 func (engine *Engine) GetDefaultCacher() caches.Cacher {
 	return engine.cacherMgr.GetDefaultCacher()
@@ -304,6 +346,9 @@ func (engine *Engine) NewSession() *Session {
 
 // Close the engine
 func (engine *Engine) Close() error {
+	if tracker := engine.getLeakTracker(); tracker != nil {
+		tracker.close()
+	}
 	return engine.DB().Close()
 }
 
@@ -552,6 +597,9 @@ func (engine *Engine) dumpTables(tables []*schemas.Table, w io.Writer, tp ...sch
 		}
 
 		for _, index := range table.Indexes {
+			if err := dialects.CheckIndex(dstDialect, index); err != nil {
+				return err
+			}
 			_, err = io.WriteString(w, dstDialect.CreateIndexSQL(table.Name, index)+";\n")
 			if err != nil {
 				return err
@@ -812,6 +860,20 @@ func (engine *Engine) Join(joinOperator string, tablename interface{}, condition
 	return session.Join(joinOperator, tablename, condition, args...)
 }
 
+// With adds a common table expression
+func (engine *Engine) With(name string, cte *builder.Builder) *Session {
+	session := engine.NewSession()
+	session.isAutoClose = true
+	return session.With(name, cte)
+}
+
+// WithRecursive adds a recursive common table expression
+func (engine *Engine) WithRecursive(name string, cte *builder.Builder) *Session {
+	session := engine.NewSession()
+	session.isAutoClose = true
+	return session.WithRecursive(name, cte)
+}
+
 // GroupBy generate group by statement
 func (engine *Engine) GroupBy(keys string) *Session {
 	session := engine.NewSession()
@@ -946,7 +1008,11 @@ func (engine *Engine) Sync(beans ...interface{}) error {
 			}
 		} else {
 			for _, col := range table.Columns() {
-				isExist, err := engine.dialect.IsColumnExist(engine.db, session.ctx, tableNameNoSchema, col.Name)
+				var isExist bool
+				err := session.withStatementContext("IsColumnExist "+tableNameNoSchema+"."+col.Name, func(ctx context.Context) (err error) {
+					isExist, err = engine.dialect.IsColumnExist(engine.db, ctx, tableNameNoSchema, col.Name)
+					return
+				})
 				if err != nil {
 					return err
 				}
@@ -966,7 +1032,7 @@ func (engine *Engine) Sync(beans ...interface{}) error {
 					return err
 				}
 				if index.Type == schemas.UniqueType {
-					isExist, err := session.isIndexExist2(tableNameNoSchema, index.Cols, true)
+					isExist, err := session.isIndexExist2(tableNameNoSchema, index.Cols, schemas.UniqueType)
 					if err != nil {
 						return err
 					}
@@ -980,8 +1046,8 @@ func (engine *Engine) Sync(beans ...interface{}) error {
 							return err
 						}
 					}
-				} else if index.Type == schemas.IndexType {
-					isExist, err := session.isIndexExist2(tableNameNoSchema, index.Cols, false)
+				} else if index.Type == schemas.IndexType || index.Type == schemas.FulltextType {
+					isExist, err := session.isIndexExist2(tableNameNoSchema, index.Cols, index.Type)
 					if err != nil {
 						return err
 					}
@@ -1100,6 +1166,13 @@ func (engine *Engine) InsertOne(bean interface{}) (int64, error) {
 	return session.InsertOne(bean)
 }
 
+// InsertSelect inserts the records returned by query into target
+func (engine *Engine) InsertSelect(target interface{}, query *builder.Builder) (int64, error) {
+	session := engine.NewSession()
+	defer session.Close()
+	return session.InsertSelect(target, query)
+}
+
 // Update records, bean's non-empty fields are updated contents,
 // condiBean' non-empty filds are conditions
 // CAUTION:
@@ -1134,6 +1207,20 @@ func (engine *Engine) Exist(bean ...interface{}) (bool, error) {
 	return session.Exist(bean...)
 }
 
+// DryRun generates the SQL of the operation without executing it
+func (engine *Engine) DryRun(op func(*Session) error) (*DryRunSQL, error) {
+	session := engine.NewSession()
+	defer session.Close()
+	return session.DryRun(op)
+}
+
+// Explain returns the plan of the query which would be executed by the operation
+func (engine *Engine) Explain(op func(*Session) error) (*QueryPlan, error) {
+	session := engine.NewSession()
+	defer session.Close()
+	return session.Explain(op)
+}
+
 // Find retrieve records from table, condiBeans's non-empty fields
 // are conditions. beans could be []Struct, []*Struct, map[int64]Struct
 // map[int64]*Struct
@@ -1263,6 +1350,176 @@ func (engine *Engine) AddHook(hook contexts.Hook) {
 	engine.db.AddHook(hook)
 }
 
+// ExplainSQL returns the plan of the SQL, the SQL is explained by a connection of the pool
+// directly, so the hooks are not called
+func (engine *Engine) ExplainSQL(ctx context.Context, sqlStr string, args ...interface{}) (*QueryPlan, error) {
+	return explainQuery(ctx, engine.dialect.URI().DBType, engine.DB().DB, sqlStr, args, false)
+}
+
+// AddSlowQueryHook adds a hook which logs the statements slower than the threshold by the
+// logger of the engine, the slow SELECT statements are also explained if explain is true.
+// The explaining is skipped if no connection could be got from the pool immediately.
+func (engine *Engine) AddSlowQueryHook(threshold time.Duration, explain bool) *log.SlowQueryHook {
+	hook := log.NewSlowQueryHook(threshold, engine.logger)
+	if explain {
+		hook.SetExplainer(func(ctx context.Context, sqlStr string, args []interface{}) (string, error) {
+			stats := engine.DB().Stats()
+			if stats.Idle == 0 && stats.MaxOpenConnections > 0 && stats.InUse >= stats.MaxOpenConnections {
+				return "", ErrNoIdleConnection
+			}
+			plan, err := engine.ExplainSQL(ctx, sqlStr, args...)
+			if err != nil {
+				return "", err
+			}
+			return plan.String(), nil
+		})
+	}
+	engine.AddHook(hook)
+	return hook
+}
+
+// AddMetrics adds a hook which feeds the metrics of the statements, the transactions and
+// the connection pool to the collector, the default cacher is also observed if it's set
+func (engine *Engine) AddMetrics(collector metrics.Collector) {
+	engine.AddHook(metrics.NewHook(collector, engine.DB().Stats))
+	engine.observeCacher(collector)
+}
+
+// observeCacher wraps the default cacher to feed the cache lookups to the collector
+func (engine *Engine) observeCacher(collector metrics.Collector) {
+	if cacher := engine.GetDefaultCacher(); cacher != nil {
+		if _, ok := cacher.(*metrics.Cacher); !ok {
+			engine.SetDefaultCacher(metrics.NewCacher(cacher, collector))
+		}
+	}
+}
+
+// AddTracer adds a hook which starts a span by the tracer for every statement and every
+// transaction, the spans are parented from the context of the session
+func (engine *Engine) AddTracer(tracer tracing.Tracer) {
+	uri := engine.dialect.URI()
+	engine.AddHook(tracing.NewHook(tracer, string(uri.DBType), uri.DBName))
+}
+
+// EnableQueryStats adds a hook which keeps the statistics of the statements grouped by
+// their fingerprints, it returns the registry of the statistics
+func (engine *Engine) EnableQueryStats() *querystats.Registry {
+	if engine.queryStats == nil {
+		engine.queryStats = querystats.NewRegistry()
+		engine.AddHook(querystats.NewHook(engine.queryStats))
+	}
+	return engine.queryStats
+}
+
+// QueryStats returns a snapshot of the statistics of the statements ordered by the total
+// time descending, it returns nil if EnableQueryStats is not called
+func (engine *Engine) QueryStats() []querystats.QueryStat {
+	if engine.queryStats == nil {
+		return nil
+	}
+	return engine.queryStats.Snapshot()
+}
+
+// SetSQLCommenter sets the commenter which appends a comment with the metadata of the
+// request and the caller to every statement, nil disables it
+func (engine *Engine) SetSQLCommenter(commenter *sqlcomment.Commenter) {
+	engine.sqlCommenter = commenter
+}
+
+// EnableNPlusOneDetector adds a hook which detects the query shapes executed more than
+// threshold times in a scope, it's for development. A scope is created for every session
+// unless the context already carries one, so use nplusone.WithScope to detect the N+1
+// queries of a request. The detections are logged as warnings by default.
+func (engine *Engine) EnableNPlusOneDetector(threshold int) *nplusone.Detector {
+	if engine.nPlusOne == nil {
+		engine.nPlusOne = nplusone.NewDetector(threshold).OnDetect(func(report *nplusone.Report) {
+			engine.logger.Warnf("%v", report)
+		})
+		engine.AddHook(engine.nPlusOne)
+	}
+	return engine.nPlusOne
+}
+
+// SetSafeMode enables or disables the safe mode, the updates and the deletes without
+// condition and the selects without limit on the large tables are rejected in safe mode
+// unless Session.AllowFullTable is called
+func (engine *Engine) SetSafeMode(enable bool) {
+	engine.safeMode = enable
+}
+
+// SetLargeTables sets the tables which couldn't be selected without limit in safe mode
+func (engine *Engine) SetLargeTables(tableNames ...string) {
+	engine.largeTables = make(map[string]bool, len(tableNames))
+	for _, tableName := range tableNames {
+		engine.largeTables[strings.ToLower(tableName)] = true
+	}
+}
+
+// SetQueryTimeout sets the default timeout of every statement and every transaction of the
+// sessions, 0 means no timeout. The statements exceeding it fail with a *TimeoutError.
+func (engine *Engine) SetQueryTimeout(timeout time.Duration) {
+	engine.queryTimeout = timeout
+}
+
+// Timeout sets the timeout of the statement
+func (engine *Engine) Timeout(timeout time.Duration) *Session {
+	session := engine.NewSession()
+	session.isAutoClose = true
+	return session.Timeout(timeout)
+}
+
+// SetAdmissionController sets the controller which limits the concurrent statements, a
+// transaction is admitted as a statement from Begin to Commit, nil means no limitation.
+// A session shouldn't execute statements while iterating the rows of another session if
+// the limit is small, the rows hold their admission until they are closed. The statements
+// of the session iterating the rows share their admission.
+func (engine *Engine) SetAdmissionController(controller *admission.Controller) {
+	engine.admission = controller
+}
+
+// Priority sets the priority of the statement in the admission controller
+func (engine *Engine) Priority(p admission.Priority) *Session {
+	session := engine.NewSession()
+	session.isAutoClose = true
+	return session.Priority(p)
+}
+
+// EnableLeakTracker tracks the sessions and the rows created from now on, the sessions
+// open longer than the threshold, the ones holding a transaction and the ones garbage
+// collected without being closed are logged as warnings by default. The stacks of the
+// sessions are recorded, so it's expensive and should be enabled when debugging. It's safe
+// to be called while the engine is used, the sessions created before are not tracked.
+func (engine *Engine) EnableLeakTracker(threshold time.Duration) *LeakTracker {
+	engine.leakTrackerLock.Lock()
+	defer engine.leakTrackerLock.Unlock()
+	tracker := engine.getLeakTracker()
+	if tracker == nil {
+		tracker = newLeakTracker(threshold).OnLeak(func(leak *Leak) {
+			engine.logger.Warnf("%v", leak)
+		})
+		engine.leakTracker.Store(tracker)
+	} else {
+		tracker.SetThreshold(threshold)
+	}
+	return tracker
+}
+
+// getLeakTracker returns the leak tracker, nil if EnableLeakTracker is not called
+func (engine *Engine) getLeakTracker() *LeakTracker {
+	tracker, _ := engine.leakTracker.Load().(*LeakTracker)
+	return tracker
+}
+
+// OpenSessions returns the sessions not closed, it returns nil if EnableLeakTracker is not
+// called
+func (engine *Engine) OpenSessions() []OpenSession {
+	tracker := engine.getLeakTracker()
+	if tracker == nil {
+		return nil
+	}
+	return tracker.OpenSessions()
+}
+
 // Unscoped always disable struct tag "deleted"
 func (engine *Engine) Unscoped() *Session {
 	session := engine.NewSession()
@@ -6,13 +6,18 @@ package xormplus
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/laixyz/xormplus/caches"
 	"github.com/laixyz/xormplus/contexts"
 	"github.com/laixyz/xormplus/dialects"
 	"github.com/laixyz/xormplus/log"
+	"github.com/laixyz/xormplus/metrics"
 	"github.com/laixyz/xormplus/names"
+	"github.com/laixyz/xormplus/nplusone"
+	"github.com/laixyz/xormplus/querystats"
+	"github.com/laixyz/xormplus/tracing"
 )
 
 // EngineGroup defines an engine group
@@ -136,6 +141,76 @@ func (eg *EngineGroup) SetDefaultCacher(cacher caches.Cacher) {
 	}
 }
 
+// SetJSONHandler sets the JSON handler of the master and the slaves
+func (eg *EngineGroup) SetJSONHandler(handler JSONHandler) {
+	eg.Engine.SetJSONHandler(handler)
+	for i := 0; i < len(eg.slaves); i++ {
+		eg.slaves[i].SetJSONHandler(handler)
+	}
+}
+
+// AddMetrics adds the metrics hook to the master and the slaves, the stats of their
+// connection pools are summed
+func (eg *EngineGroup) AddMetrics(collector metrics.Collector) {
+	eg.AddHook(metrics.NewHook(collector, eg.poolStats))
+	eg.Engine.observeCacher(collector)
+	for i := 0; i < len(eg.slaves); i++ {
+		eg.slaves[i].observeCacher(collector)
+	}
+}
+
+// AddTracer adds the tracing hook to the master and the slaves
+func (eg *EngineGroup) AddTracer(tracer tracing.Tracer) {
+	eg.Engine.AddTracer(tracer)
+	for i := 0; i < len(eg.slaves); i++ {
+		eg.slaves[i].AddTracer(tracer)
+	}
+}
+
+// EnableQueryStats adds the hook of the statistics to the master and the slaves, they
+// share the registry
+func (eg *EngineGroup) EnableQueryStats() *querystats.Registry {
+	registry := eg.Engine.EnableQueryStats()
+	for i := 0; i < len(eg.slaves); i++ {
+		if eg.slaves[i].queryStats == nil {
+			eg.slaves[i].queryStats = registry
+			eg.slaves[i].AddHook(querystats.NewHook(registry))
+		}
+	}
+	return registry
+}
+
+// EnableNPlusOneDetector adds the detector to the master and the slaves, they share the
+// detector
+func (eg *EngineGroup) EnableNPlusOneDetector(threshold int) *nplusone.Detector {
+	detector := eg.Engine.EnableNPlusOneDetector(threshold)
+	for i := 0; i < len(eg.slaves); i++ {
+		if eg.slaves[i].nPlusOne == nil {
+			eg.slaves[i].nPlusOne = detector
+			eg.slaves[i].AddHook(detector)
+		}
+	}
+	return detector
+}
+
+// poolStats returns the sum of the stats of the connection pools of the master and the slaves
+func (eg *EngineGroup) poolStats() sql.DBStats {
+	stats := eg.Engine.DB().Stats()
+	for i := 0; i < len(eg.slaves); i++ {
+		s := eg.slaves[i].DB().Stats()
+		stats.MaxOpenConnections += s.MaxOpenConnections
+		stats.OpenConnections += s.OpenConnections
+		stats.InUse += s.InUse
+		stats.Idle += s.Idle
+		stats.WaitCount += s.WaitCount
+		stats.WaitDuration += s.WaitDuration
+		stats.MaxIdleClosed += s.MaxIdleClosed
+		stats.MaxIdleTimeClosed += s.MaxIdleTimeClosed
+		stats.MaxLifetimeClosed += s.MaxLifetimeClosed
+	}
+	return stats
+}
+
 // SetLogger set the new logger
 func (eg *EngineGroup) SetLogger(logger interface{}) {
 	eg.Engine.SetLogger(logger)
@@ -151,6 +226,16 @@ func (eg *EngineGroup) AddHook(hook contexts.Hook) {
 	}
 }
 
+// AddSlowQueryHook adds the slow query hook to the master and the slaves, the plans are
+// explained by the master
+func (eg *EngineGroup) AddSlowQueryHook(threshold time.Duration, explain bool) *log.SlowQueryHook {
+	hook := eg.Engine.AddSlowQueryHook(threshold, explain)
+	for i := 0; i < len(eg.slaves); i++ {
+		eg.slaves[i].AddHook(hook)
+	}
+	return hook
+}
+
 // SetLogLevel sets the logger level
 func (eg *EngineGroup) SetLogLevel(level log.LogLevel) {
 	eg.Engine.SetLogLevel(level)
@@ -0,0 +1,607 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package filter parses the filter and sort expressions from users, i.e. the query
+parameters of list APIs, into builder.Cond and ORDER BY. The columns, the operators
+and the values are validated against a schemas.Table, so the expressions are safe to
+be used in SQL.
+
+A filter expression is made of comparisons which are combined by logical operators:
+
+	status in (active,pending) and created_at >= 2024-01-01 and name ~ "foo"
+	status=in=(active,pending);created_at=ge=2024-01-01;name=like=foo
+
+The comparisons are "column operator value", the operators are
+
+	==, =            equal, "== null" means IS NULL
+	!=, <>           not equal, "!= null" means IS NOT NULL
+	>, =gt=          greater than
+	>=, =ge=         greater than or equal
+	<, =lt=          less than
+	<=, =le=         less than or equal
+	~, like, =like=  contains the text, * matches any characters
+	!~               doesn't contain the text
+	in, =in=         in the list, i.e. in (a,b)
+	not in, =out=    not in the list
+
+The logical operators are "and" or ";", "or" or "," and "not", the "and" binds tighter
+than "or" and parentheses could be used to group the comparisons. The values are bare
+words or strings quoted by " or ', they must be able to be converted to the type of
+the column, i.e. numbers, true/false or times like 2024-01-01 and RFC3339.
+
+A sort expression is the columns separated by ",", a column could be prefixed by - or
+followed by desc to be sorted descending, i.e. "-created_at,name" or "created_at desc, name".
+*/
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/laixyz/xormplus/builder"
+	"github.com/laixyz/xormplus/schemas"
+)
+
+// Operator represents the comparison operator of the filter expression
+type Operator string
+
+// all the operators
+const (
+	Eq      Operator = "=="
+	Ne      Operator = "!="
+	Gt      Operator = ">"
+	Ge      Operator = ">="
+	Lt      Operator = "<"
+	Le      Operator = "<="
+	Like    Operator = "~"
+	NotLike Operator = "!~"
+	In      Operator = "in"
+	NotIn   Operator = "out"
+)
+
+// operatorAliases maps the operators in the expression to Operator
+var operatorAliases = map[string]Operator{
+	"==":     Eq,
+	"=":      Eq,
+	"!=":     Ne,
+	"<>":     Ne,
+	">":      Gt,
+	"=gt=":   Gt,
+	">=":     Ge,
+	"=ge=":   Ge,
+	"<":      Lt,
+	"=lt=":   Lt,
+	"<=":     Le,
+	"=le=":   Le,
+	"~":      Like,
+	"=like=": Like,
+	"!~":     NotLike,
+	"=in=":   In,
+	"=out=":  NotIn,
+}
+
+// Error describes the position and the reason why the expression is invalid
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (err *Error) Error() string {
+	return fmt.Sprintf("filter: %s at position %d", err.Msg, err.Pos)
+}
+
+func newError(pos int, format string, args ...interface{}) *Error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// default limitations of the expressions
+const (
+	DefaultMaxLength     = 4096
+	DefaultMaxConditions = 32
+	maxDepth             = 16
+)
+
+// Parser parses the filter and sort expressions of a table
+type Parser struct {
+	table         *schemas.Table
+	columns       map[string]bool
+	operators     map[string][]Operator
+	maxConditions int
+	location      *time.Location
+	quoter        schemas.Quoter
+}
+
+// NewParser creates a parser of the table, all the columns and the operators
+// are allowed by default
+func NewParser(table *schemas.Table) *Parser {
+	return &Parser{
+		table:         table,
+		operators:     make(map[string][]Operator),
+		maxConditions: DefaultMaxConditions,
+		location:      time.Local,
+		quoter:        schemas.CommonQuoter,
+	}
+}
+
+// AllowColumns restricts the columns which could be filtered and sorted by
+func (p *Parser) AllowColumns(cols ...string) *Parser {
+	if p.columns == nil {
+		p.columns = make(map[string]bool, len(cols))
+	}
+	for _, col := range cols {
+		p.columns[strings.ToLower(col)] = true
+	}
+	return p
+}
+
+// AllowOperators restricts the operators of the column, the operators of all
+// the columns are restricted if col is empty
+func (p *Parser) AllowOperators(col string, ops ...Operator) *Parser {
+	col = strings.ToLower(col)
+	p.operators[col] = append(p.operators[col], ops...)
+	return p
+}
+
+// SetMaxConditions sets the max number of the comparisons in a filter expression
+func (p *Parser) SetMaxConditions(n int) *Parser {
+	p.maxConditions = n
+	return p
+}
+
+// SetLocation sets the location of the times without time zone
+func (p *Parser) SetLocation(loc *time.Location) *Parser {
+	p.location = loc
+	return p
+}
+
+// SetQuoter sets the quoter of the column names, the names are quoted by ` by default
+// which are replaced by the quotes of the dialect when they are used by the engine
+func (p *Parser) SetQuoter(quoter schemas.Quoter) *Parser {
+	p.quoter = quoter
+	return p
+}
+
+// column finds the column by its name or its field name
+func (p *Parser) column(name string, pos int) (*schemas.Column, error) {
+	col := p.table.GetColumn(name)
+	if col == nil {
+		for _, c := range p.table.Columns() {
+			if strings.EqualFold(c.FieldName, name) {
+				col = c
+				break
+			}
+		}
+	}
+	if col == nil || (p.columns != nil && !p.columns[strings.ToLower(col.Name)]) {
+		return nil, newError(pos, "unknown column %s", name)
+	}
+	return col, nil
+}
+
+func (p *Parser) checkOperator(col *schemas.Column, op Operator, pos int) error {
+	if ops, ok := p.operators[strings.ToLower(col.Name)]; ok {
+		return containsOperator(ops, op, col, pos)
+	}
+	if ops, ok := p.operators[""]; ok {
+		return containsOperator(ops, op, col, pos)
+	}
+	return nil
+}
+
+func containsOperator(ops []Operator, op Operator, col *schemas.Column, pos int) error {
+	for _, o := range ops {
+		if o == op {
+			return nil
+		}
+	}
+	return newError(pos, "operator %s is not allowed on column %s", op, col.Name)
+}
+
+// Parse parses the filter expression, an empty condition will be returned if
+// the expression is empty
+func (p *Parser) Parse(filter string) (builder.Cond, error) {
+	if strings.TrimSpace(filter) == "" {
+		return builder.NewCond(), nil
+	}
+	if len(filter) > DefaultMaxLength {
+		return nil, newError(DefaultMaxLength, "expression is too long")
+	}
+
+	tokens, err := lex(filter)
+	if err != nil {
+		return nil, err
+	}
+	s := &state{parser: p, tokens: tokens}
+	cond, err := s.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := s.peek(); tok.typ != tokenEOF {
+		return nil, newError(tok.pos, "unexpected %q", tok.val)
+	}
+	return cond, nil
+}
+
+// ParseSort parses the sort expression to ORDER BY
+func (p *Parser) ParseSort(sort string) (string, error) {
+	var orders []string
+	var pos int
+	for _, part := range strings.Split(sort, ",") {
+		field := strings.TrimSpace(part)
+		if field == "" {
+			pos += len(part) + 1
+			continue
+		}
+
+		var desc bool
+		if strings.HasPrefix(field, "-") {
+			desc, field = true, field[1:]
+		} else if strings.HasPrefix(field, "+") {
+			field = field[1:]
+		} else if fields := strings.Fields(field); len(fields) == 2 {
+			switch strings.ToLower(fields[1]) {
+			case "asc":
+			case "desc":
+				desc = true
+			default:
+				return "", newError(pos, "invalid sort direction %s", fields[1])
+			}
+			field = fields[0]
+		}
+
+		col, err := p.column(strings.TrimSpace(field), pos)
+		if err != nil {
+			return "", err
+		}
+		if desc {
+			orders = append(orders, p.quoter.Quote(col.Name)+" DESC")
+		} else {
+			orders = append(orders, p.quoter.Quote(col.Name)+" ASC")
+		}
+		pos += len(part) + 1
+	}
+	return strings.Join(orders, ","), nil
+}
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// convert converts the value to the type of the column
+func (p *Parser) convert(col *schemas.Column, tok token) (interface{}, error) {
+	if tok.typ != tokenWord && tok.typ != tokenString {
+		return nil, newError(tok.pos, "value expected")
+	}
+
+	sqlType := col.SQLType
+	switch {
+	case sqlType.Name == schemas.Bool || sqlType.Name == schemas.Boolean:
+		v, err := strconv.ParseBool(tok.val)
+		if err != nil {
+			return nil, newError(tok.pos, "invalid bool %q of column %s", tok.val, col.Name)
+		}
+		return v, nil
+	case sqlType.IsNumeric():
+		switch sqlType.Name {
+		case schemas.Float, schemas.Double, schemas.Real, schemas.Decimal,
+			schemas.Numeric, schemas.Money, schemas.SmallMoney:
+			v, err := strconv.ParseFloat(tok.val, 64)
+			if err != nil {
+				return nil, newError(tok.pos, "invalid number %q of column %s", tok.val, col.Name)
+			}
+			return v, nil
+		}
+		v, err := strconv.ParseInt(tok.val, 10, 64)
+		if err != nil {
+			return nil, newError(tok.pos, "invalid integer %q of column %s", tok.val, col.Name)
+		}
+		return v, nil
+	case sqlType.IsTime():
+		for _, layout := range timeLayouts {
+			if t, err := time.ParseInLocation(layout, tok.val, p.location); err == nil {
+				return t, nil
+			}
+		}
+		return nil, newError(tok.pos, "invalid time %q of column %s", tok.val, col.Name)
+	case sqlType.IsBlob() || sqlType.IsJson() || sqlType.IsArray():
+		return nil, newError(tok.pos, "column %s could not be filtered", col.Name)
+	}
+
+	if len(col.EnumOptions) > 0 {
+		if _, ok := col.EnumOptions[tok.val]; !ok {
+			return nil, newError(tok.pos, "invalid option %q of column %s", tok.val, col.Name)
+		}
+	}
+	return tok.val, nil
+}
+
+// likeEscape is the escape character of the LIKE patterns, backslash is not used since
+// it's an escape character of the string literals of MySQL
+const likeEscape = "!"
+
+// likePattern converts the value to the pattern of LIKE, * matches any characters
+// and the value is contained if there is no *, the wildcards of LIKE in the value
+// are escaped. [ is a wildcard of MSSQL only and Oracle rejects the escaped characters
+// other than the wildcards, so it's escaped for MSSQL only.
+func likePattern(v, dialect string) string {
+	var wildcards = []string{likeEscape, likeEscape + likeEscape, "%", likeEscape + "%", "_", likeEscape + "_"}
+	if dialect == builder.MSSQL {
+		wildcards = append(wildcards, "[", likeEscape+"[")
+	}
+	v = strings.NewReplacer(wildcards...).Replace(v)
+	if strings.Contains(v, "*") {
+		return strings.Replace(v, "*", "%", -1)
+	}
+	return "%" + v + "%"
+}
+
+// likeCond is the LIKE condition of the filter, the pattern is escaped according to
+// the dialect of the writer
+type likeCond struct {
+	col   string
+	value string
+}
+
+var _ builder.Cond = likeCond{}
+
+// WriteTo writes SQL to Writer
+func (like likeCond) WriteTo(w builder.Writer) error {
+	var dialect string
+	if dw, ok := w.(interface{ Dialect() string }); ok {
+		dialect = dw.Dialect()
+	}
+	if _, err := fmt.Fprintf(w, "%s LIKE ? ESCAPE '%s'", like.col, likeEscape); err != nil {
+		return err
+	}
+	w.Append(likePattern(like.value, dialect))
+	return nil
+}
+
+// And implements And with other conditions
+func (like likeCond) And(conds ...builder.Cond) builder.Cond {
+	return builder.And(like, builder.And(conds...))
+}
+
+// Or implements Or with other conditions
+func (like likeCond) Or(conds ...builder.Cond) builder.Cond {
+	return builder.Or(like, builder.Or(conds...))
+}
+
+// IsValid tests if this condition is valid
+func (like likeCond) IsValid() bool {
+	return len(like.col) > 0
+}
+
+type state struct {
+	parser     *Parser
+	tokens     []token
+	idx        int
+	conditions int
+}
+
+func (s *state) peek() token {
+	return s.tokens[s.idx]
+}
+
+func (s *state) next() token {
+	tok := s.tokens[s.idx]
+	if tok.typ != tokenEOF {
+		s.idx++
+	}
+	return tok
+}
+
+func (s *state) isKeyword(tok token, keyword string) bool {
+	return tok.typ == tokenWord && strings.EqualFold(tok.val, keyword)
+}
+
+func (s *state) parseOr(depth int) (builder.Cond, error) {
+	cond, err := s.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := s.peek()
+		if tok.typ != tokenComma && !s.isKeyword(tok, "or") {
+			return cond, nil
+		}
+		s.next()
+		right, err := s.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		cond = builder.Or(cond, right)
+	}
+}
+
+func (s *state) parseAnd(depth int) (builder.Cond, error) {
+	cond, err := s.parseUnary(depth)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := s.peek()
+		if tok.typ != tokenSemicolon && !s.isKeyword(tok, "and") {
+			return cond, nil
+		}
+		s.next()
+		right, err := s.parseUnary(depth)
+		if err != nil {
+			return nil, err
+		}
+		cond = builder.And(cond, right)
+	}
+}
+
+func (s *state) parseUnary(depth int) (builder.Cond, error) {
+	if depth > maxDepth {
+		return nil, newError(s.peek().pos, "expression is nested too deeply")
+	}
+
+	tok := s.peek()
+	switch {
+	case s.isKeyword(tok, "not"):
+		s.next()
+		cond, err := s.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return builder.Not{cond}, nil
+	case tok.typ == tokenLParen:
+		s.next()
+		cond, err := s.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if tok := s.next(); tok.typ != tokenRParen {
+			return nil, newError(tok.pos, "%q expected", ")")
+		}
+		return cond, nil
+	default:
+		return s.parseComparison()
+	}
+}
+
+// parseOperator reads the operator after the column
+func (s *state) parseOperator() (Operator, token, error) {
+	tok := s.next()
+	switch {
+	case tok.typ == tokenOp:
+		op, ok := operatorAliases[tok.val]
+		if !ok {
+			return "", tok, newError(tok.pos, "unknown operator %s", tok.val)
+		}
+		return op, tok, nil
+	case s.isKeyword(tok, "in"):
+		return In, tok, nil
+	case s.isKeyword(tok, "out"):
+		return NotIn, tok, nil
+	case s.isKeyword(tok, "like"):
+		return Like, tok, nil
+	case s.isKeyword(tok, "not"):
+		if next := s.next(); s.isKeyword(next, "in") {
+			return NotIn, tok, nil
+		} else if s.isKeyword(next, "like") {
+			return NotLike, tok, nil
+		}
+		return "", tok, newError(tok.pos, "in or like expected after not")
+	}
+	return "", tok, newError(tok.pos, "operator expected")
+}
+
+func (s *state) parseComparison() (builder.Cond, error) {
+	colTok := s.next()
+	if colTok.typ != tokenWord {
+		return nil, newError(colTok.pos, "column expected")
+	}
+	s.conditions++
+	if s.conditions > s.parser.maxConditions {
+		return nil, newError(colTok.pos, "too many conditions")
+	}
+
+	col, err := s.parser.column(colTok.val, colTok.pos)
+	if err != nil {
+		return nil, err
+	}
+	colName := s.parser.quoter.Quote(col.Name)
+	op, opTok, err := s.parseOperator()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.parser.checkOperator(col, op, opTok.pos); err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case In, NotIn:
+		values, err := s.parseList(col)
+		if err != nil {
+			return nil, err
+		}
+		if op == In {
+			return builder.In(colName, values...), nil
+		}
+		return builder.NotIn(colName, values...), nil
+	case Like, NotLike:
+		tok := s.next()
+		if tok.typ != tokenWord && tok.typ != tokenString {
+			return nil, newError(tok.pos, "value expected")
+		}
+		if !col.SQLType.IsText() {
+			return nil, newError(opTok.pos, "operator %s is not allowed on column %s", op, col.Name)
+		}
+		var cond builder.Cond = likeCond{colName, tok.val}
+		if op == NotLike {
+			return builder.Not{cond}, nil
+		}
+		return cond, nil
+	}
+
+	tok := s.next()
+	if tok.typ == tokenWord && strings.EqualFold(tok.val, "null") {
+		switch op {
+		case Eq:
+			return builder.IsNull{colName}, nil
+		case Ne:
+			return builder.NotNull{colName}, nil
+		}
+		return nil, newError(tok.pos, "null could only be compared by == or !=")
+	}
+
+	v, err := s.parser.convert(col, tok)
+	if err != nil {
+		return nil, err
+	}
+	switch op {
+	case Eq:
+		return builder.Eq{colName: v}, nil
+	case Ne:
+		return builder.Neq{colName: v}, nil
+	case Gt:
+		return builder.Gt{colName: v}, nil
+	case Ge:
+		return builder.Gte{colName: v}, nil
+	case Lt:
+		return builder.Lt{colName: v}, nil
+	default:
+		return builder.Lte{colName: v}, nil
+	}
+}
+
+// parseList reads the values in parentheses, a single value without
+// parentheses is also accepted
+func (s *state) parseList(col *schemas.Column) ([]interface{}, error) {
+	tok := s.peek()
+	if tok.typ != tokenLParen {
+		v, err := s.parser.convert(col, s.next())
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{v}, nil
+	}
+
+	s.next()
+	var values []interface{}
+	for {
+		v, err := s.parser.convert(col, s.next())
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+
+		tok := s.next()
+		if tok.typ == tokenRParen {
+			return values, nil
+		}
+		if tok.typ != tokenComma {
+			return nil, newError(tok.pos, "%q or %q expected", ",", ")")
+		}
+	}
+}
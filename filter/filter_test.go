@@ -0,0 +1,146 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package filter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/laixyz/xormplus/builder"
+	"github.com/laixyz/xormplus/schemas"
+	"github.com/stretchr/testify/assert"
+)
+
+func testTable() *schemas.Table {
+	table := schemas.NewEmptyTable()
+	table.Name = "account"
+	table.AddColumn(schemas.NewColumn("id", "Id", schemas.SQLType{Name: schemas.BigInt}, 0, 0, false))
+	table.AddColumn(schemas.NewColumn("name", "Name", schemas.SQLType{Name: schemas.Varchar}, 255, 0, true))
+	table.AddColumn(schemas.NewColumn("score", "Score", schemas.SQLType{Name: schemas.Double}, 0, 0, false))
+	table.AddColumn(schemas.NewColumn("active", "Active", schemas.SQLType{Name: schemas.Bool}, 0, 0, false))
+	table.AddColumn(schemas.NewColumn("created_at", "CreatedAt", schemas.SQLType{Name: schemas.DateTime}, 0, 0, false))
+	table.AddColumn(schemas.NewColumn("doc", "Doc", schemas.SQLType{Name: schemas.Json}, 0, 0, true))
+	status := schemas.NewColumn("status", "Status", schemas.SQLType{Name: schemas.Enum}, 0, 0, false)
+	status.EnumOptions = map[string]int{"active": 0, "pending": 1}
+	table.AddColumn(status)
+	return table
+}
+
+func TestParse(t *testing.T) {
+	var cases = []struct {
+		filter string
+		sql    string
+		args   []interface{}
+	}{
+		{"", "", nil},
+		{"id == 1", "`id`=?", []interface{}{int64(1)}},
+		{"Name = 'a b' and score > 1.5", "`name`=? AND `score`>?", []interface{}{"a b", 1.5}},
+		{"id=gt=1;id=le=10", "`id`>? AND `id`<=?", []interface{}{int64(1), int64(10)}},
+		{"id < 1 or id >= 10, id <> 5", "`id`<? OR `id`>=? OR `id`<>?", []interface{}{int64(1), int64(10), int64(5)}},
+		{"id = 1 or id = 2 and active = true", "`id`=? OR (`id`=? AND `active`=?)", []interface{}{int64(1), int64(2), true}},
+		{"(id = 1 or id = 2) and not name ~ foo", "(`id`=? OR `id`=?) AND NOT `name` LIKE ? ESCAPE '!'", []interface{}{int64(1), int64(2), "%foo%"}},
+		{"name=like=\"fo*\"", "`name` LIKE ? ESCAPE '!'", []interface{}{"fo%"}},
+		{"name !~ foo", "NOT `name` LIKE ? ESCAPE '!'", []interface{}{"%foo%"}},
+		{"name ~ '100%_!*'", "`name` LIKE ? ESCAPE '!'", []interface{}{"100!%!_!!%"}},
+		{"status in (active, pending)", "`status` IN (?,?)", []interface{}{"active", "pending"}},
+		{"status=out=(active);id not in (1,2)", "`status` NOT IN (?) AND `id` NOT IN (?,?)", []interface{}{"active", int64(1), int64(2)}},
+		{"name == null or name != NULL", "`name` IS NULL OR `name` IS NOT NULL", nil},
+	}
+
+	parser := NewParser(testTable())
+	for _, c := range cases {
+		cond, err := parser.Parse(c.filter)
+		if !assert.NoError(t, err, c.filter) {
+			continue
+		}
+		sql, args, err := builder.ToSQL(cond)
+		assert.NoError(t, err)
+		assert.EqualValues(t, c.sql, sql, c.filter)
+		assert.EqualValues(t, c.args, args, c.filter)
+	}
+}
+
+func TestParseLikeMSSQL(t *testing.T) {
+	cond, err := NewParser(testTable()).Parse("name ~ '[a]_'")
+	assert.NoError(t, err)
+	sql, args, err := builder.ToDialectSQL(builder.MSSQL, cond)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "`name` LIKE ? ESCAPE '!'", sql)
+	assert.EqualValues(t, []interface{}{"%![a]!_%"}, args)
+}
+
+func TestParseTime(t *testing.T) {
+	parser := NewParser(testTable()).SetLocation(time.UTC)
+	cond, err := parser.Parse("created_at >= 2020-01-02 and created_at < '2020-01-03 10:00:00'")
+	assert.NoError(t, err)
+	_, args, err := builder.ToSQL(cond)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []interface{}{
+		time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+		time.Date(2020, 1, 3, 10, 0, 0, 0, time.UTC),
+	}, args)
+}
+
+func TestParseErrors(t *testing.T) {
+	parser := NewParser(testTable()).
+		AllowColumns("id", "name", "score", "active", "created_at", "doc", "status").
+		AllowOperators("name", Eq, Like).
+		SetMaxConditions(3)
+
+	var cases = []struct {
+		filter string
+		pos    int
+	}{
+		{"password == 1", 0},
+		{"id == abc", 6},
+		{"score > 1e", 8},
+		{"active = yes", 9},
+		{"created_at > yesterday", 13},
+		{"status = deleted", 9},
+		{"doc == 1", 7},
+		{"name != foo", 5},
+		{"id ~ 1", 3},
+		{"id == 1 and", 11},
+		{"(id == 1", 8},
+		{"id == 1)", 7},
+		{"id > null", 5},
+		{"id = 'abc", 5},
+		{"id ?? 1", 3},
+		{"id == 1, id == 2, id == 3, id == 4", 27},
+		{"1 or 1=1 --", 0},
+	}
+
+	for _, c := range cases {
+		_, err := parser.Parse(c.filter)
+		if assert.Error(t, err, c.filter) {
+			assert.IsType(t, &Error{}, err)
+			assert.EqualValues(t, c.pos, err.(*Error).Pos, c.filter)
+		}
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	parser := NewParser(testTable())
+	orderBy, err := parser.ParseSort("-created_at, Name,+id")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "`created_at` DESC,`name` ASC,`id` ASC", orderBy)
+
+	orderBy, err = parser.ParseSort("score desc,CreatedAt asc")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "`score` DESC,`created_at` ASC", orderBy)
+
+	orderBy, err = NewParser(testTable()).SetQuoter(schemas.Quoter{Prefix: '[', Suffix: ']', IsReserved: schemas.AlwaysReserve}).ParseSort("-id")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "[id] DESC", orderBy)
+
+	_, err = parser.ParseSort("id; DROP TABLE account")
+	assert.Error(t, err)
+
+	_, err = parser.ParseSort("id down")
+	assert.Error(t, err)
+
+	_, err = parser.AllowColumns("id").ParseSort("name")
+	assert.Error(t, err)
+}
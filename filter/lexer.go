@@ -0,0 +1,116 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package filter
+
+import (
+	"strings"
+	"unicode"
+)
+
+type tokenType int
+
+const (
+	tokenEOF tokenType = iota
+	tokenWord
+	tokenString
+	tokenOp
+	tokenLParen
+	tokenRParen
+	tokenComma
+	tokenSemicolon
+)
+
+type token struct {
+	typ tokenType
+	val string
+	pos int
+}
+
+// isWordRune returns true if the rune could be a part of a bare word, i.e. columns,
+// keywords, numbers and times like 2024-01-01T10:00:00+08:00
+func isWordRune(r rune) bool {
+	if unicode.IsSpace(r) {
+		return false
+	}
+	switch r {
+	case '(', ')', ',', ';', '"', '\'', '=', '!', '<', '>', '~':
+		return false
+	}
+	return true
+}
+
+func lex(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokenLParen, "(", i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokenRParen, ")", i})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{tokenComma, ",", i})
+			i++
+		case r == ';':
+			tokens = append(tokens, token{tokenSemicolon, ";", i})
+			i++
+		case r == '"' || r == '\'':
+			var sb strings.Builder
+			start := i
+			i++
+			for ; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, newError(start, "unterminated string")
+			}
+			i++
+			tokens = append(tokens, token{tokenString, sb.String(), start})
+		case r == '=' || r == '!' || r == '<' || r == '>' || r == '~':
+			start := i
+			op, n := lexOp(runes[i:])
+			if n == 0 {
+				return nil, newError(start, "invalid operator")
+			}
+			i += n
+			tokens = append(tokens, token{tokenOp, op, start})
+		default:
+			start := i
+			for i < len(runes) && isWordRune(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{tokenWord, string(runes[start:i]), start})
+		}
+	}
+	return append(tokens, token{tokenEOF, "", len(runes)}), nil
+}
+
+// lexOp reads an operator, the FIQL operators like =gt= are supported
+func lexOp(runes []rune) (string, int) {
+	if runes[0] == '=' {
+		// =gt=, =in= and etc.
+		var j = 1
+		for j < len(runes) && unicode.IsLetter(runes[j]) {
+			j++
+		}
+		if j > 1 && j < len(runes) && runes[j] == '=' {
+			return strings.ToLower(string(runes[:j+1])), j + 1
+		}
+	}
+	for _, op := range []string{"==", "!=", "<>", ">=", "<=", "!~", "=", ">", "<", "~"} {
+		if strings.HasPrefix(string(runes), op) {
+			return op, len([]rune(op))
+		}
+	}
+	return "", 0
+}
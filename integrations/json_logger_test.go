@@ -0,0 +1,99 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/laixyz/xormplus/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONLogger(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type JsonLoggerRecord struct {
+		Id       int64
+		Name     string
+		Password string
+		Avatar   []byte
+	}
+	assertSync(t, new(JsonLoggerRecord))
+
+	engine := newIsolatedEngine(t)
+
+	var buf bytes.Buffer
+	logger := log.NewJSONLogger(&buf).
+		SetRedactColumns(colMapper.Obj2Table("Password")).
+		SetMaxArgLength(8)
+	logger.ShowSQL(true)
+	engine.SetLogger(logger)
+
+	session := engine.NewSession()
+	defer session.Close()
+	assert.NoError(t, session.Begin())
+	_, err := session.Insert(&JsonLoggerRecord{Name: "a", Password: "secret", Avatar: make([]byte, 16)})
+	assert.NoError(t, err)
+	assert.NoError(t, session.Commit())
+	_, err = engine.Where(colMapper.Obj2Table("Password")+" = ?", "secret").Count(new(JsonLoggerRecord))
+	assert.NoError(t, err)
+	_, err = engine.Exec("SELECT * FROM not_exist_table")
+	assert.Error(t, err)
+
+	type entry struct {
+		Level        string        `json:"level"`
+		Msg          string        `json:"msg"`
+		TxID         string        `json:"tx_id"`
+		SQL          string        `json:"sql"`
+		Args         []interface{} `json:"args"`
+		Duration     *float64      `json:"duration_ms"`
+		RowsAffected *int64        `json:"rows_affected"`
+		Error        string        `json:"error"`
+	}
+	var entries []entry
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var e entry
+		assert.NoError(t, json.Unmarshal([]byte(line), &e), line)
+		entries = append(entries, e)
+	}
+
+	var insert, count, failed *entry
+	txIDs := make(map[string]bool)
+	for i, e := range entries {
+		switch {
+		case strings.HasPrefix(e.SQL, "INSERT"):
+			insert = &entries[i]
+		case strings.HasPrefix(e.SQL, "SELECT count"):
+			count = &entries[i]
+		case e.Error != "":
+			failed = &entries[i]
+		}
+		if e.SQL == "BEGIN TRANSACTION" || e.SQL == "COMMIT" || strings.HasPrefix(e.SQL, "INSERT") {
+			txIDs[e.TxID] = true
+		}
+	}
+	if assert.NotNil(t, insert) {
+		assert.EqualValues(t, "info", insert.Level)
+		assert.EqualValues(t, "sql", insert.Msg)
+		assert.EqualValues(t, []interface{}{"a", log.RedactedValue, "[16 bytes]"}, insert.Args)
+		assert.NotNil(t, insert.Duration)
+		if assert.NotNil(t, insert.RowsAffected) {
+			assert.EqualValues(t, 1, *insert.RowsAffected)
+		}
+		assert.NotEmpty(t, insert.TxID)
+	}
+	// the statements of the transaction have the same transaction ID
+	assert.EqualValues(t, 1, len(txIDs))
+	if assert.NotNil(t, count) {
+		assert.EqualValues(t, []interface{}{log.RedactedValue}, count.Args)
+		assert.Empty(t, count.TxID)
+	}
+	if assert.NotNil(t, failed) {
+		assert.EqualValues(t, "error", failed.Level)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/laixyz/xormplus/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type MetricsRecord struct {
+		Id   int64
+		Name string
+	}
+	assertSync(t, new(MetricsRecord))
+
+	engine := newIsolatedEngine(t)
+
+	collector := metrics.NewMemory()
+	engine.AddMetrics(collector)
+
+	session := engine.NewSession()
+	defer session.Close()
+	assert.NoError(t, session.Begin())
+	_, err := session.Insert(&MetricsRecord{Name: "a"}, &MetricsRecord{Name: "b"})
+	assert.NoError(t, err)
+	assert.NoError(t, session.Commit())
+
+	var records []MetricsRecord
+	assert.NoError(t, engine.Find(&records))
+	assert.EqualValues(t, 2, len(records))
+
+	tableName := tableMapper.Obj2Table("MetricsRecord")
+	s := collector.Snapshot()
+	assert.EqualValues(t, 1, s.Statements[metrics.StatementKey{Op: "select", Table: tableName}].Count)
+	assert.EqualValues(t, 2, s.Rows[metrics.StatementKey{Op: "select", Table: tableName}])
+	assert.EqualValues(t, 2, s.Rows[metrics.StatementKey{Op: "insert", Table: tableName}])
+	assert.EqualValues(t, 1, s.Transactions["commit"].Count)
+	assert.True(t, s.Pool.OpenConnections > 0)
+
+	var buf bytes.Buffer
+	assert.NoError(t, metrics.WritePrometheus(&buf, collector))
+	assert.Contains(t, buf.String(), `xorm_rows_total{op="select",table="`+tableName+`"} 2`)
+}
+
+func TestMetricsEngineGroup(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type MetricsGroupRecord struct {
+		Id   int64
+		Name string
+	}
+	assertSync(t, new(MetricsGroupRecord))
+
+	eg := newIsolatedEngineGroup(t)
+	collector := metrics.NewMemory()
+	eg.AddMetrics(collector)
+
+	// the queries of the group sessions are executed by the slave
+	sess := eg.NewSession()
+	defer sess.Close()
+	var records []MetricsGroupRecord
+	assert.NoError(t, sess.Find(&records))
+
+	tableName := tableMapper.Obj2Table("MetricsGroupRecord")
+	s := collector.Snapshot()
+	assert.EqualValues(t, 1, s.Statements[metrics.StatementKey{Op: "select", Table: tableName}].Count)
+	assert.True(t, s.Pool.OpenConnections > 0)
+}
@@ -0,0 +1,106 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/laixyz/xormplus/log"
+	"github.com/laixyz/xormplus/nplusone"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNPlusOneDetector(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type NPlusOneRecord struct {
+		Id   int64
+		Name string
+	}
+	assertSync(t, new(NPlusOneRecord))
+
+	for i := 0; i < 5; i++ {
+		_, err := testEngine.Insert(&NPlusOneRecord{Name: fmt.Sprintf("%d", i)})
+		assert.NoError(t, err)
+	}
+
+	engine := newIsolatedEngine(t)
+
+	var buf bytes.Buffer
+	engine.SetLogger(log.NewSimpleLogger(&buf))
+	assert.True(t, engine.EnableNPlusOneDetector(3) == engine.EnableNPlusOneDetector(3))
+
+	// every session of the engine has its own scope
+	for i := int64(1); i <= 5; i++ {
+		var record NPlusOneRecord
+		has, err := engine.ID(i).Get(&record)
+		assert.NoError(t, err)
+		assert.True(t, has)
+	}
+	assert.NotContains(t, buf.String(), "N+1 query detected")
+
+	ctx := nplusone.WithScope(context.Background())
+	for i := int64(1); i <= 5; i++ {
+		var record NPlusOneRecord
+		has, err := engine.Context(ctx).ID(i).Get(&record)
+		assert.NoError(t, err)
+		assert.True(t, has)
+	}
+	reports := nplusone.Reports(ctx)
+	if assert.EqualValues(t, 1, len(reports)) {
+		assert.EqualValues(t, 5, reports[0].Count)
+		assert.Contains(t, reports[0].Stack, "nplusone_test.go")
+	}
+	assert.EqualValues(t, 1, strings.Count(buf.String(), "N+1 query detected"))
+	assert.Contains(t, buf.String(), "nplusone_test.go")
+
+	// a long lived session is a scope even if its context is replaced
+	engine.EnableNPlusOneDetector(3).SetFail(true)
+	session := engine.NewSession().Context(context.Background())
+	defer session.Close()
+	for i := int64(1); i <= 4; i++ {
+		var record NPlusOneRecord
+		_, err := session.ID(i).Get(&record)
+		if i <= 3 {
+			assert.NoError(t, err)
+		} else {
+			assert.Error(t, err)
+			_, ok := err.(*nplusone.Report)
+			assert.True(t, ok)
+		}
+	}
+}
+
+func TestNPlusOneDetectorEngineGroup(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type NPlusOneGroupRecord struct {
+		Id   int64
+		Name string
+	}
+	assertSync(t, new(NPlusOneGroupRecord))
+
+	eg := newIsolatedEngineGroup(t)
+	detector := eg.EnableNPlusOneDetector(3)
+	assert.True(t, detector == eg.Slave().EnableNPlusOneDetector(3))
+
+	// the queries of the group sessions are executed by the slave
+	ctx := nplusone.WithScope(context.Background())
+	sess := eg.NewSession().Context(ctx)
+	defer sess.Close()
+	for i := int64(1); i <= 4; i++ {
+		var record NPlusOneGroupRecord
+		_, err := sess.ID(i).Get(&record)
+		assert.NoError(t, err)
+	}
+	reports := nplusone.Reports(ctx)
+	if assert.EqualValues(t, 1, len(reports)) {
+		assert.EqualValues(t, 4, reports[0].Count)
+	}
+}
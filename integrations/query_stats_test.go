@@ -0,0 +1,71 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryStats(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type QueryStatsRecord struct {
+		Id   int64
+		Name string
+	}
+	assertSync(t, new(QueryStatsRecord))
+
+	engine := newIsolatedEngine(t)
+
+	assert.Nil(t, engine.QueryStats())
+	assert.True(t, engine.EnableQueryStats() == engine.EnableQueryStats())
+
+	_, err := engine.Insert(&QueryStatsRecord{Name: "a"}, &QueryStatsRecord{Name: "b"}, &QueryStatsRecord{Name: "c"})
+	assert.NoError(t, err)
+	for i := 1; i <= 3; i++ {
+		var records []QueryStatsRecord
+		assert.NoError(t, engine.In("id", []int{1, 2, 3}[:i]).Find(&records))
+	}
+
+	var found bool
+	for _, stat := range engine.QueryStats() {
+		if strings.HasPrefix(stat.Fingerprint, "SELECT") && strings.Contains(stat.Fingerprint, "IN (...)") {
+			found = true
+			assert.EqualValues(t, 3, stat.Calls)
+			assert.EqualValues(t, 6, stat.Rows)
+			assert.True(t, stat.TotalTime > 0)
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestQueryStatsEngineGroup(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type QueryStatsGroupRecord struct {
+		Id   int64
+		Name string
+	}
+	assertSync(t, new(QueryStatsGroupRecord))
+
+	eg := newIsolatedEngineGroup(t)
+	registry := eg.EnableQueryStats()
+	assert.True(t, registry == eg.Slave().EnableQueryStats())
+
+	// the queries of the group sessions are executed by the slave
+	sess := eg.NewSession()
+	defer sess.Close()
+	_, err := sess.Count(new(QueryStatsGroupRecord))
+	assert.NoError(t, err)
+
+	stats := eg.QueryStats()
+	if assert.EqualValues(t, 1, len(stats)) {
+		assert.True(t, strings.HasPrefix(stats[0].Fingerprint, "SELECT count(*)"), stats[0].Fingerprint)
+		assert.EqualValues(t, 1, stats[0].Calls)
+	}
+}
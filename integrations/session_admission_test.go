@@ -0,0 +1,96 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"testing"
+	"time"
+
+	"github.com/laixyz/xormplus/admission"
+	"github.com/laixyz/xormplus/caches"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdmissionController(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type AdmissionRecord struct {
+		Id   int64
+		Name string
+	}
+	assertSync(t, new(AdmissionRecord))
+
+	engine := newIsolatedEngine(t)
+	controller := admission.NewController(1).SetQueueTimeout(50 * time.Millisecond)
+	engine.SetAdmissionController(controller)
+
+	// the transaction is admitted once from Begin to Commit
+	sess := engine.NewSession()
+	defer sess.Close()
+	assert.NoError(t, sess.Begin())
+	_, err := sess.Insert(&AdmissionRecord{Name: "a"}, &AdmissionRecord{Name: "b"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, controller.Stats().InFlight)
+
+	_, err = engine.Count(new(AdmissionRecord))
+	assert.EqualValues(t, admission.ErrQueueTimeout, err)
+	assert.NoError(t, sess.Commit())
+	assert.EqualValues(t, 0, controller.Stats().InFlight)
+
+	// the rows hold the admission until they are closed
+	rows, err := engine.Rows(new(AdmissionRecord))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, controller.Stats().InFlight)
+	assert.NoError(t, rows.Close())
+	assert.EqualValues(t, 0, controller.Stats().InFlight)
+
+	var names []string
+	err = engine.Priority(admission.PriorityLow).Asc("id").Iterate(new(AdmissionRecord), func(i int, bean interface{}) error {
+		names = append(names, bean.(*AdmissionRecord).Name)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"a", "b"}, names)
+
+	stats := controller.Stats()
+	assert.EqualValues(t, 0, stats.InFlight)
+	assert.EqualValues(t, 1, stats.Priorities[admission.PriorityLow].Admitted)
+	assert.EqualValues(t, 1, stats.Priorities[admission.PriorityNormal].Timeouts)
+	assert.EqualValues(t, 2, stats.Priorities[admission.PriorityNormal].Admitted)
+}
+
+func TestAdmissionControllerCacher(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type AdmissionCacheRecord struct {
+		Id   int64
+		Name string
+	}
+	assertSync(t, new(AdmissionCacheRecord))
+
+	engine := newIsolatedEngine(t)
+	engine.SetDefaultCacher(caches.NewLRUCacher2(caches.NewMemoryStore(), time.Hour, 10000))
+	controller := admission.NewController(1).SetQueueTimeout(50 * time.Millisecond)
+	engine.SetAdmissionController(controller)
+
+	_, err := engine.Insert(&AdmissionCacheRecord{Name: "a"}, &AdmissionCacheRecord{Name: "b"})
+	assert.NoError(t, err)
+
+	// the statements of the cacher executed while the rows of the ids are open share
+	// the admission of the session
+	var records []AdmissionCacheRecord
+	assert.NoError(t, engine.Asc("id").Find(&records))
+	assert.EqualValues(t, 2, len(records))
+
+	var record AdmissionCacheRecord
+	has, err := engine.Where("name = ?", "b").Get(&record)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.EqualValues(t, "b", record.Name)
+
+	stats := controller.Stats()
+	assert.EqualValues(t, 0, stats.InFlight)
+	assert.EqualValues(t, 0, stats.Priorities[admission.PriorityNormal].Timeouts)
+}
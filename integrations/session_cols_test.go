@@ -63,6 +63,43 @@ func TestSetExpr(t *testing.T) {
 	assert.EqualValues(t, 1, cnt)
 }
 
+func TestSetExprFunc(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type UserExprFunc struct {
+		Id    int64
+		Name  string
+		Nick  string `xorm:"null"`
+		Title string
+	}
+
+	assertSync(t, new(UserExprFunc))
+
+	_, err := testEngine.Cols("name", "title").Insert(&UserExprFunc{Name: "lunny"})
+	assert.NoError(t, err)
+
+	cnt, err := testEngine.SetExpr("title",
+		builder.Concat("name", builder.Value("-"), builder.Coalesce("nick", builder.Value("none")))).
+		ID(1).Update(new(UserExprFunc))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+
+	var user UserExprFunc
+	has, err := testEngine.Where(builder.Concat("name", builder.Value("!")).Compare("=", "lunny!")).Get(&user)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.EqualValues(t, "lunny-none", user.Title)
+
+	_, err = testEngine.SetExpr("title", builder.Fn("UPPER", builder.Value("expr"))).
+		Cols("name").Insert(&UserExprFunc{Name: "xorm"})
+	assert.NoError(t, err)
+
+	var titles []string
+	assert.NoError(t, testEngine.Table(new(UserExprFunc)).Cols("title").
+		Where(builder.Eq{"name": "xorm"}).Find(&titles))
+	assert.EqualValues(t, []string{"EXPR"}, titles)
+}
+
 func TestCols(t *testing.T) {
 	assert.NoError(t, PrepareEngine())
 
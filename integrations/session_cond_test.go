@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/laixyz/xormplus/builder"
+	"github.com/laixyz/xormplus/filter"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -194,6 +195,60 @@ func TestIn(t *testing.T) {
 	assert.EqualValues(t, 1, cnt)
 }
 
+func TestInLimit(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+	assert.NoError(t, testEngine.Sync2(new(Userinfo)))
+
+	var users = make([]Userinfo, 0, 10)
+	for i := 0; i < 10; i++ {
+		users = append(users, Userinfo{Username: fmt.Sprintf("user%d", i), Departname: "dev"})
+	}
+	cnt, err := testEngine.Insert(users)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 10, cnt)
+
+	var ids []int64
+	assert.NoError(t, testEngine.Table(new(Userinfo)).Cols("id").Find(&ids))
+	assert.EqualValues(t, 10, len(ids))
+
+	dialect := string(testEngine.Dialect().URI().DBType)
+	threshold, oldStrategy := builder.InLimit(dialect)
+	defer builder.SetInLimit(dialect, threshold, oldStrategy)
+
+	// InTempTable fallbacks to InSplit out of transactions
+	for _, strategy := range []builder.InStrategy{builder.InSplit, builder.InInline, builder.InTempTable} {
+		builder.SetInLimit(dialect, 3, strategy)
+
+		users = make([]Userinfo, 0)
+		err = testEngine.Where("id > ?", 0).In("id", ids[:8]).Find(&users)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 8, len(users))
+
+		total, err := testEngine.NotIn("id", ids[:8]).Count(new(Userinfo))
+		assert.NoError(t, err)
+		assert.EqualValues(t, 2, total)
+	}
+
+	// the values are loaded into temporary tables in a transaction
+	builder.SetInLimit(dialect, 3, builder.InTempTable)
+	sess := testEngine.NewSession()
+	defer sess.Close()
+	assert.NoError(t, sess.Begin())
+
+	users = make([]Userinfo, 0)
+	assert.NoError(t, sess.In("id", ids[:8]).Find(&users))
+	assert.EqualValues(t, 8, len(users))
+	if dialect != "oracle" {
+		lastSQL, _ := sess.LastSQL()
+		assert.Contains(t, lastSQL, "(SELECT v FROM ")
+	}
+
+	total, err := sess.NotIn("username", "user0", "user1", "user2", "user3").Count(new(Userinfo))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 6, total)
+	assert.NoError(t, sess.Commit())
+}
+
 func TestFindAndCount(t *testing.T) {
 	assert.NoError(t, PrepareEngine())
 
@@ -225,3 +280,46 @@ func TestFindAndCount(t *testing.T) {
 	assert.NoError(t, err)
 	assert.EqualValues(t, 1, total)
 }
+
+func TestFilterParser(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type FilterAccount struct {
+		Id     int64
+		Name   string
+		Score  float64
+		Status string `xorm:"ENUM('active','pending')"`
+	}
+	assertSync(t, new(FilterAccount))
+
+	_, err := testEngine.Insert([]FilterAccount{
+		{Name: "foo", Score: 1.5, Status: "active"},
+		{Name: "bar", Score: 3, Status: "pending"},
+		{Name: "foobar", Score: 5, Status: "active"},
+	})
+	assert.NoError(t, err)
+
+	table, err := testEngine.TableInfo(new(FilterAccount))
+	assert.NoError(t, err)
+	parser := filter.NewParser(table)
+
+	cond, err := parser.Parse("name ~ foo and (score > 2 or status in (pending))")
+	assert.NoError(t, err)
+	orderBy, err := parser.ParseSort("-score")
+	assert.NoError(t, err)
+
+	var accounts []FilterAccount
+	assert.NoError(t, testEngine.Where(cond).OrderBy(orderBy).Find(&accounts))
+	assert.EqualValues(t, 1, len(accounts))
+	assert.EqualValues(t, "foobar", accounts[0].Name)
+
+	// the wildcards in the value are matched literally
+	cond, err = parser.Parse("name ~ 'f_o%'")
+	assert.NoError(t, err)
+	cnt, err := testEngine.Where(cond).Count(new(FilterAccount))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, cnt)
+
+	_, err = parser.Parse("name == foo; drop table filter_account")
+	assert.Error(t, err)
+}
@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/laixyz/xormplus"
 	"github.com/laixyz/xormplus/caches"
 	"github.com/laixyz/xormplus/schemas"
 
@@ -239,3 +240,68 @@ func TestUnscopeDelete(t *testing.T) {
 	assert.NoError(t, err)
 	assert.False(t, has)
 }
+
+func TestDeleteJoin(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type DeleteJoinGroup struct {
+		Id   int64
+		Name string
+	}
+
+	type DeleteJoinUser struct {
+		Id        int64
+		Name      string
+		GroupId   int64
+		DeletedAt time.Time `xorm:"deleted"`
+	}
+
+	assertSync(t, new(DeleteJoinGroup), new(DeleteJoinUser))
+
+	var groups = []DeleteJoinGroup{{Name: "admin"}, {Name: "guest"}}
+	for i := range groups {
+		_, err := testEngine.Insert(&groups[i])
+		assert.NoError(t, err)
+	}
+	cnt, err := testEngine.Insert([]DeleteJoinUser{
+		{Name: "a", GroupId: groups[0].Id},
+		{Name: "b", GroupId: groups[1].Id},
+		{Name: "c", GroupId: groups[0].Id},
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, cnt)
+
+	userTable := testEngine.TableName(new(DeleteJoinUser), true)
+	groupTable := testEngine.TableName(new(DeleteJoinGroup), true)
+
+	cnt, err = testEngine.Join("INNER", groupTable, groupTable+".id = "+userTable+".group_id").
+		Where(groupTable+".name = ?", "guest").
+		Delete(new(DeleteJoinUser))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+
+	total, err := testEngine.Count(new(DeleteJoinUser))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, total)
+
+	total, err = testEngine.Unscoped().Count(new(DeleteJoinUser))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, total)
+
+	cnt, err = testEngine.Join("INNER", groupTable, groupTable+".id = "+userTable+".group_id").
+		Where(groupTable+".name = ?", "admin").
+		Unscoped().
+		Delete(new(DeleteJoinUser))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, cnt)
+
+	total, err = testEngine.Unscoped().Count(new(DeleteJoinUser))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+
+	// the join condition is not a condition of the deleted rows
+	_, err = testEngine.Join("INNER", groupTable, groupTable+".id = "+userTable+".group_id").
+		Unscoped().
+		Delete(new(DeleteJoinUser))
+	assert.EqualValues(t, xormplus.ErrNeedDeletedCond, err)
+}
@@ -0,0 +1,125 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/laixyz/xormplus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDryRun(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type DryRunRecord struct {
+		Id        int64
+		Name      string
+		Version   int       `xorm:"version"`
+		DeletedAt time.Time `xorm:"deleted"`
+	}
+	assertSync(t, new(DryRunRecord))
+
+	_, err := testEngine.Insert(&DryRunRecord{Name: "a"})
+	assert.NoError(t, err)
+
+	res, err := testEngine.DryRun(func(session *xormplus.Session) error {
+		_, err := session.Insert(&DryRunRecord{Name: "b"})
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, res.SQL, "INSERT INTO")
+	assert.Contains(t, res.BoundSQL, "'b'")
+	assert.Contains(t, res.Args, "b")
+
+	var records []DryRunRecord
+	res, err = testEngine.Where("name = ?", "a").DryRun(func(session *xormplus.Session) error {
+		return session.Find(&records)
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, res.SQL, colMapper.Obj2Table("DeletedAt"))
+	assert.Contains(t, res.BoundSQL, "'a'")
+	assert.EqualValues(t, 0, len(records))
+
+	res, err = testEngine.ID(1).DryRun(func(session *xormplus.Session) error {
+		_, err := session.Update(&DryRunRecord{Name: "c", Version: 1})
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, res.SQL, "UPDATE")
+	assert.Contains(t, res.SQL, colMapper.Obj2Table("Version"))
+
+	res, err = testEngine.ID(1).DryRun(func(session *xormplus.Session) error {
+		_, err := session.Delete(new(DryRunRecord))
+		return err
+	})
+	assert.NoError(t, err)
+	// soft delete updates the deleted column
+	assert.Contains(t, res.SQL, "UPDATE")
+
+	res, err = testEngine.DryRun(func(session *xormplus.Session) error {
+		_, err := session.Count(new(DryRunRecord))
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, res.SQL, "count(*)")
+
+	// nothing is executed
+	var record DryRunRecord
+	has, err := testEngine.ID(1).Get(&record)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.EqualValues(t, "a", record.Name)
+	assert.EqualValues(t, 1, record.Version)
+	total, err := testEngine.Count(new(DryRunRecord))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+
+	_, err = testEngine.DryRun(func(session *xormplus.Session) error {
+		return nil
+	})
+	assert.EqualValues(t, xormplus.ErrNoSQLGenerated, err)
+
+	errOp := errors.New("op failed")
+	_, err = testEngine.DryRun(func(session *xormplus.Session) error {
+		return errOp
+	})
+	assert.EqualValues(t, errOp, err)
+}
+
+func TestDryRunClosures(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type DryRunSoft struct {
+		Id        int64
+		DeletedAt time.Time `xorm:"deleted"`
+	}
+	type DryRunHard struct {
+		Id        int64
+		DeletedAt time.Time
+	}
+	assertSync(t, new(DryRunSoft), new(DryRunHard))
+
+	_, err := testEngine.Insert(&DryRunHard{})
+	assert.NoError(t, err)
+
+	session := testEngine.NewSession()
+	defer session.Close()
+
+	_, err = session.ID(1).DryRun(func(session *xormplus.Session) error {
+		_, err := session.Delete(new(DryRunSoft))
+		return err
+	})
+	assert.NoError(t, err)
+
+	// the closures of the soft delete are not applied to the next operation
+	var hard DryRunHard
+	cnt, err := session.ID(1).Delete(&hard)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+	assert.True(t, hard.DeletedAt.IsZero())
+}
@@ -0,0 +1,71 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"testing"
+
+	"github.com/laixyz/xormplus"
+	"github.com/laixyz/xormplus/schemas"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplain(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	switch testEngine.Dialect().URI().DBType {
+	case schemas.SQLITE, schemas.MYSQL, schemas.POSTGRES, schemas.MSSQL:
+	default:
+		t.Skip()
+	}
+
+	type ExplainRecord struct {
+		Id    int64
+		Name  string `xorm:"index(explain_name)"`
+		Score int
+	}
+	assertSync(t, new(ExplainRecord))
+
+	var records []ExplainRecord
+	for i := 0; i < 10; i++ {
+		records = append(records, ExplainRecord{Name: "name", Score: i})
+	}
+	_, err := testEngine.Insert(records)
+	assert.NoError(t, err)
+
+	var indexName = "IDX_" + tableMapper.Obj2Table("ExplainRecord") + "_explain_name"
+	plan, err := testEngine.Where("name = ?", "name").Explain(func(session *xormplus.Session) error {
+		return session.Find(&records)
+	})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, plan.Raw)
+	assert.EqualValues(t, []interface{}{"name"}, plan.Args)
+	if testEngine.Dialect().URI().DBType == schemas.SQLITE {
+		// the other databases may scan the small table
+		assert.True(t, plan.UsesIndex(indexName), plan.Raw)
+		assert.EqualValues(t, 0, len(plan.FullScans()), plan.Raw)
+	}
+
+	plan, err = testEngine.Where("score > ?", 5).Explain(func(session *xormplus.Session) error {
+		return session.Find(&records)
+	})
+	assert.NoError(t, err)
+	assert.False(t, plan.UsesIndex(indexName), plan.Raw)
+	assert.EqualValues(t, []string{tableMapper.Obj2Table("ExplainRecord")}, plan.FullScans(), plan.Raw)
+
+	plan, err = testEngine.Explain(func(session *xormplus.Session) error {
+		_, err := session.Count(new(ExplainRecord))
+		return err
+	})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, plan.Nodes)
+
+	_, err = testEngine.NewSession().ExplainAnalyze(func(session *xormplus.Session) error {
+		return session.Find(&records)
+	})
+	if testEngine.Dialect().URI().DBType != schemas.POSTGRES {
+		assert.EqualValues(t, xormplus.ErrExplainNotSupported, err)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/laixyz/xormplus/builder"
+	"github.com/laixyz/xormplus/caches"
 	"github.com/laixyz/xormplus/internal/utils"
 	"github.com/laixyz/xormplus/names"
 
@@ -962,3 +964,122 @@ func TestDistinctAndCols(t *testing.T) {
 	assert.EqualValues(t, 1, len(names))
 	assert.EqualValues(t, "test", names[0])
 }
+
+func TestFindWithCTE(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type CteCategory struct {
+		Id       int64
+		ParentId int64
+		Name     string
+	}
+
+	assertSync(t, new(CteCategory))
+
+	var categories = []CteCategory{
+		{Id: 1, ParentId: 0, Name: "root"},
+		{Id: 2, ParentId: 1, Name: "books"},
+		{Id: 3, ParentId: 2, Name: "novels"},
+		{Id: 4, ParentId: 1, Name: "music"},
+		{Id: 5, ParentId: 0, Name: "other"},
+	}
+	cnt, err := testEngine.Insert(categories)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 5, cnt)
+
+	tableName := testEngine.TableName(new(CteCategory), true)
+	tree := builder.Select("id", "parent_id", "name").From(tableName).Where(builder.Eq{"id": 2}).
+		Union("all", builder.Select("c.id", "c.parent_id", "c.name").From(tableName+" c").
+			InnerJoin("tree", "c.parent_id = tree.id"))
+
+	var subTree []CteCategory
+	err = testEngine.WithRecursive("tree(id, parent_id, name)", tree).Table("tree").Asc("id").Find(&subTree)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, len(subTree))
+	assert.EqualValues(t, "books", subTree[0].Name)
+	assert.EqualValues(t, "novels", subTree[1].Name)
+
+	var names []string
+	err = testEngine.WithRecursive("tree(id, parent_id, name)", tree).Table("tree").
+		Iterate(new(CteCategory), func(i int, bean interface{}) error {
+			names = append(names, bean.(*CteCategory).Name)
+			return nil
+		})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, len(names))
+
+	roots := builder.Select("id").From(tableName).Where(builder.Eq{"parent_id": 0})
+	total, err := testEngine.With("roots", roots).Table(tableName).
+		Where("parent_id IN (SELECT id FROM roots)").Count()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, total)
+}
+
+func TestFindExistsAndInTuple(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type TupleUser struct {
+		Id   int64
+		Name string
+	}
+
+	type TupleOrder struct {
+		UserId int64 `xorm:"pk"`
+		Seq    int   `xorm:"pk"`
+		Amount int
+	}
+
+	assertSync(t, new(TupleUser), new(TupleOrder))
+
+	_, err := testEngine.Insert([]TupleUser{{Id: 1, Name: "a"}, {Id: 2, Name: "b"}, {Id: 3, Name: "c"}})
+	assert.NoError(t, err)
+	_, err = testEngine.Insert([]TupleOrder{
+		{UserId: 1, Seq: 1, Amount: 5},
+		{UserId: 1, Seq: 2, Amount: 20},
+		{UserId: 2, Seq: 1, Amount: 30},
+	})
+	assert.NoError(t, err)
+
+	userTable := testEngine.TableName(new(TupleUser), true)
+	orderTable := testEngine.TableName(new(TupleOrder), true)
+
+	var users []TupleUser
+	err = testEngine.Where(builder.Exists(builder.Select("1").From(orderTable).
+		Where(builder.Expr(orderTable + ".user_id = " + userTable + ".id").And(builder.Gt{"amount": 10})))).
+		Asc("id").Find(&users)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, len(users))
+	assert.EqualValues(t, 1, users[0].Id)
+	assert.EqualValues(t, 2, users[1].Id)
+
+	users = make([]TupleUser, 0)
+	err = testEngine.Where(builder.NotExists(builder.Select("1").From(orderTable).
+		Where(builder.Expr(orderTable + ".user_id = " + userTable + ".id")))).Find(&users)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, len(users))
+	assert.EqualValues(t, 3, users[0].Id)
+
+	users = make([]TupleUser, 0)
+	err = testEngine.Where(builder.Any("id", "=", builder.Select("user_id").From(orderTable))).Asc("id").Find(&users)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, len(users))
+
+	var orders []TupleOrder
+	err = testEngine.Where(builder.InTuple([]string{"user_id", "seq"}, [][]interface{}{{1, 2}, {2, 1}, {3, 1}})).
+		Asc("user_id").Find(&orders)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, len(orders))
+	assert.EqualValues(t, 20, orders[0].Amount)
+	assert.EqualValues(t, 30, orders[1].Amount)
+
+	// lookup the composite primary keys via cache
+	assert.NoError(t, testEngine.MapCacher(new(TupleOrder), caches.NewLRUCacher(caches.NewMemoryStore(), 1000)))
+	defer testEngine.MapCacher(new(TupleOrder), nil)
+
+	for i := 0; i < 2; i++ {
+		orders = make([]TupleOrder, 0)
+		err = testEngine.Where("amount > ?", 1).Asc("user_id", "seq").Find(&orders)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 3, len(orders))
+	}
+}
@@ -11,7 +11,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/laixyz/xormplus"
+	"github.com/laixyz/xormplus/builder"
 	"github.com/laixyz/xormplus/contexts"
+	"github.com/laixyz/xormplus/internal/json"
 	"github.com/laixyz/xormplus/schemas"
 
 	"github.com/stretchr/testify/assert"
@@ -750,3 +753,63 @@ func TestGetViaMapCond(t *testing.T) {
 	assert.NoError(t, err)
 	assert.False(t, has)
 }
+
+type countJSONHandler struct {
+	json.StdJSON
+	marshaled int
+}
+
+func (h *countJSONHandler) Marshal(v interface{}) ([]byte, error) {
+	h.marshaled++
+	return h.StdJSON.Marshal(v)
+}
+
+func TestJSONHandlerAndConds(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type JsonDoc struct {
+		Id      int64
+		Content map[string]interface{} `xorm:"json"`
+	}
+
+	assertSync(t, new(JsonDoc))
+
+	// the handler of the engine isn't used by the other engines which use the default one
+	engine := newIsolatedEngine(t)
+	handler := &countJSONHandler{}
+	engine.SetJSONHandler(handler)
+	defaultHandler := &countJSONHandler{}
+	xormplus.SetDefaultJSONHandler(defaultHandler)
+	defer xormplus.SetDefaultJSONHandler(nil)
+
+	_, err := engine.Insert(&JsonDoc{
+		Content: map[string]interface{}{"name": "xorm", "tags": []string{"go", "orm"}},
+	})
+	assert.NoError(t, err)
+	_, err = testEngine.Insert(&JsonDoc{
+		Content: map[string]interface{}{"name": "builder"},
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, handler.marshaled)
+	assert.EqualValues(t, 1, defaultHandler.marshaled)
+
+	if testEngine.Dialect().URI().DBType == schemas.SQLITE {
+		if _, err := testEngine.Exec("SELECT json('{}')"); err != nil {
+			t.Skip("JSON functions are not compiled into SQLite")
+		}
+	}
+
+	var docs []JsonDoc
+	assert.NoError(t, testEngine.Where(builder.JSONEq("content", "$.name", "xorm")).Find(&docs))
+	assert.EqualValues(t, 1, len(docs))
+	assert.EqualValues(t, "xorm", docs[0].Content["name"])
+
+	cnt, err := testEngine.Where(builder.JSONHasKey("content", "$.tags")).Count(new(JsonDoc))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+
+	cnt, err = engine.Where(builder.JSONContains("content", "$.tags", "orm")).Count(new(JsonDoc))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+	assert.EqualValues(t, 1, defaultHandler.marshaled)
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/laixyz/xormplus"
+	"github.com/laixyz/xormplus/builder"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -986,3 +987,83 @@ func TestInsertTwice(t *testing.T) {
 
 	assert.NoError(t, ssn.Commit())
 }
+
+func TestInsertSelect(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type InsertSelectSrc struct {
+		Id   int64
+		Name string
+		Age  int
+	}
+
+	type InsertSelectDst struct {
+		Id   int64
+		Name string
+		Age  int
+	}
+
+	assertSync(t, new(InsertSelectSrc), new(InsertSelectDst))
+
+	cnt, err := testEngine.Insert([]InsertSelectSrc{
+		{Name: "a", Age: 18},
+		{Name: "b", Age: 25},
+		{Name: "c", Age: 30},
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, cnt)
+
+	srcTable := testEngine.TableName(new(InsertSelectSrc), true)
+	cnt, err = testEngine.Cols("name", "age").InsertSelect(new(InsertSelectDst),
+		builder.Select("name", "age").From(srcTable).Where(builder.Gt{"age": 20}))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, cnt)
+
+	var dsts []InsertSelectDst
+	assert.NoError(t, testEngine.Asc("name").Find(&dsts))
+	assert.EqualValues(t, 2, len(dsts))
+	assert.EqualValues(t, "b", dsts[0].Name)
+	assert.EqualValues(t, 25, dsts[0].Age)
+	assert.EqualValues(t, "c", dsts[1].Name)
+
+	_, err = testEngine.InsertSelect(new(InsertSelectDst), nil)
+	assert.Error(t, err)
+}
+
+func TestBuilderInsertUpsert(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type BuilderStock struct {
+		Sku  string `xorm:"pk varchar(20)"`
+		Name string
+		Qty  int
+	}
+
+	assertSync(t, new(BuilderStock))
+
+	tableName := testEngine.TableName(new(BuilderStock), true)
+	dialect := string(testEngine.Dialect().URI().DBType)
+	res, err := testEngine.Exec(builder.Dialect(dialect).Insert().Into(tableName).Columns("sku", "name", "qty").
+		Values("a", "apple", 1).Values("b", "banana", 2))
+	assert.NoError(t, err)
+	cnt, err := res.RowsAffected()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, cnt)
+
+	_, err = testEngine.Exec(builder.Dialect(dialect).Insert().Into(tableName).Columns("sku", "name", "qty").
+		Values("a", "avocado", 5).Values("c", "cherry", 3).
+		OnConflict("sku").DoUpdate(builder.Eq{"name": builder.Excluded("name"), "qty": builder.Incr(10)}))
+	assert.NoError(t, err)
+
+	_, err = testEngine.Exec(builder.Dialect(dialect).Insert().Into(tableName).Columns("sku", "name", "qty").
+		Values("b", "blueberry", 9).OnConflict("sku").DoNothing())
+	assert.NoError(t, err)
+
+	var stocks []BuilderStock
+	assert.NoError(t, testEngine.Asc("sku").Find(&stocks))
+	assert.EqualValues(t, []BuilderStock{
+		{Sku: "a", Name: "avocado", Qty: 11},
+		{Sku: "b", Name: "banana", Qty: 2},
+		{Sku: "c", Name: "cherry", Qty: 3},
+	}, stocks)
+}
@@ -0,0 +1,147 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/laixyz/xormplus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeakTracker(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type LeakRecord struct {
+		Id   int64
+		Name string
+	}
+	assertSync(t, new(LeakRecord))
+
+	engine := newIsolatedEngine(t)
+	assert.Nil(t, engine.OpenSessions())
+
+	var mu sync.Mutex
+	var leaks []xormplus.Leak
+	tracker := engine.EnableLeakTracker(time.Hour).OnLeak(func(leak *xormplus.Leak) {
+		mu.Lock()
+		leaks = append(leaks, *leak)
+		mu.Unlock()
+	})
+
+	// the sessions closed automatically are not leaked
+	_, err := engine.Insert(&LeakRecord{Name: "a"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, len(engine.OpenSessions()))
+
+	sess := engine.NewSession()
+	sessions := engine.OpenSessions()
+	assert.EqualValues(t, 1, len(sessions))
+	assert.Contains(t, sessions[0].Stack, "session_leak_test.go")
+
+	rows, err := sess.Rows(new(LeakRecord))
+	assert.NoError(t, err)
+	sessions = engine.OpenSessions()
+	assert.EqualValues(t, 1, len(sessions[0].Rows))
+	assert.Contains(t, sessions[0].Rows[0].Stack, "session_leak_test.go")
+	assert.NoError(t, rows.Close())
+	assert.EqualValues(t, 0, len(engine.OpenSessions()[0].Rows))
+
+	assert.NoError(t, sess.Begin())
+	assert.True(t, engine.OpenSessions()[0].InTransaction)
+	assert.EqualValues(t, 0, len(tracker.Check()))
+	tracker.SetThreshold(time.Nanosecond)
+	found := tracker.Check()
+	assert.EqualValues(t, 1, len(found))
+	assert.EqualValues(t, xormplus.LeakUncommittedTx, found[0].Kind)
+	// a leak is reported once
+	assert.EqualValues(t, 0, len(tracker.Check()))
+	assert.NoError(t, sess.Commit())
+	assert.False(t, engine.OpenSessions()[0].InTransaction)
+	found = tracker.Check()
+	assert.EqualValues(t, 1, len(found))
+	assert.EqualValues(t, xormplus.LeakOpenTooLong, found[0].Kind)
+
+	assert.NoError(t, sess.Close())
+	assert.EqualValues(t, 0, len(engine.OpenSessions()))
+
+	// the transaction is measured from its beginning instead of the creation of the session,
+	// the leaks are read from OnLeak since they could be reported by the background check
+	kinds := func(id uint64) map[string]bool {
+		mu.Lock()
+		defer mu.Unlock()
+		found := make(map[string]bool)
+		for _, leak := range leaks {
+			if leak.Session.ID == id {
+				found[leak.Kind] = true
+			}
+		}
+		return found
+	}
+	tracker.SetThreshold(100 * time.Millisecond)
+	sess = engine.NewSession()
+	id := engine.OpenSessions()[0].ID
+	time.Sleep(150 * time.Millisecond)
+	assert.NoError(t, sess.Begin())
+	assert.False(t, engine.OpenSessions()[0].TxStarted.IsZero())
+	tracker.Check()
+	assert.EqualValues(t, map[string]bool{xormplus.LeakOpenTooLong: true}, kinds(id))
+	time.Sleep(150 * time.Millisecond)
+	tracker.Check()
+	assert.EqualValues(t, map[string]bool{xormplus.LeakOpenTooLong: true, xormplus.LeakUncommittedTx: true}, kinds(id))
+	assert.NoError(t, sess.Rollback())
+	assert.True(t, engine.OpenSessions()[0].TxStarted.IsZero())
+	assert.NoError(t, sess.Close())
+
+	// the session garbage collected without being closed is reported by the finalizer
+	tracker.SetThreshold(0)
+	func() {
+		engine.NewSession()
+	}()
+	var collected bool
+	for i := 0; i < 100 && !collected; i++ {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		for _, leak := range leaks {
+			if leak.Kind == xormplus.LeakGarbageCollected {
+				collected = true
+			}
+		}
+		mu.Unlock()
+	}
+	assert.True(t, collected)
+	assert.EqualValues(t, 0, len(engine.OpenSessions()))
+
+	mu.Lock()
+	assert.EqualValues(t, 5, len(leaks))
+	mu.Unlock()
+}
+
+func TestLeakTrackerEnabledConcurrently(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	engine := newIsolatedEngine(t)
+
+	// the tracker could be enabled while the sessions are created
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				sess := engine.NewSession()
+				assert.NoError(t, sess.Close())
+			}
+		}()
+	}
+	tracker := engine.EnableLeakTracker(time.Hour)
+	wg.Wait()
+	assert.True(t, tracker == engine.EnableLeakTracker(time.Hour))
+	assert.EqualValues(t, 0, len(engine.OpenSessions()))
+}
@@ -0,0 +1,84 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"testing"
+	"time"
+
+	"github.com/laixyz/xormplus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSafeMode(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type SafeModeRecord struct {
+		Id      int64
+		Name    string
+		Deleted time.Time `xorm:"deleted"`
+	}
+	assertSync(t, new(SafeModeRecord))
+
+	engine := newIsolatedEngine(t)
+	engine.SetSafeMode(true)
+
+	_, err := engine.Insert(&SafeModeRecord{Name: "a"}, &SafeModeRecord{Name: "b"}, &SafeModeRecord{Name: "c"})
+	assert.NoError(t, err)
+
+	// the soft deleted condition is not a condition
+	_, err = engine.Update(&SafeModeRecord{Name: "d"})
+	assert.EqualValues(t, xormplus.ErrFullTableRejected, err)
+	_, err = engine.Delete(&SafeModeRecord{})
+	assert.EqualValues(t, xormplus.ErrFullTableRejected, err)
+	_, err = engine.Where("1=1").Delete(&SafeModeRecord{})
+	assert.NoError(t, err)
+	_, err = engine.Unscoped().Delete(&SafeModeRecord{})
+	assert.EqualValues(t, xormplus.ErrNeedDeletedCond, err)
+
+	cnt, err := engine.ID(1).Update(&SafeModeRecord{Name: "d"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, cnt)
+	cnt, err = engine.Unscoped().Where("id > ?", 0).Update(&SafeModeRecord{Name: "d"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, cnt)
+	cnt, err = engine.Unscoped().AllowFullTable().Update(&SafeModeRecord{Name: "e"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, cnt)
+
+	// AllowFullTable is only for the next statement
+	session := engine.NewSession()
+	defer session.Close()
+	_, err = session.Unscoped().AllowFullTable().Update(&SafeModeRecord{Name: "f"})
+	assert.NoError(t, err)
+	_, err = session.Unscoped().Update(&SafeModeRecord{Name: "f"})
+	assert.EqualValues(t, xormplus.ErrFullTableRejected, err)
+	_, err = session.SafeMode(false).Unscoped().Update(&SafeModeRecord{Name: "f"})
+	assert.NoError(t, err)
+
+	tableName := engine.TableName(new(SafeModeRecord), true)
+	_, err = engine.Exec("UPDATE " + tableName + " SET name = 'g'")
+	assert.EqualValues(t, xormplus.ErrFullTableRejected, err)
+	_, err = engine.Exec("DELETE FROM " + tableName + " -- WHERE id = 1")
+	assert.EqualValues(t, xormplus.ErrFullTableRejected, err)
+	_, err = engine.Exec("DELETE FROM "+tableName+" WHERE id IN (SELECT id FROM "+tableName+" WHERE name = ?)", "x")
+	assert.NoError(t, err)
+
+	// the selects without limit on the large tables
+	engine.SetLargeTables(tableName)
+	var records []SafeModeRecord
+	assert.EqualValues(t, xormplus.ErrSelectWithoutLimit, engine.Unscoped().Find(&records))
+	assert.NoError(t, engine.Unscoped().Limit(10).Find(&records))
+	assert.EqualValues(t, 3, len(records))
+	assert.NoError(t, engine.Unscoped().AllowFullTable().Find(&records))
+	has, err := engine.Unscoped().Get(new(SafeModeRecord))
+	assert.NoError(t, err)
+	assert.True(t, has)
+	cnt, err = engine.Unscoped().Count(new(SafeModeRecord))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, cnt)
+	_, err = engine.QueryString("SELECT * FROM " + tableName)
+	assert.EqualValues(t, xormplus.ErrSelectWithoutLimit, err)
+}
@@ -9,6 +9,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/laixyz/xormplus/builder"
+	"github.com/laixyz/xormplus/schemas"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -396,3 +398,76 @@ func TestSync2_Default(t *testing.T) {
 	assertSync(t, new(TestSync2Default))
 	assert.NoError(t, testEngine.Sync2(new(TestSync2Default)))
 }
+
+type SyncFulltext struct {
+	Id          int64
+	Title       string `xorm:"fulltext(search)"`
+	Description string `xorm:"text fulltext(search)"`
+}
+
+func TestSync2_Fulltext(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+	assert.NoError(t, testEngine.DropTables(new(SyncFulltext)))
+
+	if testEngine.Dialect().URI().DBType == schemas.SQLITE {
+		if _, err := testEngine.Exec("CREATE VIRTUAL TABLE fts5_probe USING fts5(a)"); err != nil {
+			t.Skip("FTS5 is not compiled into SQLite")
+		}
+		_, err := testEngine.Exec("DROP TABLE fts5_probe")
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, testEngine.Sync2(new(SyncFulltext)))
+	// synchronize again should keep the full-text index
+	assert.NoError(t, testEngine.Sync2(new(SyncFulltext)))
+
+	tableInfo, err := testEngine.TableInfo(new(SyncFulltext))
+	assert.NoError(t, err)
+	tables, err := testEngine.DBMetas()
+	assert.NoError(t, err)
+	var found bool
+	for _, table := range tables {
+		if table.Name != tableInfo.Name {
+			continue
+		}
+		found = true
+		index := table.Indexes["search"]
+		if assert.NotNil(t, index) {
+			assert.EqualValues(t, schemas.FulltextType, index.Type)
+			assert.EqualValues(t, []string{"title", "description"}, index.Cols)
+		}
+	}
+	assert.True(t, found)
+
+	_, err = testEngine.Insert([]SyncFulltext{
+		{Title: "xorm", Description: "simple and powerful orm for go"},
+		{Title: "builder", Description: "sql builder for go"},
+		{Title: "gitea", Description: "git with a cup of tea"},
+	})
+	assert.NoError(t, err)
+
+	tableName := testEngine.TableName(new(SyncFulltext), true)
+	match := builder.Match([]string{"title", "description"}, "go", builder.MatchNatural).Index(tableName, "search")
+
+	var docs []SyncFulltext
+	assert.NoError(t, testEngine.Where(match).Asc("id").Find(&docs))
+	assert.EqualValues(t, 2, len(docs))
+
+	// the full-text index follows the updates and deletions
+	_, err = testEngine.ID(1).Update(&SyncFulltext{Description: "simple and powerful orm"})
+	assert.NoError(t, err)
+	_, err = testEngine.ID(3).Update(&SyncFulltext{Description: "painless self-hosted git service written in go"})
+	assert.NoError(t, err)
+	_, err = testEngine.ID(2).Delete(new(SyncFulltext))
+	assert.NoError(t, err)
+
+	docs = docs[:0]
+	assert.NoError(t, testEngine.Where(match).Find(&docs))
+	assert.EqualValues(t, 1, len(docs))
+	assert.EqualValues(t, "gitea", docs[0].Title)
+
+	cnt, err := testEngine.Where(builder.Match([]string{"title", "description"}, "self-hosted git", builder.MatchPhrase).
+		Index(tableName, "search")).Count(new(SyncFulltext))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+}
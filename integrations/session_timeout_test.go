@@ -0,0 +1,131 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/laixyz/xormplus"
+	"github.com/laixyz/xormplus/schemas"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryTimeout(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type QueryTimeoutRecord struct {
+		Id   int64
+		Name string
+	}
+	assertSync(t, new(QueryTimeoutRecord))
+
+	engine := newIsolatedEngine(t)
+
+	// the deadline is exceeded before the statements are executed
+	engine.SetQueryTimeout(time.Nanosecond)
+	_, err := engine.Insert(&QueryTimeoutRecord{Name: "a"})
+	assert.True(t, xormplus.IsTimeout(err), "%v", err)
+	var records []QueryTimeoutRecord
+	err = engine.Find(&records)
+	assert.True(t, xormplus.IsTimeout(err), "%v", err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "%v", err)
+
+	// the timeout of the session overrides the one of the engine
+	_, err = engine.Timeout(time.Minute).Insert(&QueryTimeoutRecord{Name: "a"})
+	assert.NoError(t, err)
+	engine.SetQueryTimeout(0)
+	records = nil
+	assert.NoError(t, engine.Find(&records))
+	assert.EqualValues(t, 1, len(records))
+
+	// the other failures are not timeouts
+	_, err = engine.Exec("SELECT * FROM not_exist_table")
+	assert.Error(t, err)
+	assert.False(t, xormplus.IsTimeout(err))
+}
+
+func TestSchemaTimeout(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type SchemaTimeoutRecord struct {
+		Id   int64
+		Name string
+	}
+
+	// the statements of the dialects are limited by the timeout too
+	engine := newIsolatedEngine(t)
+	engine.SetQueryTimeout(time.Nanosecond)
+	err := engine.Ping()
+	assert.True(t, xormplus.IsTimeout(err), "%v", err)
+	_, err = engine.IsTableExist(new(SchemaTimeoutRecord))
+	assert.True(t, xormplus.IsTimeout(err), "%v", err)
+	err = engine.Sync2(new(SchemaTimeoutRecord))
+	assert.True(t, xormplus.IsTimeout(err), "%v", err)
+
+	engine.SetQueryTimeout(time.Minute)
+	assert.NoError(t, engine.Ping())
+	assert.NoError(t, engine.Sync2(new(SchemaTimeoutRecord)))
+	exist, err := engine.IsTableExist(new(SchemaTimeoutRecord))
+	assert.NoError(t, err)
+	assert.True(t, exist)
+}
+
+func TestTransactionTimeout(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type TxTimeoutRecord struct {
+		Id   int64
+		Name string
+	}
+	assertSync(t, new(TxTimeoutRecord))
+
+	sess := testEngine.NewSession().Timeout(100 * time.Millisecond)
+	defer sess.Close()
+	assert.NoError(t, sess.Begin())
+	_, err := sess.Insert(&TxTimeoutRecord{Name: "a"})
+	assert.NoError(t, err)
+
+	time.Sleep(200 * time.Millisecond)
+	_, err = sess.Insert(&TxTimeoutRecord{Name: "b"})
+	assert.True(t, xormplus.IsTimeout(err), "%v", err)
+	assert.NoError(t, sess.Rollback())
+
+	cnt, err := testEngine.Count(new(TxTimeoutRecord))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, cnt)
+
+	// the session could begin another transaction after the timeout
+	assert.NoError(t, sess.Begin())
+	_, err = sess.Insert(&TxTimeoutRecord{Name: "c"})
+	assert.NoError(t, err)
+	assert.NoError(t, sess.Commit())
+
+	cnt, err = testEngine.Count(new(TxTimeoutRecord))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+}
+
+func TestRowsTimeout(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	switch testEngine.Dialect().URI().DBType {
+	case schemas.SQLITE, schemas.POSTGRES:
+	default:
+		t.Skip()
+		return
+	}
+
+	// the deadline is exceeded while the rows are scanned
+	const sqlStr = "WITH RECURSIVE cnt(x) AS (SELECT 1 UNION ALL SELECT x+1 FROM cnt) SELECT x FROM cnt"
+	_, err := testEngine.Timeout(50 * time.Millisecond).QueryInterface(sqlStr)
+	assert.True(t, xormplus.IsTimeout(err), "%v", err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "%v", err)
+
+	_, err = testEngine.Timeout(50 * time.Millisecond).QueryString(sqlStr)
+	assert.True(t, xormplus.IsTimeout(err), "%v", err)
+}
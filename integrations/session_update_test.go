@@ -11,9 +11,11 @@ import (
 	"time"
 
 	"github.com/laixyz/xormplus"
+	"github.com/laixyz/xormplus/dialects"
 	"github.com/laixyz/xormplus/internal/statements"
 	"github.com/laixyz/xormplus/internal/utils"
 	"github.com/laixyz/xormplus/names"
+	"github.com/laixyz/xormplus/schemas"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -224,6 +226,28 @@ func TestForUpdate(t *testing.T) {
 	wg.Wait()
 }
 
+func TestForUpdateSkipLocked(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+	assert.NoError(t, setupForUpdate(testEngine))
+
+	session := testEngine.NewSession()
+	defer session.Close()
+	assert.NoError(t, session.Begin())
+
+	var fList []ForUpdate
+	err := session.Where("id > ?", 1).ForUpdate().SkipLocked().Asc("id").Find(&fList)
+	if testEngine.Dialect().URI().DBType == schemas.SQLITE {
+		assert.EqualValues(t, dialects.ErrUnsupportedLockMode, err)
+
+		// a bare FOR UPDATE is accepted since SQLite locks the whole database
+		fList = make([]ForUpdate, 0)
+		err = session.Where("id > ?", 1).ForUpdate().Asc("id").Find(&fList)
+	}
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, len(fList))
+	assert.NoError(t, session.Commit())
+}
+
 func TestWithIn(t *testing.T) {
 	type temp3 struct {
 		Id   int64  `xorm:"Id pk autoincr"`
@@ -1400,3 +1424,70 @@ func TestNilFromDB(t *testing.T) {
 	assert.NoError(t, err)
 	assert.EqualValues(t, 1, cnt)
 }
+
+func TestUpdateJoin(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	// the columns version and score of the joined table are the same as the updated one
+	type UpdateJoinGroup struct {
+		Id      int64
+		Name    string
+		Version int
+		Score   int
+	}
+
+	type UpdateJoinUser struct {
+		Id      int64
+		Name    string
+		GroupId int64
+		Version int `xorm:"version"`
+		Score   int
+	}
+
+	assertSync(t, new(UpdateJoinGroup), new(UpdateJoinUser))
+
+	var groups = []UpdateJoinGroup{{Name: "admin"}, {Name: "guest"}}
+	for i := range groups {
+		_, err := testEngine.Insert(&groups[i])
+		assert.NoError(t, err)
+	}
+	cnt, err := testEngine.Insert([]UpdateJoinUser{
+		{Name: "a", GroupId: groups[0].Id},
+		{Name: "b", GroupId: groups[1].Id},
+		{Name: "c", GroupId: groups[0].Id},
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, cnt)
+
+	userTable := testEngine.TableName(new(UpdateJoinUser), true)
+	groupTable := testEngine.TableName(new(UpdateJoinGroup), true)
+
+	cnt, err = testEngine.Table(userTable).
+		Join("INNER", groupTable, groupTable+".id = "+userTable+".group_id").
+		Where(groupTable+".name = ?", "admin").
+		Update(&UpdateJoinUser{Name: "updated", Version: 1})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, cnt)
+
+	total, err := testEngine.Where("name = ?", "updated").And("version = ?", 2).Count(new(UpdateJoinUser))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, total)
+
+	cnt, err = testEngine.Table(userTable).
+		Join("INNER", groupTable, groupTable+".id = "+userTable+".group_id").
+		Where(groupTable+".name = ?", "guest").
+		Incr("score", 3).
+		Update(&UpdateJoinUser{Version: 1})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+
+	total, err = testEngine.Where("score = ?", 3).Count(new(UpdateJoinUser))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+
+	_, err = testEngine.Table(userTable).
+		Join("INNER", groupTable, groupTable+".id = "+userTable+".group_id").
+		Limit(1).
+		Update(&UpdateJoinUser{Name: "updated"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,96 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/laixyz/xormplus/log"
+	"github.com/laixyz/xormplus/schemas"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlowQueryHook(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type SlowQueryRecord struct {
+		Id   int64
+		Name string
+	}
+	assertSync(t, new(SlowQueryRecord))
+
+	// hooks can't be removed, so a new engine is used
+	engine := newIsolatedEngine(t)
+
+	var buf bytes.Buffer
+	engine.SetLogger(log.NewSimpleLogger(&buf))
+	hook := engine.AddSlowQueryHook(0, true).SetRateLimit(2, time.Hour)
+
+	var records []SlowQueryRecord
+	assert.NoError(t, engine.Where("id > ?", 0).Find(&records))
+	hook.Wait()
+	logs := buf.String()
+	assert.Contains(t, logs, "[SLOW SQL]")
+	assert.Contains(t, logs, "slow_query_test.go:")
+	assert.NotContains(t, logs, "explain failed")
+	if testEngine.Dialect().URI().DBType == schemas.SQLITE {
+		assert.Contains(t, logs, "SEARCH on "+tableMapper.Obj2Table("SlowQueryRecord"))
+	}
+
+	_, err := engine.Count(new(SlowQueryRecord))
+	assert.NoError(t, err)
+	_, err = engine.Count(new(SlowQueryRecord))
+	assert.NoError(t, err)
+	hook.Wait()
+	assert.EqualValues(t, 2, strings.Count(buf.String(), "[SLOW SQL]"))
+
+	// the statement doesn't wait for the explaining which needs another connection
+	engine2 := newIsolatedEngine(t)
+	engine2.SetMaxOpenConns(1)
+
+	var buf2 bytes.Buffer
+	engine2.SetLogger(log.NewSimpleLogger(&buf2))
+	hook = engine2.AddSlowQueryHook(0, true).SetExplainTimeout(time.Second)
+
+	var done = make(chan error)
+	go func() {
+		var records []SlowQueryRecord
+		done <- engine2.Where("id > ?", 0).Find(&records)
+	}()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the statement is blocked by the explaining")
+	}
+	hook.Wait()
+	assert.Contains(t, buf2.String(), "[SLOW SQL]")
+}
+
+func TestSlowQueryHookEngineGroup(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type SlowQueryGroupRecord struct {
+		Id   int64
+		Name string
+	}
+	assertSync(t, new(SlowQueryGroupRecord))
+
+	eg := newIsolatedEngineGroup(t)
+	var buf bytes.Buffer
+	eg.SetLogger(log.NewSimpleLogger(&buf))
+	hook := eg.AddSlowQueryHook(0, false)
+
+	// the queries of the group sessions are executed by the slave
+	sess := eg.NewSession()
+	defer sess.Close()
+	var records []SlowQueryGroupRecord
+	assert.NoError(t, sess.Where("id > ?", 0).Find(&records))
+	hook.Wait()
+	assert.Contains(t, buf.String(), "[SLOW SQL]")
+}
@@ -0,0 +1,86 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/laixyz/xormplus/contexts"
+	"github.com/laixyz/xormplus/sqlcomment"
+	"github.com/stretchr/testify/assert"
+)
+
+type sqlRecorderHook struct {
+	sqls []string
+}
+
+func (h *sqlRecorderHook) BeforeProcess(c *contexts.ContextHook) (context.Context, error) {
+	return c.Ctx, nil
+}
+
+func (h *sqlRecorderHook) AfterProcess(c *contexts.ContextHook) error {
+	h.sqls = append(h.sqls, c.SQL)
+	return nil
+}
+
+func TestSQLCommenter(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type SqlCommentRecord struct {
+		Id   int64
+		Name string
+	}
+	assertSync(t, new(SqlCommentRecord))
+
+	engine := newIsolatedEngine(t)
+
+	hook := new(sqlRecorderHook)
+	engine.AddHook(hook)
+	commenter := sqlcomment.NewCommenter("test")
+	engine.SetSQLCommenter(commenter)
+
+	ctx := sqlcomment.WithTraceID(sqlcomment.WithRoute(context.Background(), "/records"), "t1")
+	_, err := engine.Context(ctx).Insert(&SqlCommentRecord{Name: "a"})
+	assert.NoError(t, err)
+	var records []SqlCommentRecord
+	assert.NoError(t, engine.Context(ctx).Find(&records))
+	assert.EqualValues(t, 1, len(records))
+	if assert.EqualValues(t, 2, len(hook.sqls)) {
+		for _, sqlStr := range hook.sqls {
+			assert.Contains(t, sqlStr, " /* app=test,route=/records,trace_id=t1,file=")
+			assert.Contains(t, sqlStr, "sql_comment_test.go:")
+		}
+	}
+
+	// the prepared statements are cached by the SQL without comment
+	session := engine.NewSession().Prepare()
+	defer session.Close()
+	for i, traceID := range []string{"t2", "t3"} {
+		hook.sqls = nil
+		session.Context(sqlcomment.WithTraceID(context.Background(), traceID))
+		_, err = session.Where("id > ?", 0).Count(new(SqlCommentRecord))
+		assert.NoError(t, err)
+		if i == 0 {
+			assert.EqualValues(t, 2, len(hook.sqls))
+			assert.EqualValues(t, "PREPARE", hook.sqls[0])
+		} else {
+			assert.EqualValues(t, 1, len(hook.sqls))
+		}
+		assert.NotContains(t, hook.sqls[len(hook.sqls)-1], "/*")
+	}
+
+	// the prepared statements keep the comments when they are prepared
+	commenter.SetCommentPrepared(true)
+	session = engine.NewSession().Prepare()
+	defer session.Close()
+	for _, traceID := range []string{"t4", "t5"} {
+		hook.sqls = nil
+		session.Context(sqlcomment.WithTraceID(context.Background(), traceID))
+		_, err = session.Where("id > ?", 0).Count(new(SqlCommentRecord))
+		assert.NoError(t, err)
+		assert.Contains(t, hook.sqls[len(hook.sqls)-1], "trace_id=t4")
+	}
+}
@@ -168,6 +168,32 @@ func PrepareEngine() error {
 	return createEngine(dbType, connString)
 }
 
+// newIsolatedEngine creates an engine of the tested database with the mappers of testEngine,
+// it's used by the tests which add hooks or change the settings which can't be restored.
+// The engine is closed when the test finishes.
+func newIsolatedEngine(t *testing.T) *xormplus.Engine {
+	engine, err := xormplus.NewEngine(dbType, connString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		engine.Close()
+	})
+	engine.SetMapper(testEngine.GetColumnMapper())
+	engine.SetTableMapper(testEngine.GetTableMapper())
+	return engine
+}
+
+// newIsolatedEngineGroup creates an engine group of two new engines, the queries out of
+// transactions are executed by the slave
+func newIsolatedEngineGroup(t *testing.T) *xormplus.EngineGroup {
+	eg, err := xormplus.NewEngineGroup(newIsolatedEngine(t), []*xormplus.Engine{newIsolatedEngine(t)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return eg
+}
+
 func MainTest(m *testing.M) {
 	flag.Parse()
 
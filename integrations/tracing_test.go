@@ -0,0 +1,89 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/laixyz/xormplus/tracing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionTracing(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type TracingRecord struct {
+		Id   int64
+		Name string
+	}
+	assertSync(t, new(TracingRecord))
+
+	// hooks can't be removed, so a new engine is used
+	engine := newIsolatedEngine(t)
+
+	recorder := tracing.NewRecorder()
+	engine.AddTracer(recorder)
+
+	ctx, root := recorder.Start(context.Background(), "request")
+	session := engine.NewSession().Context(ctx)
+	defer session.Close()
+	assert.NoError(t, session.Begin())
+	_, err := session.Insert(&TracingRecord{Name: "a"})
+	assert.NoError(t, err)
+	assert.NoError(t, session.Commit())
+	_, err = session.Count(new(TracingRecord))
+	assert.NoError(t, err)
+	root.End()
+
+	spans := recorder.Spans()
+	var tx, insert, count *tracing.RecordedSpan
+	for i, span := range spans {
+		assert.True(t, span.Ended, span.Name)
+		switch span.Name {
+		case tracing.SpanTransaction:
+			tx = &spans[i]
+		case "INSERT":
+			insert = &spans[i]
+		case "SELECT":
+			count = &spans[i]
+		}
+	}
+	if assert.NotNil(t, tx) && assert.NotNil(t, insert) && assert.NotNil(t, count) {
+		assert.EqualValues(t, spans[0].ID, tx.ParentID)
+		assert.EqualValues(t, "commit", tx.Attributes[tracing.AttrTxStatus])
+		assert.EqualValues(t, string(testEngine.Dialect().URI().DBType), tx.Attributes[tracing.AttrSystem])
+		assert.EqualValues(t, tx.ID, insert.ParentID)
+		assert.EqualValues(t, 1, insert.Attributes[tracing.AttrRowsAffected])
+		// the statements after the transaction are not the children of the transaction
+		assert.EqualValues(t, spans[0].ID, count.ParentID)
+	}
+}
+
+func TestTracingEngineGroup(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type TracingGroupRecord struct {
+		Id   int64
+		Name string
+	}
+	assertSync(t, new(TracingGroupRecord))
+
+	eg := newIsolatedEngineGroup(t)
+	recorder := tracing.NewRecorder()
+	eg.AddTracer(recorder)
+
+	// the queries of the group sessions are executed by the slave
+	sess := eg.NewSession()
+	defer sess.Close()
+	_, err := sess.Count(new(TracingGroupRecord))
+	assert.NoError(t, err)
+
+	spans := recorder.Spans()
+	if assert.EqualValues(t, 1, len(spans)) {
+		assert.EqualValues(t, "SELECT", spans[0].Name)
+		assert.True(t, spans[0].Ended)
+	}
+}
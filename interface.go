@@ -10,12 +10,19 @@ import (
 	"reflect"
 	"time"
 
+	"github.com/laixyz/xormplus/admission"
+	"github.com/laixyz/xormplus/builder"
 	"github.com/laixyz/xormplus/caches"
 	"github.com/laixyz/xormplus/contexts"
 	"github.com/laixyz/xormplus/dialects"
 	"github.com/laixyz/xormplus/log"
+	"github.com/laixyz/xormplus/metrics"
 	"github.com/laixyz/xormplus/names"
+	"github.com/laixyz/xormplus/nplusone"
+	"github.com/laixyz/xormplus/querystats"
 	"github.com/laixyz/xormplus/schemas"
+	"github.com/laixyz/xormplus/sqlcomment"
+	"github.com/laixyz/xormplus/tracing"
 )
 
 // Interface defines the interface which Engine, EngineGroup and Session will implementate.
@@ -33,8 +40,10 @@ type Interface interface {
 	Delete(interface{}) (int64, error)
 	Distinct(columns ...string) *Session
 	DropIndexes(bean interface{}) error
+	DryRun(op func(*Session) error) (*DryRunSQL, error)
 	Exec(sqlOrArgs ...interface{}) (sql.Result, error)
 	Exist(bean ...interface{}) (bool, error)
+	Explain(op func(*Session) error) (*QueryPlan, error)
 	Find(interface{}, ...interface{}) error
 	FindAndCount(interface{}, ...interface{}) (int64, error)
 	Get(interface{}) (bool, error)
@@ -44,6 +53,7 @@ type Interface interface {
 	Incr(column string, arg ...interface{}) *Session
 	Insert(...interface{}) (int64, error)
 	InsertOne(interface{}) (int64, error)
+	InsertSelect(interface{}, *builder.Builder) (int64, error)
 	IsTableEmpty(bean interface{}) (bool, error)
 	IsTableExist(beanOrTableName interface{}) (bool, error)
 	Iterate(interface{}, IterFunc) error
@@ -55,6 +65,7 @@ type Interface interface {
 	Omit(columns ...string) *Session
 	OrderBy(order string) *Session
 	Ping() error
+	Priority(p admission.Priority) *Session
 	Query(sqlOrArgs ...interface{}) (resultsSlice []map[string][]byte, err error)
 	QueryInterface(sqlOrArgs ...interface{}) ([]map[string]interface{}, error)
 	QueryString(sqlOrArgs ...interface{}) ([]map[string]string, error)
@@ -67,10 +78,13 @@ type Interface interface {
 	Sums(bean interface{}, colNames ...string) ([]float64, error)
 	SumsInt(bean interface{}, colNames ...string) ([]int64, error)
 	Table(tableNameOrBean interface{}) *Session
+	Timeout(timeout time.Duration) *Session
 	Unscoped() *Session
 	Update(bean interface{}, condiBeans ...interface{}) (int64, error)
 	UseBool(...string) *Session
 	Where(interface{}, ...interface{}) *Session
+	With(string, *builder.Builder) *Session
+	WithRecursive(string, *builder.Builder) *Session
 }
 
 // EngineInterface defines the interface which Engine, EngineGroup will implementate.
@@ -102,6 +116,7 @@ type EngineInterface interface {
 	SetConnMaxLifetime(time.Duration)
 	SetColumnMapper(names.Mapper)
 	SetDefaultCacher(caches.Cacher)
+	SetJSONHandler(JSONHandler)
 	SetLogger(logger interface{})
 	SetLogLevel(log.LogLevel)
 	SetMapper(names.Mapper)
@@ -113,6 +128,18 @@ type EngineInterface interface {
 	SetTZDatabase(tz *time.Location)
 	SetTZLocation(tz *time.Location)
 	AddHook(hook contexts.Hook)
+	AddMetrics(collector metrics.Collector)
+	AddTracer(tracer tracing.Tracer)
+	EnableLeakTracker(threshold time.Duration) *LeakTracker
+	EnableNPlusOneDetector(threshold int) *nplusone.Detector
+	EnableQueryStats() *querystats.Registry
+	OpenSessions() []OpenSession
+	QueryStats() []querystats.QueryStat
+	SetAdmissionController(controller *admission.Controller)
+	SetLargeTables(tableNames ...string)
+	SetQueryTimeout(timeout time.Duration)
+	SetSafeMode(enable bool)
+	SetSQLCommenter(commenter *sqlcomment.Commenter)
 	ShowSQL(show ...bool)
 	Sync(...interface{}) error
 	Sync2(...interface{}) error
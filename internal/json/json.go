@@ -4,7 +4,10 @@
 
 package json
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"sync/atomic"
+)
 
 // JSONInterface represents an interface to handle json data
 type JSONInterface interface {
@@ -13,10 +16,43 @@ type JSONInterface interface {
 }
 
 var (
-	// DefaultJSONHandler default json handler
-	DefaultJSONHandler JSONInterface = StdJSON{}
+	// DefaultJSONHandler default json handler, it delegates to the handler set by
+	// SetDefaultJSONHandler
+	DefaultJSONHandler JSONInterface = defaultJSON{}
+
+	handler atomic.Value
 )
 
+// handlerHolder keeps the concrete type stored in the atomic value consistent
+type handlerHolder struct {
+	JSONInterface
+}
+
+func init() {
+	handler.Store(handlerHolder{StdJSON{}})
+}
+
+// SetDefaultJSONHandler replaces the handler used by DefaultJSONHandler, it's safe
+// to be called concurrently with marshaling and unmarshaling
+func SetDefaultJSONHandler(h JSONInterface) {
+	if h == nil {
+		h = StdJSON{}
+	}
+	handler.Store(handlerHolder{h})
+}
+
+type defaultJSON struct{}
+
+// Marshal implements JSONInterface
+func (defaultJSON) Marshal(v interface{}) ([]byte, error) {
+	return handler.Load().(handlerHolder).Marshal(v)
+}
+
+// Unmarshal implements JSONInterface
+func (defaultJSON) Unmarshal(data []byte, v interface{}) error {
+	return handler.Load().(handlerHolder).Unmarshal(data, v)
+}
+
 // StdJSON implements JSONInterface via encoding/json
 type StdJSON struct{}
 
@@ -70,6 +70,10 @@ func (exprs *exprParams) WriteArgs(w *builder.BytesWriter) error {
 			if _, err := w.WriteString(")"); err != nil {
 				return err
 			}
+		case builder.Cond:
+			if err := arg.WriteTo(w); err != nil {
+				return err
+			}
 		case string:
 			if arg == "" {
 				arg = "''"
@@ -27,7 +27,7 @@ func (statement *Statement) writeInsertOutput(buf *strings.Builder, table *schem
 // GenInsertSQL generates insert beans SQL
 func (statement *Statement) GenInsertSQL(colNames []string, args []interface{}) (string, []interface{}, error) {
 	var (
-		buf       = builder.NewWriter()
+		buf       = statement.newWriter()
 		exprs     = statement.ExprColumns
 		table     = statement.RefTable
 		tableName = statement.TableName()
@@ -143,7 +143,7 @@ func (statement *Statement) GenInsertSQL(colNames []string, args []interface{})
 // GenInsertMapSQL generates insert map SQL
 func (statement *Statement) GenInsertMapSQL(columns []string, args []interface{}) (string, []interface{}, error) {
 	var (
-		buf       = builder.NewWriter()
+		buf       = statement.newWriter()
 		exprs     = statement.ExprColumns
 		tableName = statement.TableName()
 	)
@@ -205,3 +205,50 @@ func (statement *Statement) GenInsertMapSQL(columns []string, args []interface{}
 
 	return buf.String(), buf.Args(), nil
 }
+
+// GenInsertSelectSQL generates "INSERT INTO ... SELECT ..." SQL, the inserted columns
+// are the columns indicated by Cols
+func (statement *Statement) GenInsertSelectSQL(query *builder.Builder) (string, []interface{}, error) {
+	if query == nil {
+		return "", nil, ErrUnSupportedSQLType
+	}
+
+	tableName := statement.TableName()
+	if len(tableName) <= 0 {
+		return "", nil, ErrTableNotFound
+	}
+
+	subSQL, subArgs, err := statement.GenCondSQL(query)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var buf strings.Builder
+	if _, err := buf.WriteString("INSERT INTO "); err != nil {
+		return "", nil, err
+	}
+	if err := statement.dialect.Quoter().QuoteTo(&buf, tableName); err != nil {
+		return "", nil, err
+	}
+
+	if len(statement.ColumnMap) > 0 {
+		if _, err := buf.WriteString(" ("); err != nil {
+			return "", nil, err
+		}
+		if err := statement.dialect.Quoter().JoinWrite(&buf, statement.ColumnMap, ","); err != nil {
+			return "", nil, err
+		}
+		if _, err := buf.WriteString(")"); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if _, err := buf.WriteString(" "); err != nil {
+		return "", nil, err
+	}
+	if _, err := buf.WriteString(subSQL); err != nil {
+		return "", nil, err
+	}
+
+	return buf.String(), subArgs, nil
+}
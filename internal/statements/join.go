@@ -0,0 +1,213 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package statements
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/laixyz/xormplus/schemas"
+)
+
+// ErrUnsupportedJoin represents an error that joins cannot be used with update or delete
+var ErrUnsupportedJoin = errors.New("Unsupported join on update or delete")
+
+type join struct {
+	op        string
+	table     string
+	tableArgs []interface{}
+	cond      string
+	condArgs  []interface{}
+}
+
+func (j join) isInner() bool {
+	var op = strings.ToUpper(strings.TrimSpace(j.op))
+	return op == "" || op == "INNER"
+}
+
+// HasJoin returns true if the statement joins other tables
+func (statement *Statement) HasJoin() bool {
+	return len(statement.joins) > 0
+}
+
+// joinTarget returns the name which the updated or deleted table is referred as
+// and the table expression used in FROM
+func (statement *Statement) joinTarget() (string, string) {
+	var tableName = statement.quote(statement.TableName())
+	if len(statement.TableAlias) == 0 {
+		return tableName, tableName
+	}
+
+	var alias = statement.quote(statement.TableAlias)
+	if statement.dialect.URI().DBType == schemas.ORACLE {
+		return alias, tableName + " " + alias
+	}
+	return alias, tableName + " AS " + alias
+}
+
+// QuoteJoinColumn returns the quoted column qualified by the updated or deleted table if
+// the statement has joins, so it's not ambiguous with the columns of the joined tables
+func (statement *Statement) QuoteJoinColumn(colName string) string {
+	if !statement.HasJoin() {
+		return statement.quote(colName)
+	}
+	target, _ := statement.joinTarget()
+	return target + "." + statement.quote(colName)
+}
+
+// writeJoins writes the joins from the index start, the first join could be written
+// as a plain table when the condition will be moved to WHERE
+func (statement *Statement) writeJoins(buf *strings.Builder, start int, args []interface{}) []interface{} {
+	for i := start; i < len(statement.joins); i++ {
+		j := statement.joins[i]
+		buf.WriteString(" ")
+		buf.WriteString(j.op)
+		buf.WriteString(" JOIN ")
+		buf.WriteString(j.table)
+		buf.WriteString(" ON ")
+		buf.WriteString(j.cond)
+		args = append(append(args, j.tableArgs...), j.condArgs...)
+	}
+	return args
+}
+
+func writeWhere(buf *strings.Builder, conds ...string) {
+	var written bool
+	for _, cond := range conds {
+		if len(cond) == 0 {
+			continue
+		}
+		if written {
+			buf.WriteString(" AND ")
+		} else {
+			buf.WriteString(" WHERE ")
+		}
+		if len(conds) > 1 {
+			buf.WriteString("(")
+			buf.WriteString(cond)
+			buf.WriteString(")")
+		} else {
+			buf.WriteString(cond)
+		}
+		written = true
+	}
+}
+
+// GenUpdateJoinSQL generates an UPDATE statement which honors the joins of the statement,
+// colNames are the assignments like "`name` = ?" and condSQL is the WHERE condition without the keyword
+func (statement *Statement) GenUpdateJoinSQL(colNames []string, setArgs []interface{}, condSQL string, condArgs []interface{}) (string, []interface{}, error) {
+	if !statement.HasJoin() {
+		return "", nil, ErrUnsupportedJoin
+	}
+
+	var (
+		buf          strings.Builder
+		args         = make([]interface{}, 0, len(setArgs)+len(condArgs)+len(statement.joinArgs))
+		target, from = statement.joinTarget()
+	)
+
+	switch statement.dialect.URI().DBType {
+	case schemas.MYSQL:
+		buf.WriteString("UPDATE ")
+		buf.WriteString(from)
+		args = statement.writeJoins(&buf, 0, args)
+		buf.WriteString(" SET ")
+		for i, colName := range colNames {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(target)
+			buf.WriteString(".")
+			buf.WriteString(colName)
+		}
+		args = append(args, setArgs...)
+		writeWhere(&buf, condSQL)
+	case schemas.POSTGRES, schemas.SQLITE:
+		first := statement.joins[0]
+		if !first.isInner() {
+			return "", nil, ErrUnsupportedJoin
+		}
+
+		buf.WriteString("UPDATE ")
+		buf.WriteString(from)
+		buf.WriteString(" SET ")
+		buf.WriteString(strings.Join(colNames, ", "))
+		args = append(args, setArgs...)
+		buf.WriteString(" FROM ")
+		buf.WriteString(first.table)
+		args = append(args, first.tableArgs...)
+		args = statement.writeJoins(&buf, 1, args)
+		writeWhere(&buf, first.cond, condSQL)
+		args = append(args, first.condArgs...)
+	case schemas.MSSQL:
+		buf.WriteString("UPDATE ")
+		buf.WriteString(target)
+		buf.WriteString(" SET ")
+		buf.WriteString(strings.Join(colNames, ", "))
+		args = append(args, setArgs...)
+		buf.WriteString(" FROM ")
+		buf.WriteString(from)
+		args = statement.writeJoins(&buf, 0, args)
+		writeWhere(&buf, condSQL)
+	default:
+		return "", nil, ErrUnsupportedJoin
+	}
+
+	return buf.String(), append(args, condArgs...), nil
+}
+
+// GenDeleteJoinSQL generates a DELETE statement which honors the joins of the statement,
+// condSQL is the WHERE condition without the keyword
+func (statement *Statement) GenDeleteJoinSQL(condSQL string, condArgs []interface{}) (string, []interface{}, error) {
+	if !statement.HasJoin() {
+		return "", nil, ErrUnsupportedJoin
+	}
+
+	var (
+		buf          strings.Builder
+		args         = make([]interface{}, 0, len(condArgs)+len(statement.joinArgs))
+		target, from = statement.joinTarget()
+	)
+
+	switch statement.dialect.URI().DBType {
+	case schemas.MYSQL, schemas.MSSQL:
+		buf.WriteString("DELETE ")
+		buf.WriteString(target)
+		buf.WriteString(" FROM ")
+		buf.WriteString(from)
+		args = statement.writeJoins(&buf, 0, args)
+		writeWhere(&buf, condSQL)
+		args = append(args, condArgs...)
+	case schemas.POSTGRES:
+		first := statement.joins[0]
+		if !first.isInner() {
+			return "", nil, ErrUnsupportedJoin
+		}
+
+		buf.WriteString("DELETE FROM ")
+		buf.WriteString(from)
+		buf.WriteString(" USING ")
+		buf.WriteString(first.table)
+		args = append(args, first.tableArgs...)
+		args = statement.writeJoins(&buf, 1, args)
+		writeWhere(&buf, first.cond, condSQL)
+		args = append(append(args, first.condArgs...), condArgs...)
+	case schemas.SQLITE:
+		buf.WriteString("DELETE FROM ")
+		buf.WriteString(statement.quote(statement.TableName()))
+		buf.WriteString(" WHERE rowid IN (SELECT ")
+		buf.WriteString(target)
+		buf.WriteString(".rowid FROM ")
+		buf.WriteString(from)
+		args = statement.writeJoins(&buf, 0, args)
+		writeWhere(&buf, condSQL)
+		buf.WriteString(")")
+		args = append(args, condArgs...)
+	default:
+		return "", nil, ErrUnsupportedJoin
+	}
+
+	return buf.String(), args, nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package statements
+
+import (
+	"testing"
+	"time"
+
+	"github.com/laixyz/xormplus/caches"
+	"github.com/laixyz/xormplus/dialects"
+	"github.com/laixyz/xormplus/names"
+	"github.com/laixyz/xormplus/tags"
+	"github.com/stretchr/testify/assert"
+)
+
+func createJoinTestStatement(t *testing.T, driverName, connStr string) *Statement {
+	dialect, err := dialects.OpenDialect(driverName, connStr)
+	assert.NoError(t, err)
+
+	parser := tags.NewParser("xorm", dialect, names.SnakeMapper{}, names.SnakeMapper{}, caches.NewManager())
+	statement := NewStatement(dialect, parser, time.Local)
+	assert.NoError(t, statement.SetTable("a"))
+	statement.Join("INNER", "b", "b.a_id = a.id AND b.kind = ?", 1)
+	return statement
+}
+
+func TestGenUpdateJoinSQL(t *testing.T) {
+	var cases = []struct {
+		driverName string
+		connStr    string
+		sql        string
+		args       []interface{}
+	}{
+		{
+			"mysql", "root:@tcp(localhost:3306)/test",
+			"UPDATE `a` INNER JOIN `b` ON b.a_id = a.id AND b.kind = ? SET `a`.`name` = ? WHERE b.id=?",
+			[]interface{}{1, "x", 2},
+		},
+		{
+			"postgres", "postgres://postgres@localhost/test?sslmode=disable",
+			`UPDATE "a" SET "name" = ? FROM "b" WHERE (b.a_id = a.id AND b.kind = ?) AND (b.id=?)`,
+			[]interface{}{"x", 1, 2},
+		},
+		{
+			"sqlite3", "./test.db",
+			"UPDATE `a` SET `name` = ? FROM `b` WHERE (b.a_id = a.id AND b.kind = ?) AND (b.id=?)",
+			[]interface{}{"x", 1, 2},
+		},
+		{
+			"mssql", "server=localhost;user id=sa;password=pass;database=test",
+			"UPDATE [a] SET [name] = ? FROM [a] INNER JOIN [b] ON b.a_id = a.id AND b.kind = ? WHERE b.id=?",
+			[]interface{}{"x", 1, 2},
+		},
+	}
+
+	for _, c := range cases {
+		statement := createJoinTestStatement(t, c.driverName, c.connStr)
+		var quoter = statement.dialect.Quoter()
+		sql, args, err := statement.GenUpdateJoinSQL([]string{quoter.Quote("name") + " = ?"}, []interface{}{"x"},
+			"b.id=?", []interface{}{2})
+		assert.NoError(t, err)
+		assert.EqualValues(t, c.sql, sql, c.driverName)
+		assert.EqualValues(t, c.args, args, c.driverName)
+	}
+}
+
+func TestQuoteJoinColumn(t *testing.T) {
+	statement := createJoinTestStatement(t, "mysql", "root:@tcp(localhost:3306)/test")
+	assert.EqualValues(t, "`a`.`version`", statement.QuoteJoinColumn("version"))
+	statement.TableAlias = "u"
+	assert.EqualValues(t, "`u`.`version`", statement.QuoteJoinColumn("version"))
+
+	statement.Reset()
+	assert.EqualValues(t, "`version`", statement.QuoteJoinColumn("version"))
+}
+
+func TestGenDeleteJoinSQL(t *testing.T) {
+	var cases = []struct {
+		driverName string
+		connStr    string
+		sql        string
+		args       []interface{}
+	}{
+		{
+			"mysql", "root:@tcp(localhost:3306)/test",
+			"DELETE `a` FROM `a` INNER JOIN `b` ON b.a_id = a.id AND b.kind = ? WHERE b.id=?",
+			[]interface{}{1, 2},
+		},
+		{
+			"postgres", "postgres://postgres@localhost/test?sslmode=disable",
+			`DELETE FROM "a" USING "b" WHERE (b.a_id = a.id AND b.kind = ?) AND (b.id=?)`,
+			[]interface{}{1, 2},
+		},
+		{
+			"sqlite3", "./test.db",
+			"DELETE FROM `a` WHERE rowid IN (SELECT `a`.rowid FROM `a` INNER JOIN `b` ON b.a_id = a.id AND b.kind = ? WHERE b.id=?)",
+			[]interface{}{1, 2},
+		},
+		{
+			"mssql", "server=localhost;user id=sa;password=pass;database=test",
+			"DELETE [a] FROM [a] INNER JOIN [b] ON b.a_id = a.id AND b.kind = ? WHERE b.id=?",
+			[]interface{}{1, 2},
+		},
+	}
+
+	for _, c := range cases {
+		statement := createJoinTestStatement(t, c.driverName, c.connStr)
+		sql, args, err := statement.GenDeleteJoinSQL("b.id=?", []interface{}{2})
+		assert.NoError(t, err)
+		assert.EqualValues(t, c.sql, sql, c.driverName)
+		assert.EqualValues(t, c.args, args, c.driverName)
+	}
+
+	statement := createJoinTestStatement(t, "postgres", "postgres://postgres@localhost/test?sslmode=disable")
+	statement.joins[0].op = "LEFT"
+	_, _, err := statement.GenDeleteJoinSQL("", nil)
+	assert.EqualValues(t, ErrUnsupportedJoin, err)
+}
@@ -69,7 +69,7 @@ func (statement *Statement) GenQuerySQL(sqlOrArgs ...interface{}) (string, []int
 		args = append(args, args...)
 	}
 
-	return sqlStr, args, nil
+	return statement.genWithSQL(sqlStr, args)
 }
 
 func (statement *Statement) GenSumSQL(bean interface{}, columns ...string) (string, []interface{}, error) {
@@ -99,7 +99,7 @@ func (statement *Statement) GenSumSQL(bean interface{}, columns ...string) (stri
 		return "", nil, err
 	}
 
-	return sqlStr, append(statement.joinArgs, condArgs...), nil
+	return statement.genWithSQL(sqlStr, append(statement.joinArgs, condArgs...))
 }
 
 func (statement *Statement) GenGetSQL(bean interface{}) (string, []interface{}, error) {
@@ -150,7 +150,7 @@ func (statement *Statement) GenGetSQL(bean interface{}) (string, []interface{},
 		return "", nil, err
 	}
 
-	return sqlStr, append(statement.joinArgs, condArgs...), nil
+	return statement.genWithSQL(sqlStr, append(statement.joinArgs, condArgs...))
 }
 
 // GenCountSQL generates the SQL for counting
@@ -183,7 +183,7 @@ func (statement *Statement) GenCountSQL(beans ...interface{}) (string, []interfa
 		return "", nil, err
 	}
 
-	return sqlStr, append(statement.joinArgs, condArgs...), nil
+	return statement.genWithSQL(sqlStr, append(statement.joinArgs, condArgs...))
 }
 
 func (statement *Statement) genSelectSQL(columnStr string, needLimit, needOrderBy bool) (string, []interface{}, error) {
@@ -206,6 +206,13 @@ func (statement *Statement) genSelectSQL(columnStr string, needLimit, needOrderB
 		whereStr = " WHERE " + condSQL
 	}
 
+	var lockHint, lockSuffix string
+	if statement.IsForUpdate {
+		if lockHint, lockSuffix, err = dialect.LockSQL(statement.lockMode()); err != nil {
+			return "", nil, err
+		}
+	}
+
 	if dialect.URI().DBType == schemas.MSSQL && strings.Contains(statement.TableName(), "..") {
 		fromStr += statement.TableName()
 	} else {
@@ -219,6 +226,7 @@ func (statement *Statement) genSelectSQL(columnStr string, needLimit, needOrderB
 			fromStr += " AS " + quote(statement.TableAlias)
 		}
 	}
+	fromStr += lockHint
 	if statement.JoinStr != "" {
 		fromStr = fmt.Sprintf("%v %v", fromStr, statement.JoinStr)
 	}
@@ -309,9 +317,7 @@ func (statement *Statement) genSelectSQL(columnStr string, needLimit, needOrderB
 			}
 		}
 	}
-	if statement.IsForUpdate {
-		return dialect.ForUpdateSQL(buf.String()), condArgs, nil
-	}
+	buf.WriteString(lockSuffix)
 
 	return buf.String(), condArgs, nil
 }
@@ -360,6 +366,10 @@ func (statement *Statement) GenExistSQL(bean ...interface{}) (string, []interfac
 			}
 			args = []interface{}{}
 		}
+
+		if sqlStr, args, err = statement.genWithSQL(sqlStr, args); err != nil {
+			return "", nil, err
+		}
 	} else {
 		beanValue := reflect.ValueOf(bean[0])
 		if beanValue.Kind() != reflect.Ptr {
@@ -437,5 +447,38 @@ func (statement *Statement) GenFindSQL(autoCond builder.Cond) (string, []interfa
 		args = append(args, args...)
 	}
 
-	return sqlStr, args, nil
+	return statement.genWithSQL(sqlStr, args)
+}
+
+// With adds a common table expression to the select statements
+func (statement *Statement) With(name string, cte *builder.Builder, recursive bool) *Statement {
+	if statement.ctes == nil {
+		statement.ctes = builder.Dialect(string(statement.dialect.URI().DBType))
+	}
+	if recursive {
+		statement.ctes.WithRecursive(name, cte)
+	} else {
+		statement.ctes.With(name, cte)
+	}
+	return statement
+}
+
+// HasCTE returns true if the statement has common table expressions
+func (statement *Statement) HasCTE() bool {
+	return statement.ctes != nil
+}
+
+// genWithSQL prepends the WITH clause of the common table expressions to a select statement
+// generated by the statement, the raw statements are never changed
+func (statement *Statement) genWithSQL(sqlStr string, args []interface{}) (string, []interface{}, error) {
+	if statement.ctes == nil || statement.RawSQL != "" {
+		return sqlStr, args, nil
+	}
+
+	w := builder.NewWriter()
+	if err := statement.ctes.WriteTo(w); err != nil {
+		return "", nil, err
+	}
+
+	return statement.ReplaceQuote(w.String()) + " " + sqlStr, append(w.Args(), args...), nil
 }
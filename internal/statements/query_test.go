@@ -0,0 +1,106 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package statements
+
+import (
+	"testing"
+	"time"
+
+	"github.com/laixyz/xormplus/builder"
+	"github.com/laixyz/xormplus/caches"
+	"github.com/laixyz/xormplus/dialects"
+	"github.com/laixyz/xormplus/names"
+	"github.com/laixyz/xormplus/tags"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenLockSQL(t *testing.T) {
+	var cases = []struct {
+		driverName string
+		connStr    string
+		mode       dialects.LockMode
+		sql        string
+		err        error
+	}{
+		{
+			"mysql", "root:@tcp(localhost:3306)/test", dialects.LockForUpdate | dialects.LockSkipLocked,
+			"SELECT id FROM `job` WHERE status=? LIMIT 10 FOR UPDATE SKIP LOCKED", nil,
+		},
+		{
+			"mysql", "root:@tcp(localhost:3306)/test", dialects.LockForShare,
+			"SELECT id FROM `job` WHERE status=? LIMIT 10 LOCK IN SHARE MODE", nil,
+		},
+		{
+			"mysql", "root:@tcp(localhost:3306)/test", dialects.LockForShare | dialects.LockNoWait,
+			"SELECT id FROM `job` WHERE status=? LIMIT 10 FOR SHARE NOWAIT", nil,
+		},
+		{
+			"postgres", "postgres://postgres@localhost/test?sslmode=disable", dialects.LockNoWait,
+			`SELECT id FROM "job" WHERE status=? LIMIT 10 FOR UPDATE NOWAIT`, nil,
+		},
+		{
+			"postgres", "postgres://postgres@localhost/test?sslmode=disable", dialects.LockForShare | dialects.LockSkipLocked,
+			`SELECT id FROM "job" WHERE status=? LIMIT 10 FOR SHARE SKIP LOCKED`, nil,
+		},
+		{
+			"mssql", "server=localhost;user id=sa;password=pass;database=test", dialects.LockForUpdate | dialects.LockSkipLocked,
+			"SELECT TOP 10 id FROM [job] WITH (UPDLOCK, ROWLOCK, READPAST) WHERE status=?", nil,
+		},
+		{
+			"mssql", "server=localhost;user id=sa;password=pass;database=test", dialects.LockForShare,
+			"SELECT TOP 10 id FROM [job] WITH (HOLDLOCK, ROWLOCK) WHERE status=?", nil,
+		},
+		{
+			"sqlite3", "./test.db", dialects.LockForUpdate,
+			"SELECT id FROM `job` WHERE status=? LIMIT 10", nil,
+		},
+		{
+			"sqlite3", "./test.db", dialects.LockSkipLocked, "", dialects.ErrUnsupportedLockMode,
+		},
+		{
+			"mysql", "root:@tcp(localhost:3306)/test", dialects.LockNoWait | dialects.LockSkipLocked,
+			"", dialects.ErrUnsupportedLockMode,
+		},
+	}
+
+	for _, c := range cases {
+		dialect, err := dialects.OpenDialect(c.driverName, c.connStr)
+		assert.NoError(t, err)
+
+		parser := tags.NewParser("xorm", dialect, names.SnakeMapper{}, names.SnakeMapper{}, caches.NewManager())
+		statement := NewStatement(dialect, parser, time.Local)
+		assert.NoError(t, statement.SetTable("job"))
+		statement.Select("id").Where(builder.Eq{"status": 1}).Limit(10).Lock(c.mode)
+
+		sql, args, err := statement.GenFindSQL(builder.NewCond())
+		if c.err != nil {
+			assert.EqualValues(t, c.err, err, c.driverName)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.EqualValues(t, c.sql, sql, c.driverName)
+		assert.EqualValues(t, 1, len(args), c.driverName)
+	}
+}
+
+func TestGenWithSQL(t *testing.T) {
+	statement := createJoinTestStatement(t, "sqlite3", "./test.db")
+	statement.Reset()
+	assert.NoError(t, statement.SetTable("t"))
+	statement.With("t", builder.Select("id").From("a"), false)
+	sql, _, err := statement.GenCountSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "WITH t AS (SELECT id FROM a) SELECT count(*) FROM `t`", sql)
+
+	// the raw statements are not changed
+	statement.SQL("SELECT id FROM b WHERE id=?", 1)
+	sql, args, err := statement.GenQuerySQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT id FROM b WHERE id=?", sql)
+	assert.EqualValues(t, []interface{}{1}, args)
+	sql, _, err = statement.GenCountSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT id FROM b WHERE id=?", sql)
+}
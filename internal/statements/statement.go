@@ -45,6 +45,8 @@ type Statement struct {
 	OrderStr        string
 	JoinStr         string
 	joinArgs        []interface{}
+	joins           []join
+	ctes            *builder.Builder
 	GroupByStr      string
 	HavingStr       string
 	SelectStr       string
@@ -62,6 +64,7 @@ type Statement struct {
 	NoAutoCondition bool
 	IsDistinct      bool
 	IsForUpdate     bool
+	LockMode        dialects.LockMode
 	TableAlias      string
 	allUseBool      bool
 	CheckVersion    bool
@@ -77,6 +80,7 @@ type Statement struct {
 	BufferSize      int
 	Context         contexts.ContextCache
 	LastError       error
+	jsonHandler     json.JSONInterface
 }
 
 // NewStatement creates a new statement
@@ -90,6 +94,26 @@ func NewStatement(dialect dialects.Dialect, tagParser *tags.Parser, defaultTimeZ
 	return statement
 }
 
+// SetJSONHandler sets the handler to marshal the fields tagged json and the values of
+// JSON conditions, nil means json.DefaultJSONHandler
+func (statement *Statement) SetJSONHandler(handler json.JSONInterface) {
+	statement.jsonHandler = handler
+}
+
+// JSONHandler returns the handler to marshal and unmarshal the fields tagged json
+func (statement *Statement) JSONHandler() json.JSONInterface {
+	if statement.jsonHandler == nil {
+		return json.DefaultJSONHandler
+	}
+	return statement.jsonHandler
+}
+
+// newWriter creates a writer of the dialect which marshals the values of JSON conditions
+// by the handler of the statement
+func (statement *Statement) newWriter() *builder.BytesWriter {
+	return builder.NewDialectWriter(string(statement.dialect.URI().DBType)).SetJSONHandler(statement.jsonHandler)
+}
+
 func (statement *Statement) SetTableName(tableName string) {
 	statement.tableName = tableName
 }
@@ -104,11 +128,22 @@ func (statement *Statement) GenRawSQL() string {
 }
 
 func (statement *Statement) GenCondSQL(condOrBuilder interface{}) (string, []interface{}, error) {
-	condSQL, condArgs, err := builder.ToSQL(condOrBuilder)
-	if err != nil {
+	cond, ok := condOrBuilder.(builder.Cond)
+	if !ok {
+		condSQL, condArgs, err := builder.ToDialectSQL(string(statement.dialect.URI().DBType), condOrBuilder)
+		if err != nil {
+			return "", nil, err
+		}
+		return statement.ReplaceQuote(condSQL), condArgs, nil
+	}
+	if cond == nil || !cond.IsValid() {
+		return "", nil, nil
+	}
+	w := statement.newWriter()
+	if err := cond.WriteTo(w); err != nil {
 		return "", nil, err
 	}
-	return statement.ReplaceQuote(condSQL), condArgs, nil
+	return statement.ReplaceQuote(w.String()), w.Args(), nil
 }
 
 func (statement *Statement) ReplaceQuote(sql string) string {
@@ -132,6 +167,8 @@ func (statement *Statement) Reset() {
 	statement.UseCascade = true
 	statement.JoinStr = ""
 	statement.joinArgs = make([]interface{}, 0)
+	statement.joins = nil
+	statement.ctes = nil
 	statement.GroupByStr = ""
 	statement.HavingStr = ""
 	statement.ColumnMap = columnMap{}
@@ -146,6 +183,7 @@ func (statement *Statement) Reset() {
 	statement.NoAutoCondition = false
 	statement.IsDistinct = false
 	statement.IsForUpdate = false
+	statement.LockMode = 0
 	statement.TableAlias = ""
 	statement.SelectStr = ""
 	statement.allUseBool = false
@@ -359,9 +397,32 @@ func (statement *Statement) Distinct(columns ...string) *Statement {
 // ForUpdate generates "SELECT ... FOR UPDATE" statement
 func (statement *Statement) ForUpdate() *Statement {
 	statement.IsForUpdate = true
+	statement.LockMode = statement.LockMode&^dialects.LockForShare | dialects.LockForUpdate
 	return statement
 }
 
+// ForShare generates "SELECT ... FOR SHARE" statement
+func (statement *Statement) ForShare() *Statement {
+	statement.IsForUpdate = true
+	statement.LockMode = statement.LockMode&^dialects.LockForUpdate | dialects.LockForShare
+	return statement
+}
+
+// Lock adds the lock mode to the statement, i.e. dialects.LockNoWait
+func (statement *Statement) Lock(mode dialects.LockMode) *Statement {
+	statement.IsForUpdate = true
+	statement.LockMode |= mode
+	return statement
+}
+
+// lockMode returns the lock mode of the statement, FOR UPDATE is the default one
+func (statement *Statement) lockMode() dialects.LockMode {
+	if statement.LockMode&(dialects.LockForUpdate|dialects.LockForShare) == 0 {
+		return statement.LockMode | dialects.LockForUpdate
+	}
+	return statement.LockMode
+}
+
 // Select replace select
 func (statement *Statement) Select(str string) *Statement {
 	statement.SelectStr = statement.ReplaceQuote(str)
@@ -522,6 +583,10 @@ func (statement *Statement) Join(joinOP string, tablename interface{}, condition
 		fmt.Fprintf(&buf, "%v JOIN ", joinOP)
 	}
 
+	var (
+		tableStr  string
+		tableArgs []interface{}
+	)
 	switch tp := tablename.(type) {
 	case builder.Builder:
 		subSQL, subQueryArgs, err := tp.ToSQL()
@@ -534,8 +599,8 @@ func (statement *Statement) Join(joinOP string, tablename interface{}, condition
 		aliasName := statement.dialect.Quoter().Trim(fields[len(fields)-1])
 		aliasName = schemas.CommonQuoter.Trim(aliasName)
 
-		fmt.Fprintf(&buf, "(%s) %s ON %v", statement.ReplaceQuote(subSQL), aliasName, statement.ReplaceQuote(condition))
-		statement.joinArgs = append(statement.joinArgs, subQueryArgs...)
+		tableStr = fmt.Sprintf("(%s) %s", statement.ReplaceQuote(subSQL), aliasName)
+		tableArgs = subQueryArgs
 	case *builder.Builder:
 		subSQL, subQueryArgs, err := tp.ToSQL()
 		if err != nil {
@@ -547,8 +612,8 @@ func (statement *Statement) Join(joinOP string, tablename interface{}, condition
 		aliasName := statement.dialect.Quoter().Trim(fields[len(fields)-1])
 		aliasName = schemas.CommonQuoter.Trim(aliasName)
 
-		fmt.Fprintf(&buf, "(%s) %s ON %v", statement.ReplaceQuote(subSQL), aliasName, statement.ReplaceQuote(condition))
-		statement.joinArgs = append(statement.joinArgs, subQueryArgs...)
+		tableStr = fmt.Sprintf("(%s) %s", statement.ReplaceQuote(subSQL), aliasName)
+		tableArgs = subQueryArgs
 	default:
 		tbName := dialects.FullTableName(statement.dialect, statement.tagParser.GetTableMapper(), tablename, true)
 		if !utils.IsSubQuery(tbName) {
@@ -556,11 +621,22 @@ func (statement *Statement) Join(joinOP string, tablename interface{}, condition
 			statement.dialect.Quoter().QuoteTo(&buf, tbName)
 			tbName = buf.String()
 		}
-		fmt.Fprintf(&buf, "%s ON %v", tbName, statement.ReplaceQuote(condition))
+		tableStr = tbName
 	}
 
+	condition = statement.ReplaceQuote(condition)
+	fmt.Fprintf(&buf, "%s ON %v", tableStr, condition)
+
+	statement.joins = append(statement.joins, join{
+		op:        joinOP,
+		table:     tableStr,
+		tableArgs: tableArgs,
+		cond:      condition,
+		condArgs:  args,
+	})
+
 	statement.JoinStr = buf.String()
-	statement.joinArgs = append(statement.joinArgs, args...)
+	statement.joinArgs = append(append(statement.joinArgs, tableArgs...), args...)
 	return statement
 }
 
@@ -643,16 +719,19 @@ func (statement *Statement) GenCreateTableSQL() []string {
 	return s
 }
 
-func (statement *Statement) GenIndexSQL() []string {
+func (statement *Statement) GenIndexSQL() ([]string, error) {
 	var sqls []string
 	tbName := statement.TableName()
 	for _, index := range statement.RefTable.Indexes {
-		if index.Type == schemas.IndexType {
+		if index.Type == schemas.IndexType || index.Type == schemas.FulltextType {
+			if err := dialects.CheckIndex(statement.dialect, index); err != nil {
+				return nil, err
+			}
 			sql := statement.dialect.CreateIndexSQL(tbName, index)
 			sqls = append(sqls, sql)
 		}
 	}
-	return sqls
+	return sqls, nil
 }
 
 func uniqueName(tableName, uqeName string) string {
@@ -815,7 +894,7 @@ func (statement *Statement) buildConds2(table *schemas.Table, bean interface{},
 			} else {
 				if col.IsJSON {
 					if col.SQLType.IsText() {
-						bytes, err := json.DefaultJSONHandler.Marshal(fieldValue.Interface())
+						bytes, err := statement.JSONHandler().Marshal(fieldValue.Interface())
 						if err != nil {
 							return nil, err
 						}
@@ -823,7 +902,7 @@ func (statement *Statement) buildConds2(table *schemas.Table, bean interface{},
 					} else if col.SQLType.IsBlob() {
 						var bytes []byte
 						var err error
-						bytes, err = json.DefaultJSONHandler.Marshal(fieldValue.Interface())
+						bytes, err = statement.JSONHandler().Marshal(fieldValue.Interface())
 						if err != nil {
 							return nil, err
 						}
@@ -861,7 +940,7 @@ func (statement *Statement) buildConds2(table *schemas.Table, bean interface{},
 			}
 
 			if col.SQLType.IsText() {
-				bytes, err := json.DefaultJSONHandler.Marshal(fieldValue.Interface())
+				bytes, err := statement.JSONHandler().Marshal(fieldValue.Interface())
 				if err != nil {
 					return nil, err
 				}
@@ -877,7 +956,7 @@ func (statement *Statement) buildConds2(table *schemas.Table, bean interface{},
 						continue
 					}
 				} else {
-					bytes, err = json.DefaultJSONHandler.Marshal(fieldValue.Interface())
+					bytes, err = statement.JSONHandler().Marshal(fieldValue.Interface())
 					if err != nil {
 						return nil, err
 					}
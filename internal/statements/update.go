@@ -13,7 +13,6 @@ import (
 
 	"github.com/laixyz/xormplus/convert"
 	"github.com/laixyz/xormplus/dialects"
-	"github.com/laixyz/xormplus/internal/json"
 	"github.com/laixyz/xormplus/internal/utils"
 	"github.com/laixyz/xormplus/schemas"
 )
@@ -224,7 +223,7 @@ func (statement *Statement) BuildUpdates(tableValue reflect.Value,
 				} else {
 					// Blank struct could not be as update data
 					if requiredField || !utils.IsStructZero(fieldValue) {
-						bytes, err := json.DefaultJSONHandler.Marshal(fieldValue.Interface())
+						bytes, err := statement.JSONHandler().Marshal(fieldValue.Interface())
 						if err != nil {
 							return nil, nil, fmt.Errorf("mashal %v failed", fieldValue.Interface())
 						}
@@ -253,7 +252,7 @@ func (statement *Statement) BuildUpdates(tableValue reflect.Value,
 			}
 
 			if col.SQLType.IsText() {
-				bytes, err := json.DefaultJSONHandler.Marshal(fieldValue.Interface())
+				bytes, err := statement.JSONHandler().Marshal(fieldValue.Interface())
 				if err != nil {
 					return nil, nil, err
 				}
@@ -272,7 +271,7 @@ func (statement *Statement) BuildUpdates(tableValue reflect.Value,
 					fieldType.Elem().Kind() == reflect.Uint8 {
 					val = fieldValue.Slice(0, 0).Interface()
 				} else {
-					bytes, err = json.DefaultJSONHandler.Marshal(fieldValue.Interface())
+					bytes, err = statement.JSONHandler().Marshal(fieldValue.Interface())
 					if err != nil {
 						return nil, nil, err
 					}
@@ -13,7 +13,6 @@ import (
 
 	"github.com/laixyz/xormplus/convert"
 	"github.com/laixyz/xormplus/dialects"
-	"github.com/laixyz/xormplus/internal/json"
 	"github.com/laixyz/xormplus/schemas"
 )
 
@@ -104,13 +103,13 @@ func (statement *Statement) Value2Interface(col *schemas.Column, fieldValue refl
 		}
 
 		if col.SQLType.IsText() {
-			bytes, err := json.DefaultJSONHandler.Marshal(fieldValue.Interface())
+			bytes, err := statement.JSONHandler().Marshal(fieldValue.Interface())
 			if err != nil {
 				return nil, err
 			}
 			return string(bytes), nil
 		} else if col.SQLType.IsBlob() {
-			bytes, err := json.DefaultJSONHandler.Marshal(fieldValue.Interface())
+			bytes, err := statement.JSONHandler().Marshal(fieldValue.Interface())
 			if err != nil {
 				return nil, err
 			}
@@ -118,7 +117,7 @@ func (statement *Statement) Value2Interface(col *schemas.Column, fieldValue refl
 		}
 		return nil, fmt.Errorf("Unsupported type %v", fieldValue.Type())
 	case reflect.Complex64, reflect.Complex128:
-		bytes, err := json.DefaultJSONHandler.Marshal(fieldValue.Interface())
+		bytes, err := statement.JSONHandler().Marshal(fieldValue.Interface())
 		if err != nil {
 			return nil, err
 		}
@@ -129,7 +128,7 @@ func (statement *Statement) Value2Interface(col *schemas.Column, fieldValue refl
 		}
 
 		if col.SQLType.IsText() {
-			bytes, err := json.DefaultJSONHandler.Marshal(fieldValue.Interface())
+			bytes, err := statement.JSONHandler().Marshal(fieldValue.Interface())
 			if err != nil {
 				return nil, err
 			}
@@ -141,7 +140,7 @@ func (statement *Statement) Value2Interface(col *schemas.Column, fieldValue refl
 				(fieldValue.Type().Elem().Kind() == reflect.Uint8) {
 				bytes = fieldValue.Bytes()
 			} else {
-				bytes, err = json.DefaultJSONHandler.Marshal(fieldValue.Interface())
+				bytes, err = statement.JSONHandler().Marshal(fieldValue.Interface())
 				if err != nil {
 					return nil, err
 				}
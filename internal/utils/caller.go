@@ -0,0 +1,51 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+type pkgMarker struct{}
+
+// modulePath is the import path of xorm which is used to skip the frames of xorm
+var modulePath = strings.TrimSuffix(reflect.TypeOf(pkgMarker{}).PkgPath(), "/internal/utils")
+
+// isLibraryFrame reports whether the frame belongs to xorm, the frames of all the packages
+// of the module are skipped except the tests
+func isLibraryFrame(frame runtime.Frame) bool {
+	if strings.HasSuffix(frame.File, "_test.go") {
+		return false
+	}
+	fn := frame.Function
+	if strings.HasPrefix(fn, "database/sql.") || strings.HasPrefix(fn, "runtime.") {
+		return true
+	}
+	if !strings.HasPrefix(fn, modulePath) {
+		return false
+	}
+	// another module whose path starts with the module path is not xorm
+	rest := fn[len(modulePath):]
+	return strings.HasPrefix(rest, ".") || strings.HasPrefix(rest, "/")
+}
+
+// Caller returns the file and the line of the first caller out of xorm
+func Caller() string {
+	var pcs [64]uintptr
+	n := runtime.Callers(2, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !isLibraryFrame(frame) {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
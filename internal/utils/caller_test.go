@@ -0,0 +1,34 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsLibraryFrame(t *testing.T) {
+	var cases = []struct {
+		function string
+		file     string
+		library  bool
+	}{
+		{modulePath + ".(*Session).Find", "session_find.go", true},
+		{modulePath + "/filter.(*Parser).Parse", "filter/filter.go", true},
+		{modulePath + "/xormhelper.Paginate", "xormhelper/paginate.go", true},
+		{modulePath + "/internal/statements.(*Statement).GenGetSQL", "internal/statements/query.go", true},
+		{modulePath + "/integrations.TestFind", "integrations/session_find_test.go", false},
+		{modulePath + "/integrations.assertSync", "integrations/tests.go", true},
+		{modulePath + ".TestEngine", "engine_test.go", false},
+		{modulePath + "ext.Find", "find.go", false},
+		{"database/sql.(*DB).QueryContext", "sql.go", true},
+		{"main.main", "main.go", false},
+	}
+	for _, c := range cases {
+		assert.EqualValues(t, c.library, isLibraryFrame(runtime.Frame{Function: c.function, File: c.file}), c.function)
+	}
+}
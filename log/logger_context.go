@@ -44,6 +44,7 @@ var (
 	SessionIDKey      = "__xorm_session_id"
 	SessionKey        = "__xorm_session_key"
 	SessionShowSQLKey = "__xorm_show_sql"
+	TxIDKey           = "__xorm_tx_id"
 )
 
 // LoggerAdapter wraps a Logger interface as LoggerContext interface
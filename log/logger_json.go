@@ -0,0 +1,460 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// RedactedValue replaces the values of the redacted args
+const RedactedValue = "[REDACTED]"
+
+// JSONLogger is a ContextLogger which writes a JSON object per line for every event
+type JSONLogger struct {
+	mu            sync.Mutex
+	out           io.Writer
+	level         LogLevel
+	showSQL       bool
+	redactAll     bool
+	redactColumns map[string]bool
+	maxSQLLength  int
+	maxArgLength  int
+}
+
+var _ ContextLogger = &JSONLogger{}
+
+// NewJSONLogger creates a JSON logger
+func NewJSONLogger(out io.Writer) *JSONLogger {
+	return &JSONLogger{
+		out:   out,
+		level: DEFAULT_LOG_LEVEL,
+	}
+}
+
+// SetRedactAll sets whether to redact the values of all the args
+func (l *JSONLogger) SetRedactAll(redact bool) *JSONLogger {
+	l.mu.Lock()
+	l.redactAll = redact
+	l.mu.Unlock()
+	return l
+}
+
+// SetRedactColumns redacts the values of the args which are compared with or assigned to
+// the columns, the column names are case insensitive. The columns of the args are recognized
+// from the SQL, i.e. the column lists of INSERT, "col = ?", "col IN (?)", "(a, b) = (?, ?)"
+// and "? AS col", the args whose columns can't be recognized are also redacted.
+func (l *JSONLogger) SetRedactColumns(columns ...string) *JSONLogger {
+	l.mu.Lock()
+	l.redactColumns = make(map[string]bool, len(columns))
+	for _, col := range columns {
+		l.redactColumns[strings.ToLower(col)] = true
+	}
+	l.mu.Unlock()
+	return l
+}
+
+// SetMaxSQLLength truncates the SQL longer than max bytes, 0 means no limitation
+func (l *JSONLogger) SetMaxSQLLength(max int) *JSONLogger {
+	l.mu.Lock()
+	l.maxSQLLength = max
+	l.mu.Unlock()
+	return l
+}
+
+// SetMaxArgLength truncates the string and blob args longer than max bytes, 0 means no
+// limitation
+func (l *JSONLogger) SetMaxArgLength(max int) *JSONLogger {
+	l.mu.Lock()
+	l.maxArgLength = max
+	l.mu.Unlock()
+	return l
+}
+
+type jsonEntry struct {
+	Time         string        `json:"time"`
+	Level        string        `json:"level"`
+	Msg          string        `json:"msg"`
+	SessionID    string        `json:"session_id,omitempty"`
+	TxID         string        `json:"tx_id,omitempty"`
+	SQL          string        `json:"sql,omitempty"`
+	Args         []interface{} `json:"args,omitempty"`
+	Duration     *float64      `json:"duration_ms,omitempty"`
+	RowsAffected *int64        `json:"rows_affected,omitempty"`
+	Error        string        `json:"error,omitempty"`
+}
+
+var levelNames = map[LogLevel]string{
+	LOG_DEBUG:   "debug",
+	LOG_INFO:    "info",
+	LOG_WARNING: "warn",
+	LOG_ERR:     "error",
+}
+
+// write writes the entry, l.mu should be locked
+func (l *JSONLogger) write(level LogLevel, entry *jsonEntry) {
+	entry.Time = time.Now().Format(time.RFC3339Nano)
+	entry.Level = levelNames[level]
+	data, err := json.Marshal(entry)
+	if err != nil {
+		data, _ = json.Marshal(&jsonEntry{
+			Time:  entry.Time,
+			Level: levelNames[LOG_ERR],
+			Msg:   "marshal log failed",
+			Error: err.Error(),
+		})
+	}
+	l.out.Write(append(data, '\n'))
+}
+
+func (l *JSONLogger) logf(level LogLevel, format string, v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.level <= level {
+		l.write(level, &jsonEntry{Msg: fmt.Sprintf(format, v...)})
+	}
+}
+
+// BeforeSQL implements ContextLogger
+func (l *JSONLogger) BeforeSQL(ctx LogContext) {}
+
+// AfterSQL implements ContextLogger
+func (l *JSONLogger) AfterSQL(ctx LogContext) {
+	level := LOG_INFO
+	if ctx.Err != nil {
+		level = LOG_ERR
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.level > level {
+		return
+	}
+
+	entry := jsonEntry{
+		Msg:  "sql",
+		SQL:  truncate(ctx.SQL, l.maxSQLLength),
+		Args: l.formatArgs(ctx.SQL, ctx.Args),
+	}
+	if ctx.Ctx != nil {
+		entry.SessionID, _ = ctx.Ctx.Value(SessionIDKey).(string)
+		entry.TxID, _ = ctx.Ctx.Value(TxIDKey).(string)
+	}
+	if ctx.ExecuteTime > 0 {
+		ms := float64(ctx.ExecuteTime) / float64(time.Millisecond)
+		entry.Duration = &ms
+	}
+	if ctx.Result != nil {
+		if rows, err := ctx.Result.RowsAffected(); err == nil {
+			entry.RowsAffected = &rows
+		}
+	}
+	if ctx.Err != nil {
+		entry.Error = ctx.Err.Error()
+	}
+	l.write(level, &entry)
+}
+
+// formatArgs converts the args to the JSON values, l.mu should be locked
+func (l *JSONLogger) formatArgs(sqlStr string, args []interface{}) []interface{} {
+	if len(args) == 0 {
+		return nil
+	}
+	var columns []string
+	if !l.redactAll && len(l.redactColumns) > 0 {
+		columns = argColumns(sqlStr, len(args))
+	}
+
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		// the args of the unknown columns are redacted since they may be the redacted ones
+		if l.redactAll || (columns != nil && (columns[i] == "" || l.redactColumns[columns[i]])) {
+			values[i] = RedactedValue
+			continue
+		}
+		values[i] = l.formatArg(arg)
+	}
+	return values
+}
+
+func (l *JSONLogger) formatArg(arg interface{}) interface{} {
+	if valuer, ok := arg.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return fmt.Sprintf("%v", arg)
+		}
+		arg = v
+	}
+	switch t := arg.(type) {
+	case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return t
+	case string:
+		return truncate(t, l.maxArgLength)
+	case []byte:
+		if l.maxArgLength > 0 && len(t) > l.maxArgLength {
+			return fmt.Sprintf("[%d bytes]", len(t))
+		}
+		if utf8.Valid(t) {
+			return string(t)
+		}
+		return "0x" + hex.EncodeToString(t)
+	case time.Time:
+		return t.Format(time.RFC3339Nano)
+	}
+	return truncate(fmt.Sprintf("%v", arg), l.maxArgLength)
+}
+
+func truncate(s string, max int) string {
+	if max <= 0 || len(s) <= max {
+		return s
+	}
+	end := max
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	return s[:end] + "...(" + strconv.Itoa(len(s)) + " bytes)"
+}
+
+var (
+	placeholderRegexp   = regexp.MustCompile(`\?|\$\d+`)
+	insertColumnsRegexp = regexp.MustCompile(`(?is)^\s*(?:INSERT|REPLACE)\s+(?:INTO\s+)?\S+\s*\(([^)]*)\)\s*(?:OUTPUT\s+[^(]*)?VALUES\s*`)
+	compareRegexp       = regexp.MustCompile(`(?i)([\w.` + "`" + `"\[\]]+)\s*(?:=|<>|!=|<=|>=|<|>|\bLIKE|\bIN\s*\()\s*$`)
+	inListRegexp        = regexp.MustCompile(`(?:(?:\?|\$\d+)\s*,\s*)+$`)
+	funcCallRegexp      = regexp.MustCompile(`\w+\s*\(\s*$`)
+	aliasRegexp         = regexp.MustCompile(`(?i)^\s*AS\s+([\w` + "`" + `"\[\]]+)`)
+	tupleListRegexp     = regexp.MustCompile(`(?:\([^()]*\)\s*,\s*)+$`)
+	tupleCompareRegexp  = regexp.MustCompile(`(?i)\(([^()]*)\)\s*(?:=|<>|!=|\bIN)\s*$`)
+)
+
+func normalizeColumn(col string) string {
+	if i := strings.LastIndexByte(col, '.'); i >= 0 {
+		col = col[i+1:]
+	}
+	return strings.ToLower(strings.Trim(col, "`\"[]"))
+}
+
+// skipQuoted returns the index of the closing quote of the string literal starting at i
+func skipQuoted(s string, i int) int {
+	for j := i + 1; j < len(s); j++ {
+		if s[j] == '\'' {
+			if j+1 < len(s) && s[j+1] == '\'' {
+				j++
+				continue
+			}
+			return j
+		}
+	}
+	return len(s)
+}
+
+// tuplePosition returns the position of the end of s in the last tuple of s
+func tuplePosition(s string) int {
+	var pos, depth int
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\'':
+			i = skipQuoted(s, i)
+		case '(':
+			depth++
+			if depth == 1 {
+				pos = 0
+			}
+		case ')':
+			depth--
+		case ',':
+			if depth == 1 {
+				pos++
+			}
+		}
+	}
+	return pos
+}
+
+// valuesEnd returns the end of the tuples of VALUES which start at start, the clauses
+// after them like ON CONFLICT are not mapped by the positions
+func valuesEnd(s string, start int) int {
+	var depth int
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '\'':
+			i = skipQuoted(s, i)
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				next := strings.TrimLeft(s[i+1:], " \t\r\n")
+				if !strings.HasPrefix(next, ",") {
+					return i + 1
+				}
+			}
+		}
+	}
+	return len(s)
+}
+
+// compareColumn returns the column compared with or assigned to the arg which is at the
+// end of before, the args in a function call like LOWER(?) are also recognized
+func compareColumn(before string) string {
+	for {
+		before = inListRegexp.ReplaceAllString(before, "")
+		if m := compareRegexp.FindStringSubmatch(before); m != nil {
+			return normalizeColumn(m[1])
+		}
+		stripped := funcCallRegexp.ReplaceAllString(before, "")
+		if stripped == before {
+			return ""
+		}
+		before = stripped
+	}
+}
+
+// tupleColumn returns the column of the arg at the end of before in the tuple comparisons,
+// i.e. (a, b) = (?, ?) and (a, b) IN ((?, ?), (?, ?))
+func tupleColumn(before string) string {
+	var depth int
+	open := -1
+	for i := len(before) - 1; i >= 0 && open < 0; i-- {
+		switch before[i] {
+		case ')':
+			depth++
+		case '(':
+			if depth == 0 {
+				open = i
+			}
+			depth--
+		}
+	}
+	if open < 0 {
+		return ""
+	}
+	pos := tuplePosition(before[open:])
+
+	rest := strings.TrimRight(before[:open], " \t\r\n")
+	if strings.HasSuffix(rest, ",") || strings.HasSuffix(rest, "(") {
+		// a tuple in the list of IN
+		rest = strings.TrimRight(tupleListRegexp.ReplaceAllString(rest, ""), " \t\r\n")
+		if !strings.HasSuffix(rest, "(") {
+			return ""
+		}
+		rest = rest[:len(rest)-1]
+	}
+	m := tupleCompareRegexp.FindStringSubmatch(rest)
+	if m == nil {
+		return ""
+	}
+	cols := strings.Split(m[1], ",")
+	if pos >= len(cols) {
+		return ""
+	}
+	return normalizeColumn(strings.TrimSpace(cols[pos]))
+}
+
+// argColumns returns the columns of the args, the column is empty if it's unknown
+func argColumns(sqlStr string, n int) []string {
+	columns := make([]string, n)
+	seen := make([]bool, n)
+	locs := placeholderRegexp.FindAllStringIndex(sqlStr, -1)
+
+	var insertColumns []string
+	var valuesStart, valuesStop int
+	if m := insertColumnsRegexp.FindStringSubmatchIndex(sqlStr); m != nil {
+		for _, col := range strings.Split(sqlStr[m[2]:m[3]], ",") {
+			insertColumns = append(insertColumns, normalizeColumn(strings.TrimSpace(col)))
+		}
+		valuesStart = m[1]
+		valuesStop = valuesEnd(sqlStr, valuesStart)
+	}
+
+	for i, loc := range locs {
+		idx := i
+		if p := sqlStr[loc[0]:loc[1]]; p != "?" {
+			idx, _ = strconv.Atoi(p[1:])
+			idx--
+		}
+		if idx < 0 || idx >= n {
+			continue
+		}
+
+		var col string
+		if len(insertColumns) > 0 && loc[0] >= valuesStart && loc[0] < valuesStop {
+			if pos := tuplePosition(sqlStr[valuesStart:loc[0]]); pos < len(insertColumns) {
+				col = insertColumns[pos]
+			}
+		} else if m := aliasRegexp.FindStringSubmatch(sqlStr[loc[1]:]); m != nil {
+			col = normalizeColumn(m[1])
+		} else if col = compareColumn(sqlStr[:loc[0]]); col == "" {
+			col = tupleColumn(sqlStr[:loc[0]])
+		}
+		// an arg used by different columns like $1 is treated as unknown
+		if seen[idx] && columns[idx] != col {
+			col = ""
+		}
+		seen[idx] = true
+		columns[idx] = col
+	}
+	return columns
+}
+
+// Debugf implements ContextLogger
+func (l *JSONLogger) Debugf(format string, v ...interface{}) {
+	l.logf(LOG_DEBUG, format, v...)
+}
+
+// Errorf implements ContextLogger
+func (l *JSONLogger) Errorf(format string, v ...interface{}) {
+	l.logf(LOG_ERR, format, v...)
+}
+
+// Infof implements ContextLogger
+func (l *JSONLogger) Infof(format string, v ...interface{}) {
+	l.logf(LOG_INFO, format, v...)
+}
+
+// Warnf implements ContextLogger
+func (l *JSONLogger) Warnf(format string, v ...interface{}) {
+	l.logf(LOG_WARNING, format, v...)
+}
+
+// Level implements ContextLogger
+func (l *JSONLogger) Level() LogLevel {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.level
+}
+
+// SetLevel implements ContextLogger
+func (l *JSONLogger) SetLevel(lv LogLevel) {
+	l.mu.Lock()
+	l.level = lv
+	l.mu.Unlock()
+}
+
+// ShowSQL implements ContextLogger
+func (l *JSONLogger) ShowSQL(show ...bool) {
+	l.mu.Lock()
+	if len(show) == 0 {
+		l.showSQL = true
+	} else {
+		l.showSQL = show[0]
+	}
+	l.mu.Unlock()
+}
+
+// IsShowSQL implements ContextLogger
+func (l *JSONLogger) IsShowSQL() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.showSQL
+}
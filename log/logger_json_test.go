@@ -0,0 +1,44 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArgColumns(t *testing.T) {
+	var cases = []struct {
+		sql     string
+		columns []string
+	}{
+		{"INSERT INTO `user` (`name`,`password`) VALUES (?,?),(?,?)", []string{"name", "password", "name", "password"}},
+		{"UPDATE `user` SET `password`=?,`name`=? WHERE `id`=?", []string{"password", "name", "id"}},
+		{"SELECT * FROM user WHERE id IN (?,?,?) AND user.name LIKE ?", []string{"id", "id", "id", "name"}},
+		{`INSERT INTO "user" ("id","name") VALUES ($1,$2) ON CONFLICT ("id") DO UPDATE SET "password"=$3`, []string{"id", "name", "password"}},
+		{"INSERT INTO `user` (`id`,`name`) VALUES (?,?) ON DUPLICATE KEY UPDATE `password`=?", []string{"id", "name", "password"}},
+		{"MERGE INTO [user] USING (SELECT ? AS [id], ? AS password) AS src ON [user].[id]=src.[id]", []string{"id", "password"}},
+		{"UPDATE user SET password=LOWER(?) WHERE name=UPPER(TRIM(?))", []string{"password", "name"}},
+		{"SELECT * FROM user WHERE (password) = (?)", []string{"password"}},
+		{"SELECT * FROM user WHERE (id, password) IN ((?, ?), (?, ?))", []string{"id", "password", "id", "password"}},
+		{"SELECT * FROM user WHERE name = 'a,(b' AND id BETWEEN ? AND ?", []string{"", ""}},
+		{"SELECT * FROM user WHERE name = $1 OR password = $1", []string{""}},
+	}
+	for _, c := range cases {
+		assert.EqualValues(t, c.columns, argColumns(c.sql, len(c.columns)), c.sql)
+	}
+}
+
+func TestRedactColumns(t *testing.T) {
+	logger := NewJSONLogger(nil).SetRedactColumns("Password")
+	args := logger.formatArgs(`INSERT INTO "user" ("id","name") VALUES ($1,$2) ON CONFLICT ("id") DO UPDATE SET "password"=$3`,
+		[]interface{}{1, "a", "secret"})
+	assert.EqualValues(t, []interface{}{1, "a", RedactedValue}, args)
+
+	// the args of the unknown columns are redacted
+	args = logger.formatArgs("SELECT * FROM user WHERE name = ? AND SUBSTR(password, ?) = ?", []interface{}{"a", 1, "secret"})
+	assert.EqualValues(t, []interface{}{"a", RedactedValue, RedactedValue}, args)
+}
@@ -0,0 +1,171 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/laixyz/xormplus/contexts"
+	"github.com/laixyz/xormplus/internal/utils"
+)
+
+// Explainer returns the plan of the query, it should not execute the query by the
+// connection which calls the hooks. It's called in another goroutine after the hook
+// returns and the context is canceled when the explain timeout elapses.
+type Explainer func(ctx context.Context, sqlStr string, args []interface{}) (string, error)
+
+// default rate limitation of slow query logs and timeout of explaining
+const (
+	DefaultSlowQueryLimit    = 10
+	DefaultSlowQueryInterval = time.Minute
+	DefaultExplainTimeout    = 5 * time.Second
+)
+
+// SlowQueryHook is a hook which logs the statements slower than the threshold with the
+// session ID and the caller, the logs are limited to some times in an interval.
+type SlowQueryHook struct {
+	threshold time.Duration
+	logger    ContextLogger
+	explainer Explainer
+	explains  sync.WaitGroup
+
+	mu             sync.Mutex
+	explainTimeout time.Duration
+	limit          int
+	interval       time.Duration
+	windowStart    time.Time
+	logged         int
+	suppressed     int
+}
+
+var _ contexts.Hook = &SlowQueryHook{}
+
+// NewSlowQueryHook creates a slow query hook
+func NewSlowQueryHook(threshold time.Duration, logger ContextLogger) *SlowQueryHook {
+	return &SlowQueryHook{
+		threshold:      threshold,
+		logger:         logger,
+		explainTimeout: DefaultExplainTimeout,
+		limit:          DefaultSlowQueryLimit,
+		interval:       DefaultSlowQueryInterval,
+	}
+}
+
+// SetExplainer sets the explainer of the slow SELECT statements
+func (h *SlowQueryHook) SetExplainer(explainer Explainer) *SlowQueryHook {
+	h.mu.Lock()
+	h.explainer = explainer
+	h.mu.Unlock()
+	return h
+}
+
+// SetExplainTimeout sets the timeout of explaining a slow query
+func (h *SlowQueryHook) SetExplainTimeout(timeout time.Duration) *SlowQueryHook {
+	h.mu.Lock()
+	h.explainTimeout = timeout
+	h.mu.Unlock()
+	return h
+}
+
+// Wait waits for the slow queries being explained to be logged
+func (h *SlowQueryHook) Wait() {
+	h.explains.Wait()
+}
+
+// SetRateLimit logs at most limit slow queries in the interval, the others are suppressed
+// and counted. The logs are not limited if limit is not positive.
+func (h *SlowQueryHook) SetRateLimit(limit int, interval time.Duration) *SlowQueryHook {
+	h.mu.Lock()
+	h.limit, h.interval = limit, interval
+	h.mu.Unlock()
+	return h
+}
+
+// BeforeProcess implements contexts.Hook
+func (h *SlowQueryHook) BeforeProcess(c *contexts.ContextHook) (context.Context, error) {
+	return c.Ctx, nil
+}
+
+// AfterProcess implements contexts.Hook
+func (h *SlowQueryHook) AfterProcess(c *contexts.ContextHook) error {
+	if c.ExecuteTime < h.threshold {
+		return nil
+	}
+
+	suppressed, ok := h.allow(time.Now())
+	if !ok {
+		return nil
+	}
+
+	var sessionPart string
+	if key, ok := c.Ctx.Value(SessionIDKey).(string); ok {
+		sessionPart = fmt.Sprintf(" [%s]", key)
+	}
+	msg := fmt.Sprintf("[SLOW SQL]%s %s %v - %v at %s", sessionPart, c.SQL, c.Args, c.ExecuteTime, utils.Caller())
+	if suppressed > 0 {
+		msg += fmt.Sprintf(" (%d slow queries suppressed)", suppressed)
+	}
+
+	h.mu.Lock()
+	explainer, timeout := h.explainer, h.explainTimeout
+	h.mu.Unlock()
+	if explainer == nil || !isSelect(c.SQL) {
+		h.logger.Warnf("%s", msg)
+		return nil
+	}
+
+	// the explain query needs another connection, it's not waited by the statement so
+	// that the statement couldn't be blocked when the connection pool is exhausted
+	h.explains.Add(1)
+	go func() {
+		defer h.explains.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		plan, err := explainer(ctx, c.SQL, c.Args)
+		if err != nil {
+			msg += fmt.Sprintf("\nexplain failed: %v", err)
+		} else {
+			msg += "\n" + plan
+		}
+		h.logger.Warnf("%s", msg)
+	}()
+	return nil
+}
+
+// allow returns whether the slow query could be logged and the number of the suppressed
+// slow queries since last log
+func (h *SlowQueryHook) allow(now time.Time) (int, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.limit <= 0 {
+		return 0, true
+	}
+	if now.Sub(h.windowStart) >= h.interval {
+		h.windowStart = now
+		h.logged = 0
+	}
+	if h.logged >= h.limit {
+		h.suppressed++
+		return 0, false
+	}
+	h.logged++
+	suppressed := h.suppressed
+	h.suppressed = 0
+	return suppressed, true
+}
+
+func isSelect(sqlStr string) bool {
+	sqlStr = strings.TrimLeft(sqlStr, " \t\r\n(")
+	if len(sqlStr) < 6 {
+		return false
+	}
+	prefix := strings.ToUpper(sqlStr[:6])
+	return prefix == "SELECT" || strings.HasPrefix(prefix, "WITH ")
+}
@@ -0,0 +1,37 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package metrics
+
+import "github.com/laixyz/xormplus/caches"
+
+// Cacher wraps a caches.Cacher to observe the hits and the misses of the lookups
+type Cacher struct {
+	caches.Cacher
+	collector Collector
+}
+
+var _ caches.Cacher = &Cacher{}
+
+// NewCacher creates a cacher which observes the lookups of the cacher
+func NewCacher(cacher caches.Cacher, collector Collector) *Cacher {
+	return &Cacher{
+		Cacher:    cacher,
+		collector: collector,
+	}
+}
+
+// GetIds implements caches.Cacher
+func (c *Cacher) GetIds(tableName, sql string) interface{} {
+	ids := c.Cacher.GetIds(tableName, sql)
+	c.collector.ObserveCache(tableName, ids != nil)
+	return ids
+}
+
+// GetBean implements caches.Cacher
+func (c *Cacher) GetBean(tableName string, id string) interface{} {
+	bean := c.Cacher.GetBean(tableName, id)
+	c.collector.ObserveCache(tableName, bean != nil)
+	return bean
+}
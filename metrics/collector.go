@@ -0,0 +1,222 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package metrics collects the metrics of xorm, i.e. the latencies of statements, the
+// rows, the errors, the transactions, the caches and the connection pool. The metrics
+// are fed by Hook and Cacher, collected by Collector and could be exposed to Prometheus
+// by WritePrometheus.
+package metrics
+
+import (
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Collector collects the metrics
+type Collector interface {
+	// ObserveStatement observes a statement, errKind is empty if no error
+	ObserveStatement(op, table string, duration time.Duration, errKind string)
+	// ObserveRows observes the rows affected by an execution or returned by a query
+	ObserveRows(op, table string, rows int64)
+	// ObserveTransaction observes a transaction, status is commit or rollback
+	ObserveTransaction(status string, duration time.Duration)
+	// ObserveCache observes a cache lookup
+	ObserveCache(table string, hit bool)
+	// ObservePool observes the stats of the connection pool
+	ObservePool(stats sql.DBStats)
+}
+
+// DefaultBuckets are the upper bounds in seconds of the buckets of latency histograms
+var DefaultBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Histogram is a histogram of durations in seconds
+type Histogram struct {
+	// Buckets are the upper bounds of the buckets
+	Buckets []float64
+	// Counts are the counts of the observations less than or equal to the upper bounds
+	Counts []uint64
+	Count  uint64
+	Sum    float64
+}
+
+func newHistogram(buckets []float64) *Histogram {
+	return &Histogram{
+		Buckets: buckets,
+		Counts:  make([]uint64, len(buckets)),
+	}
+}
+
+func (h *Histogram) observe(d time.Duration) {
+	v := d.Seconds()
+	for i, upper := range h.Buckets {
+		if v <= upper {
+			h.Counts[i]++
+		}
+	}
+	h.Count++
+	h.Sum += v
+}
+
+func (h *Histogram) clone() Histogram {
+	c := *h
+	c.Counts = append([]uint64(nil), h.Counts...)
+	return c
+}
+
+// StatementKey identifies the statements of an operation on a table
+type StatementKey struct {
+	Op    string
+	Table string
+}
+
+// ErrorKey identifies the errors of an operation on a table
+type ErrorKey struct {
+	Op    string
+	Table string
+	Kind  string
+}
+
+// CacheKey identifies the cache lookups of a table
+type CacheKey struct {
+	Table string
+	Hit   bool
+}
+
+// Memory is a Collector which keeps the metrics in memory
+type Memory struct {
+	mu           sync.Mutex
+	buckets      []float64
+	statements   map[StatementKey]*Histogram
+	rows         map[StatementKey]int64
+	errors       map[ErrorKey]int64
+	transactions map[string]*Histogram
+	caches       map[CacheKey]int64
+	pool         sql.DBStats
+}
+
+var _ Collector = &Memory{}
+
+// NewMemory creates a memory collector, DefaultBuckets is used if buckets is empty
+func NewMemory(buckets ...float64) *Memory {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &Memory{
+		buckets:      buckets,
+		statements:   make(map[StatementKey]*Histogram),
+		rows:         make(map[StatementKey]int64),
+		errors:       make(map[ErrorKey]int64),
+		transactions: make(map[string]*Histogram),
+		caches:       make(map[CacheKey]int64),
+	}
+}
+
+// ObserveStatement implements Collector
+func (m *Memory) ObserveStatement(op, table string, duration time.Duration, errKind string) {
+	key := StatementKey{op, table}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok := m.statements[key]
+	if !ok {
+		h = newHistogram(m.buckets)
+		m.statements[key] = h
+	}
+	h.observe(duration)
+	if errKind != "" {
+		m.errors[ErrorKey{op, table, errKind}]++
+	}
+}
+
+// ObserveRows implements Collector
+func (m *Memory) ObserveRows(op, table string, rows int64) {
+	m.mu.Lock()
+	m.rows[StatementKey{op, table}] += rows
+	m.mu.Unlock()
+}
+
+// ObserveTransaction implements Collector
+func (m *Memory) ObserveTransaction(status string, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok := m.transactions[status]
+	if !ok {
+		h = newHistogram(m.buckets)
+		m.transactions[status] = h
+	}
+	h.observe(duration)
+}
+
+// ObserveCache implements Collector
+func (m *Memory) ObserveCache(table string, hit bool) {
+	m.mu.Lock()
+	m.caches[CacheKey{table, hit}]++
+	m.mu.Unlock()
+}
+
+// ObservePool implements Collector
+func (m *Memory) ObservePool(stats sql.DBStats) {
+	m.mu.Lock()
+	m.pool = stats
+	m.mu.Unlock()
+}
+
+// Snapshot is a copy of the metrics
+type Snapshot struct {
+	Statements   map[StatementKey]Histogram
+	Rows         map[StatementKey]int64
+	Errors       map[ErrorKey]int64
+	Transactions map[string]Histogram
+	Caches       map[CacheKey]int64
+	Pool         sql.DBStats
+}
+
+// Snapshot returns a copy of the metrics
+func (m *Memory) Snapshot() *Snapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := &Snapshot{
+		Statements:   make(map[StatementKey]Histogram, len(m.statements)),
+		Rows:         make(map[StatementKey]int64, len(m.rows)),
+		Errors:       make(map[ErrorKey]int64, len(m.errors)),
+		Transactions: make(map[string]Histogram, len(m.transactions)),
+		Caches:       make(map[CacheKey]int64, len(m.caches)),
+		Pool:         m.pool,
+	}
+	for k, h := range m.statements {
+		s.Statements[k] = h.clone()
+	}
+	for k, v := range m.rows {
+		s.Rows[k] = v
+	}
+	for k, v := range m.errors {
+		s.Errors[k] = v
+	}
+	for k, h := range m.transactions {
+		s.Transactions[k] = h.clone()
+	}
+	for k, v := range m.caches {
+		s.Caches[k] = v
+	}
+	return s
+}
+
+// Reset clears all the metrics
+func (m *Memory) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.statements = make(map[StatementKey]*Histogram)
+	m.rows = make(map[StatementKey]int64)
+	m.errors = make(map[ErrorKey]int64)
+	m.transactions = make(map[string]*Histogram)
+	m.caches = make(map[CacheKey]int64)
+	m.pool = sql.DBStats{}
+}
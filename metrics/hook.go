@@ -0,0 +1,166 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/laixyz/xormplus/contexts"
+)
+
+// PoolStatsInterval is the min interval to observe the stats of the connection pool
+var PoolStatsInterval = time.Second
+
+type txStartKey struct{}
+
+// Hook is a hook which feeds the statements, the rows and the transactions to the collector
+type Hook struct {
+	collector Collector
+	poolStats func() sql.DBStats
+
+	mu       sync.Mutex
+	lastPool time.Time
+}
+
+var (
+	_ contexts.Hook     = &Hook{}
+	_ contexts.RowsHook = &Hook{}
+)
+
+// NewHook creates a metrics hook, poolStats is called to observe the stats of the
+// connection pool at most once every PoolStatsInterval if it's not nil
+func NewHook(collector Collector, poolStats func() sql.DBStats) *Hook {
+	return &Hook{
+		collector: collector,
+		poolStats: poolStats,
+	}
+}
+
+// BeforeProcess implements contexts.Hook
+func (h *Hook) BeforeProcess(c *contexts.ContextHook) (context.Context, error) {
+	if c.SQL == "BEGIN TRANSACTION" {
+		// the context of the transaction is kept until commit or rollback
+		return context.WithValue(c.Ctx, txStartKey{}, time.Now()), nil
+	}
+	return c.Ctx, nil
+}
+
+// AfterProcess implements contexts.Hook
+func (h *Hook) AfterProcess(c *contexts.ContextHook) error {
+	op, table := ParseStatement(c.SQL)
+	h.collector.ObserveStatement(op, table, c.ExecuteTime, ErrorKind(c.Err))
+
+	switch op {
+	case "commit", "rollback":
+		if start, ok := c.Ctx.Value(txStartKey{}).(time.Time); ok && c.Err == nil {
+			h.collector.ObserveTransaction(op, time.Since(start))
+		}
+	}
+	if c.Result != nil {
+		if rows, err := c.Result.RowsAffected(); err == nil {
+			h.collector.ObserveRows(op, table, rows)
+		}
+	}
+
+	if h.poolStats != nil {
+		now := time.Now()
+		h.mu.Lock()
+		observe := now.Sub(h.lastPool) >= PoolStatsInterval
+		if observe {
+			h.lastPool = now
+		}
+		h.mu.Unlock()
+		if observe {
+			h.collector.ObservePool(h.poolStats())
+		}
+	}
+	return nil
+}
+
+// AfterRows implements contexts.RowsHook
+func (h *Hook) AfterRows(c *contexts.ContextHook, rows int64) {
+	op, table := ParseStatement(c.SQL)
+	h.collector.ObserveRows(op, table, rows)
+}
+
+const identPattern = "(?:`[^`]+`|\"[^\"]+\"|\\[[^\\]]+\\]|[\\w$]+)"
+
+var tableRegexp = regexp.MustCompile("(?i)\\b(?:FROM|INTO|UPDATE|JOIN|TABLE)\\s+(?:IF\\s+(?:NOT\\s+)?EXISTS\\s+)?(" +
+	identPattern + "(?:\\." + identPattern + ")*)")
+
+var identRegexp = regexp.MustCompile(identPattern)
+
+// ParseStatement returns the operation and the table of the SQL, the operation is the
+// lower case of the first keyword like select, insert, update and delete
+func ParseStatement(sqlStr string) (op, table string) {
+	sqlStr = strings.TrimLeft(sqlStr, " \t\r\n(")
+	end := strings.IndexAny(sqlStr, " \t\r\n(")
+	if end < 0 {
+		end = len(sqlStr)
+	}
+	op = strings.ToLower(sqlStr[:end])
+	switch op {
+	case "select", "insert", "update", "delete", "replace", "merge", "with",
+		"create", "drop", "alter", "truncate":
+	case "begin", "commit", "rollback":
+		return op, ""
+	case "":
+		return "other", ""
+	default:
+		op = "other"
+	}
+
+	if matches := tableRegexp.FindStringSubmatch(sqlStr); matches != nil {
+		table = matches[1]
+		// strip the schema
+		if parts := identRegexp.FindAllString(table, -1); len(parts) > 0 {
+			table = parts[len(parts)-1]
+		}
+		table = strings.Trim(table, "`\"[]")
+	}
+	return op, table
+}
+
+// ErrorKind classifies the error, it returns empty if err is nil
+func ErrorKind(err error) string {
+	if err == nil {
+		return ""
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, sql.ErrNoRows):
+		return "no_rows"
+	case errors.Is(err, sql.ErrTxDone):
+		return "tx_done"
+	case errors.Is(err, driver.ErrBadConn), errors.Is(err, sql.ErrConnDone):
+		return "connection"
+	}
+
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "deadlock"):
+		return "deadlock"
+	case strings.Contains(msg, "duplicate"), strings.Contains(msg, "unique"),
+		strings.Contains(msg, "constraint"), strings.Contains(msg, "foreign key"):
+		return "constraint"
+	case strings.Contains(msg, "syntax"):
+		return "syntax"
+	case strings.Contains(msg, "timeout"), strings.Contains(msg, "timed out"):
+		return "timeout"
+	case strings.Contains(msg, "connection"), strings.Contains(msg, "broken pipe"):
+		return "connection"
+	}
+	return "other"
+}
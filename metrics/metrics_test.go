@@ -0,0 +1,160 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/laixyz/xormplus/caches"
+	"github.com/laixyz/xormplus/contexts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStatement(t *testing.T) {
+	var kases = []struct {
+		sql, op, table string
+	}{
+		{"SELECT `id`,`name` FROM `user` WHERE `id`=?", "select", "user"},
+		{"select count(*) from \"public\".\"user\"", "select", "user"},
+		{"INSERT INTO [dbo].[user] (id) VALUES (?)", "insert", "user"},
+		{"UPDATE user SET name=? WHERE id=?", "update", "user"},
+		{"DELETE FROM user WHERE id=?", "delete", "user"},
+		{"CREATE TABLE IF NOT EXISTS `user` (id INTEGER)", "create", "user"},
+		{"(SELECT id FROM a) UNION (SELECT id FROM b)", "select", "a"},
+		{"BEGIN TRANSACTION", "begin", ""},
+		{"COMMIT", "commit", ""},
+		{"PRAGMA table_info(user)", "other", ""},
+		{"", "other", ""},
+	}
+	for _, kase := range kases {
+		op, table := ParseStatement(kase.sql)
+		assert.EqualValues(t, kase.op, op, kase.sql)
+		assert.EqualValues(t, kase.table, table, kase.sql)
+	}
+}
+
+func TestErrorKind(t *testing.T) {
+	assert.EqualValues(t, "", ErrorKind(nil))
+	assert.EqualValues(t, "timeout", ErrorKind(context.DeadlineExceeded))
+	assert.EqualValues(t, "canceled", ErrorKind(fmt.Errorf("query: %w", context.Canceled)))
+	assert.EqualValues(t, "no_rows", ErrorKind(sql.ErrNoRows))
+	assert.EqualValues(t, "tx_done", ErrorKind(sql.ErrTxDone))
+	assert.EqualValues(t, "constraint", ErrorKind(errors.New("UNIQUE constraint failed: user.name")))
+	assert.EqualValues(t, "deadlock", ErrorKind(errors.New("Error 1213: Deadlock found when trying to get lock")))
+	assert.EqualValues(t, "syntax", ErrorKind(errors.New(`near "SELEC": syntax error`)))
+	assert.EqualValues(t, "other", ErrorKind(errors.New("no such table: user")))
+}
+
+func TestMemory(t *testing.T) {
+	m := NewMemory(0.01, 0.1)
+	m.ObserveStatement("select", "user", 5*time.Millisecond, "")
+	m.ObserveStatement("select", "user", 50*time.Millisecond, "")
+	m.ObserveStatement("select", "user", time.Second, "timeout")
+	m.ObserveRows("select", "user", 3)
+	m.ObserveRows("select", "user", 2)
+	m.ObserveTransaction("commit", 20*time.Millisecond)
+	m.ObserveCache("user", true)
+	m.ObserveCache("user", false)
+	m.ObserveCache("user", false)
+	m.ObservePool(sql.DBStats{OpenConnections: 2, InUse: 1, Idle: 1})
+
+	s := m.Snapshot()
+	h := s.Statements[StatementKey{"select", "user"}]
+	assert.EqualValues(t, []uint64{1, 2}, h.Counts)
+	assert.EqualValues(t, 3, h.Count)
+	assert.InDelta(t, 1.055, h.Sum, 1e-9)
+	assert.EqualValues(t, 5, s.Rows[StatementKey{"select", "user"}])
+	assert.EqualValues(t, 1, s.Errors[ErrorKey{"select", "user", "timeout"}])
+	assert.EqualValues(t, 1, s.Transactions["commit"].Count)
+	assert.EqualValues(t, 1, s.Caches[CacheKey{"user", true}])
+	assert.EqualValues(t, 2, s.Caches[CacheKey{"user", false}])
+	assert.EqualValues(t, 2, s.Pool.OpenConnections)
+
+	// the snapshot is not changed by the later observations
+	m.ObserveStatement("select", "user", time.Millisecond, "")
+	assert.EqualValues(t, 3, s.Statements[StatementKey{"select", "user"}].Count)
+	assert.EqualValues(t, 1, s.Statements[StatementKey{"select", "user"}].Counts[0])
+
+	m.Reset()
+	assert.Empty(t, m.Snapshot().Statements)
+}
+
+func TestWritePrometheus(t *testing.T) {
+	m := NewMemory(0.1)
+	m.ObserveStatement("select", `us"er`, 10*time.Millisecond, "")
+	m.ObserveStatement("insert", "user", time.Second, "constraint")
+	m.ObserveRows("insert", "user", 1)
+	m.ObserveTransaction("rollback", 2*time.Second)
+	m.ObserveCache("user", true)
+	m.ObservePool(sql.DBStats{OpenConnections: 3, WaitDuration: 1500 * time.Millisecond})
+
+	var buf bytes.Buffer
+	assert.NoError(t, WritePrometheus(&buf, m))
+	out := buf.String()
+	assert.Contains(t, out, "# TYPE xorm_statement_duration_seconds histogram\n")
+	assert.Contains(t, out, `xorm_statement_duration_seconds_bucket{op="insert",table="user",le="0.1"} 0`+"\n")
+	assert.Contains(t, out, `xorm_statement_duration_seconds_bucket{op="insert",table="user",le="+Inf"} 1`+"\n")
+	assert.Contains(t, out, `xorm_statement_duration_seconds_count{op="select",table="us\"er"} 1`+"\n")
+	assert.Contains(t, out, `xorm_rows_total{op="insert",table="user"} 1`+"\n")
+	assert.Contains(t, out, `xorm_errors_total{op="insert",table="user",kind="constraint"} 1`+"\n")
+	assert.Contains(t, out, `xorm_transaction_duration_seconds_sum{status="rollback"} 2`+"\n")
+	assert.Contains(t, out, `xorm_cache_requests_total{table="user",result="hit"} 1`+"\n")
+	assert.Contains(t, out, "xorm_pool_open_connections 3\n")
+	assert.Contains(t, out, "xorm_pool_wait_duration_seconds_total 1.5\n")
+	// the output is sorted
+	assert.True(t, bytes.Index(buf.Bytes(), []byte(`op="insert"`)) < bytes.Index(buf.Bytes(), []byte(`op="select"`)))
+}
+
+type testResult int64
+
+func (r testResult) LastInsertId() (int64, error) { return 0, nil }
+func (r testResult) RowsAffected() (int64, error) { return int64(r), nil }
+
+func TestHook(t *testing.T) {
+	m := NewMemory()
+	hook := NewHook(m, func() sql.DBStats { return sql.DBStats{OpenConnections: 1} })
+
+	begin := contexts.NewContextHook(context.Background(), "BEGIN TRANSACTION", nil)
+	ctx, err := hook.BeforeProcess(begin)
+	assert.NoError(t, err)
+	assert.NoError(t, hook.AfterProcess(begin))
+
+	exec := contexts.NewContextHook(ctx, "UPDATE `user` SET name=?", []interface{}{"a"})
+	exec.End(ctx, testResult(2), nil)
+	assert.NoError(t, hook.AfterProcess(exec))
+
+	query := contexts.NewContextHook(ctx, "SELECT * FROM `user`", nil)
+	query.End(ctx, nil, nil)
+	assert.NoError(t, hook.AfterProcess(query))
+	hook.AfterRows(query, 4)
+
+	commit := contexts.NewContextHook(ctx, "COMMIT", nil)
+	assert.NoError(t, hook.AfterProcess(commit))
+
+	s := m.Snapshot()
+	assert.EqualValues(t, 2, s.Rows[StatementKey{"update", "user"}])
+	assert.EqualValues(t, 4, s.Rows[StatementKey{"select", "user"}])
+	assert.EqualValues(t, 1, s.Transactions["commit"].Count)
+	assert.EqualValues(t, 1, s.Pool.OpenConnections)
+}
+
+func TestCacher(t *testing.T) {
+	m := NewMemory()
+	cacher := NewCacher(caches.NewLRUCacher(caches.NewMemoryStore(), 100), m)
+	assert.Nil(t, cacher.GetBean("user", "1"))
+	cacher.PutBean("user", "1", "bean")
+	assert.EqualValues(t, "bean", cacher.GetBean("user", "1"))
+	assert.Nil(t, cacher.GetIds("user", "SELECT id FROM user"))
+
+	s := m.Snapshot()
+	assert.EqualValues(t, 1, s.Caches[CacheKey{"user", true}])
+	assert.EqualValues(t, 2, s.Caches[CacheKey{"user", false}])
+}
@@ -0,0 +1,162 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PrometheusNamespace is the prefix of the names of the metrics
+var PrometheusNamespace = "xorm"
+
+var labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func labels(pairs ...string) string {
+	var buf strings.Builder
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(&buf, `%s="%s"`, pairs[i], labelReplacer.Replace(pairs[i+1]))
+	}
+	return buf.String()
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func writeHistogram(w io.Writer, name, lbs string, h Histogram) {
+	sep := ""
+	if lbs != "" {
+		sep = ","
+	}
+	for i, upper := range h.Buckets {
+		fmt.Fprintf(w, "%s_bucket{%s%sle=\"%s\"} %d\n", name, lbs, sep, formatFloat(upper), h.Counts[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{%s%sle=\"+Inf\"} %d\n", name, lbs, sep, h.Count)
+	fmt.Fprintf(w, "%s_sum{%s} %s\n", name, lbs, formatFloat(h.Sum))
+	fmt.Fprintf(w, "%s_count{%s} %d\n", name, lbs, h.Count)
+}
+
+func sortedStatementKeys(m map[StatementKey]Histogram) []StatementKey {
+	keys := make([]StatementKey, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Op != keys[j].Op {
+			return keys[i].Op < keys[j].Op
+		}
+		return keys[i].Table < keys[j].Table
+	})
+	return keys
+}
+
+// WritePrometheus writes the metrics of the memory collector in Prometheus text exposition format
+func WritePrometheus(w io.Writer, m *Memory) error {
+	s := m.Snapshot()
+	bw := bufio.NewWriter(w)
+	ns := PrometheusNamespace
+
+	name := ns + "_statement_duration_seconds"
+	fmt.Fprintf(bw, "# HELP %s The latencies of the statements.\n# TYPE %s histogram\n", name, name)
+	for _, k := range sortedStatementKeys(s.Statements) {
+		writeHistogram(bw, name, labels("op", k.Op, "table", k.Table), s.Statements[k])
+	}
+
+	name = ns + "_rows_total"
+	fmt.Fprintf(bw, "# HELP %s The rows affected or returned by the statements.\n# TYPE %s counter\n", name, name)
+	rowKeys := make([]StatementKey, 0, len(s.Rows))
+	for k := range s.Rows {
+		rowKeys = append(rowKeys, k)
+	}
+	sort.Slice(rowKeys, func(i, j int) bool {
+		return rowKeys[i].Op+"\x00"+rowKeys[i].Table < rowKeys[j].Op+"\x00"+rowKeys[j].Table
+	})
+	for _, k := range rowKeys {
+		fmt.Fprintf(bw, "%s{%s} %d\n", name, labels("op", k.Op, "table", k.Table), s.Rows[k])
+	}
+
+	name = ns + "_errors_total"
+	fmt.Fprintf(bw, "# HELP %s The errors of the statements.\n# TYPE %s counter\n", name, name)
+	errKeys := make([]ErrorKey, 0, len(s.Errors))
+	for k := range s.Errors {
+		errKeys = append(errKeys, k)
+	}
+	sort.Slice(errKeys, func(i, j int) bool {
+		return errKeys[i].Op+"\x00"+errKeys[i].Table+"\x00"+errKeys[i].Kind <
+			errKeys[j].Op+"\x00"+errKeys[j].Table+"\x00"+errKeys[j].Kind
+	})
+	for _, k := range errKeys {
+		fmt.Fprintf(bw, "%s{%s} %d\n", name, labels("op", k.Op, "table", k.Table, "kind", k.Kind), s.Errors[k])
+	}
+
+	name = ns + "_transaction_duration_seconds"
+	fmt.Fprintf(bw, "# HELP %s The durations of the transactions.\n# TYPE %s histogram\n", name, name)
+	statuses := make([]string, 0, len(s.Transactions))
+	for status := range s.Transactions {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	for _, status := range statuses {
+		writeHistogram(bw, name, labels("status", status), s.Transactions[status])
+	}
+
+	name = ns + "_cache_requests_total"
+	fmt.Fprintf(bw, "# HELP %s The lookups of the caches.\n# TYPE %s counter\n", name, name)
+	cacheKeys := make([]CacheKey, 0, len(s.Caches))
+	for k := range s.Caches {
+		cacheKeys = append(cacheKeys, k)
+	}
+	sort.Slice(cacheKeys, func(i, j int) bool {
+		if cacheKeys[i].Table != cacheKeys[j].Table {
+			return cacheKeys[i].Table < cacheKeys[j].Table
+		}
+		return cacheKeys[i].Hit && !cacheKeys[j].Hit
+	})
+	for _, k := range cacheKeys {
+		result := "miss"
+		if k.Hit {
+			result = "hit"
+		}
+		fmt.Fprintf(bw, "%s{%s} %d\n", name, labels("table", k.Table, "result", result), s.Caches[k])
+	}
+
+	var gauges = []struct {
+		name, help, typ string
+		value           float64
+	}{
+		{"pool_max_open_connections", "The max number of open connections.", "gauge", float64(s.Pool.MaxOpenConnections)},
+		{"pool_open_connections", "The number of open connections.", "gauge", float64(s.Pool.OpenConnections)},
+		{"pool_in_use_connections", "The number of connections in use.", "gauge", float64(s.Pool.InUse)},
+		{"pool_idle_connections", "The number of idle connections.", "gauge", float64(s.Pool.Idle)},
+		{"pool_wait_count_total", "The number of connections waited for.", "counter", float64(s.Pool.WaitCount)},
+		{"pool_wait_duration_seconds_total", "The time blocked waiting for connections.", "counter", s.Pool.WaitDuration.Seconds()},
+		{"pool_max_idle_closed_total", "The connections closed due to max idle connections.", "counter", float64(s.Pool.MaxIdleClosed)},
+		{"pool_max_lifetime_closed_total", "The connections closed due to max lifetime.", "counter", float64(s.Pool.MaxLifetimeClosed)},
+	}
+	for _, g := range gauges {
+		name = ns + "_" + g.name
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s %s\n%s %s\n", name, g.help, name, g.typ, name, formatFloat(g.value))
+	}
+	return bw.Flush()
+}
+
+// PrometheusHandler returns a http handler which exposes the metrics of the memory collector
+func PrometheusHandler(m *Memory) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := WritePrometheus(w, m); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
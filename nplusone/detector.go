@@ -0,0 +1,172 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package nplusone detects the N+1 queries in development, i.e. the loops calling Get for
+// every row. The statements are counted by their fingerprints in a scope which is carried
+// by the context, usually a scope per request, and the shape executed more than the
+// threshold times in a scope is reported with the stack of its first execution.
+package nplusone
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+
+	"github.com/laixyz/xormplus/contexts"
+	"github.com/laixyz/xormplus/querystats"
+)
+
+// DefaultThreshold is the default max times of a query shape in a scope
+const DefaultThreshold = 10
+
+// Report reports a query shape executed more than the threshold times in a scope
+type Report struct {
+	Fingerprint string
+	Count       int
+	Threshold   int
+	// Stack is the stack of the first execution of the query shape
+	Stack string
+}
+
+func (r *Report) Error() string {
+	return fmt.Sprintf("N+1 query detected: %s executed more than %d times, first executed at:\n%s",
+		r.Fingerprint, r.Threshold, r.Stack)
+}
+
+type shape struct {
+	count  int
+	stack  string
+	report *Report
+}
+
+type scope struct {
+	mu      sync.Mutex
+	shapes  map[string]*shape
+	reports []*Report
+}
+
+type scopeKey struct{}
+
+// WithScope returns a context which carries a new scope, the statements executed with the
+// context are counted in the scope
+func WithScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, scopeKey{}, &scope{shapes: make(map[string]*shape)})
+}
+
+// HasScope returns whether the context carries a scope
+func HasScope(ctx context.Context) bool {
+	_, ok := ctx.Value(scopeKey{}).(*scope)
+	return ok
+}
+
+// Reports returns copies of the reports of the scope carried by the context
+func Reports(ctx context.Context) []Report {
+	s, ok := ctx.Value(scopeKey{}).(*scope)
+	if !ok {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reports := make([]Report, 0, len(s.reports))
+	for _, r := range s.reports {
+		reports = append(reports, *r)
+	}
+	return reports
+}
+
+// Detector is a hook which detects the N+1 queries
+type Detector struct {
+	mu        sync.Mutex
+	threshold int
+	fail      bool
+	onDetect  func(*Report)
+}
+
+var _ contexts.Hook = &Detector{}
+
+// NewDetector creates a detector, DefaultThreshold is used if threshold is not positive
+func NewDetector(threshold int) *Detector {
+	if threshold <= 0 {
+		threshold = DefaultThreshold
+	}
+	return &Detector{threshold: threshold}
+}
+
+// OnDetect sets the function called when a query shape exceeds the threshold the first
+// time in a scope, i.e. to log a warning or to fail a test
+func (d *Detector) OnDetect(fn func(*Report)) *Detector {
+	d.mu.Lock()
+	d.onDetect = fn
+	d.mu.Unlock()
+	return d
+}
+
+// SetFail sets whether the statements exceeding the threshold fail with the report
+func (d *Detector) SetFail(fail bool) *Detector {
+	d.mu.Lock()
+	d.fail = fail
+	d.mu.Unlock()
+	return d
+}
+
+// BeforeProcess implements contexts.Hook
+func (d *Detector) BeforeProcess(c *contexts.ContextHook) (context.Context, error) {
+	switch c.SQL {
+	case "", "BEGIN TRANSACTION", "COMMIT", "ROLLBACK", "PREPARE":
+		return c.Ctx, nil
+	}
+	s, ok := c.Ctx.Value(scopeKey{}).(*scope)
+	if !ok {
+		return c.Ctx, nil
+	}
+
+	d.mu.Lock()
+	threshold, fail, onDetect := d.threshold, d.fail, d.onDetect
+	d.mu.Unlock()
+
+	fingerprint := querystats.Fingerprint(c.SQL)
+	s.mu.Lock()
+	sh, ok := s.shapes[fingerprint]
+	if !ok {
+		sh = &shape{stack: string(debug.Stack())}
+		s.shapes[fingerprint] = sh
+	}
+	sh.count++
+	var report *Report
+	if sh.count > threshold {
+		first := sh.report == nil
+		if first {
+			sh.report = &Report{
+				Fingerprint: fingerprint,
+				Threshold:   threshold,
+				Stack:       sh.stack,
+			}
+			s.reports = append(s.reports, sh.report)
+		}
+		sh.report.Count = sh.count
+		r := *sh.report
+		report = &r
+		if !first {
+			onDetect = nil
+		}
+	}
+	s.mu.Unlock()
+
+	if report == nil {
+		return c.Ctx, nil
+	}
+	if onDetect != nil {
+		onDetect(report)
+	}
+	if fail {
+		return c.Ctx, report
+	}
+	return c.Ctx, nil
+}
+
+// AfterProcess implements contexts.Hook
+func (d *Detector) AfterProcess(c *contexts.ContextHook) error {
+	return nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nplusone
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/laixyz/xormplus/contexts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetector(t *testing.T) {
+	var detected []*Report
+	d := NewDetector(2).OnDetect(func(r *Report) {
+		detected = append(detected, r)
+	})
+
+	ctx := WithScope(context.Background())
+	for i := 0; i < 5; i++ {
+		_, err := d.BeforeProcess(contexts.NewContextHook(ctx, fmt.Sprintf("SELECT * FROM user WHERE id=%d", i), nil))
+		assert.NoError(t, err)
+		_, err = d.BeforeProcess(contexts.NewContextHook(ctx, "COMMIT", nil))
+		assert.NoError(t, err)
+	}
+	_, err := d.BeforeProcess(contexts.NewContextHook(ctx, "SELECT * FROM role", nil))
+	assert.NoError(t, err)
+
+	// the detection is notified once
+	if assert.EqualValues(t, 1, len(detected)) {
+		assert.EqualValues(t, "SELECT * FROM user WHERE id=?", detected[0].Fingerprint)
+		assert.EqualValues(t, 3, detected[0].Count)
+		assert.Contains(t, detected[0].Stack, "detector_test.go")
+	}
+	reports := Reports(ctx)
+	if assert.EqualValues(t, 1, len(reports)) {
+		assert.EqualValues(t, 5, reports[0].Count)
+	}
+
+	// the statements without scope are not counted
+	for i := 0; i < 5; i++ {
+		_, err := d.BeforeProcess(contexts.NewContextHook(context.Background(), "SELECT 1", nil))
+		assert.NoError(t, err)
+	}
+	assert.Nil(t, Reports(context.Background()))
+	assert.EqualValues(t, 1, len(detected))
+}
+
+func TestDetectorFail(t *testing.T) {
+	d := NewDetector(1).SetFail(true)
+	ctx := WithScope(context.Background())
+	_, err := d.BeforeProcess(contexts.NewContextHook(ctx, "SELECT 1", nil))
+	assert.NoError(t, err)
+	_, err = d.BeforeProcess(contexts.NewContextHook(ctx, "SELECT 2", nil))
+	if assert.Error(t, err) {
+		report, ok := err.(*Report)
+		assert.True(t, ok)
+		assert.EqualValues(t, 2, report.Count)
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package querystats keeps the statistics of the statements in process like
+// pg_stat_statements. The statements are grouped by their fingerprints which strip the
+// literals, the comments and the redundant whitespaces.
+package querystats
+
+import (
+	"hash/fnv"
+	"regexp"
+	"strings"
+)
+
+var (
+	inListRegexp     = regexp.MustCompile(`(?i)\bIN\s*\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	valuesListRegexp = regexp.MustCompile(`(?i)\b(VALUES\s*\([^()]*\))(?:\s*,\s*\([^()]*\))+`)
+)
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// Fingerprint normalizes the SQL to a stable fingerprint, the string and number literals
+// and the placeholders, i.e. ?, $1 and :1, are replaced by ?, the lists of IN and the rows of VALUES are
+// collapsed, the comments are stripped and the whitespaces are normalized.
+func Fingerprint(sqlStr string) string {
+	var buf strings.Builder
+	buf.Grow(len(sqlStr))
+	var space bool
+	write := func(s string) {
+		if space && buf.Len() > 0 {
+			last := buf.String()[buf.Len()-1]
+			if last != '(' && s[0] != ')' && s[0] != ',' {
+				buf.WriteByte(' ')
+			}
+		}
+		space = false
+		buf.WriteString(s)
+	}
+
+	for i := 0; i < len(sqlStr); {
+		c := sqlStr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			space = true
+			i++
+		case c == '-' && i+1 < len(sqlStr) && sqlStr[i+1] == '-':
+			end := strings.IndexByte(sqlStr[i:], '\n')
+			if end < 0 {
+				end = len(sqlStr) - i
+			}
+			space = true
+			i += end
+		case c == '/' && i+1 < len(sqlStr) && sqlStr[i+1] == '*':
+			end := strings.Index(sqlStr[i+2:], "*/")
+			if end < 0 {
+				i = len(sqlStr)
+			} else {
+				i += end + 4
+			}
+			space = true
+		case c == '\'':
+			// string literal, '' and \' are escaped quotes
+			j := i + 1
+			for j < len(sqlStr) {
+				if sqlStr[j] == '\\' {
+					j += 2
+					continue
+				}
+				if sqlStr[j] == '\'' {
+					if j+1 < len(sqlStr) && sqlStr[j+1] == '\'' {
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+			write("?")
+			i = j + 1
+		case c == '"' || c == '`' || c == '[':
+			// quoted identifier
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			end := strings.IndexByte(sqlStr[i+1:], closing)
+			if end < 0 {
+				end = len(sqlStr) - i - 1
+			}
+			write(sqlStr[i : i+end+2])
+			i += end + 2
+		case (c == '$' || c == ':' && (i == 0 || sqlStr[i-1] != ':')) && i+1 < len(sqlStr) && isDigit(sqlStr[i+1]):
+			// placeholder of postgres or oracle
+			j := i + 1
+			for j < len(sqlStr) && isDigit(sqlStr[j]) {
+				j++
+			}
+			write("?")
+			i = j
+		case isDigit(c) || c == '.' && i+1 < len(sqlStr) && isDigit(sqlStr[i+1]):
+			// number literal including decimal, exponent and hex
+			j := i + 1
+			for j < len(sqlStr) && (isIdentByte(sqlStr[j]) || sqlStr[j] == '.' ||
+				(sqlStr[j] == '+' || sqlStr[j] == '-') && (sqlStr[j-1] == 'e' || sqlStr[j-1] == 'E')) {
+				j++
+			}
+			write("?")
+			i = j
+		case isIdentByte(c):
+			j := i + 1
+			for j < len(sqlStr) && isIdentByte(sqlStr[j]) {
+				j++
+			}
+			write(sqlStr[i:j])
+			i = j
+		default:
+			if c == ';' && strings.TrimSpace(sqlStr[i+1:]) == "" {
+				i = len(sqlStr)
+				continue
+			}
+			write(sqlStr[i : i+1])
+			i++
+		}
+	}
+
+	s := inListRegexp.ReplaceAllString(buf.String(), "IN (...)")
+	return valuesListRegexp.ReplaceAllString(s, "$1")
+}
+
+// ID returns the hash of the fingerprint
+func ID(fingerprint string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(fingerprint))
+	return h.Sum64()
+}
@@ -0,0 +1,64 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package querystats
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/laixyz/xormplus/contexts"
+)
+
+// Hook is a hook which feeds the statements to the registry
+type Hook struct {
+	registry *Registry
+}
+
+var (
+	_ contexts.Hook     = &Hook{}
+	_ contexts.RowsHook = &Hook{}
+)
+
+// NewHook creates a hook of the registry
+func NewHook(registry *Registry) *Hook {
+	return &Hook{registry: registry}
+}
+
+func isStatement(sqlStr string) bool {
+	switch sqlStr {
+	case "", "BEGIN TRANSACTION", "COMMIT", "ROLLBACK", "PREPARE":
+		return false
+	}
+	return true
+}
+
+// BeforeProcess implements contexts.Hook
+func (h *Hook) BeforeProcess(c *contexts.ContextHook) (context.Context, error) {
+	return c.Ctx, nil
+}
+
+// AfterProcess implements contexts.Hook
+func (h *Hook) AfterProcess(c *contexts.ContextHook) error {
+	if !isStatement(c.SQL) {
+		return nil
+	}
+	var rows int64
+	if c.Result != nil {
+		if affected, err := c.Result.RowsAffected(); err == nil {
+			rows = affected
+		}
+	}
+	failed := c.Err != nil && !errors.Is(c.Err, sql.ErrNoRows)
+	h.registry.Observe(c.SQL, c.ExecuteTime, rows, failed)
+	return nil
+}
+
+// AfterRows implements contexts.RowsHook
+func (h *Hook) AfterRows(c *contexts.ContextHook, rows int64) {
+	if isStatement(c.SQL) {
+		h.registry.AddRows(c.SQL, rows)
+	}
+}
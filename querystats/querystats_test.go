@@ -0,0 +1,103 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package querystats
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/laixyz/xormplus/contexts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFingerprint(t *testing.T) {
+	var kases = []struct {
+		sql, fingerprint string
+	}{
+		{"SELECT * FROM user WHERE id=1", "SELECT * FROM user WHERE id=?"},
+		{"SELECT  *\n FROM user\tWHERE id = ? ;", "SELECT * FROM user WHERE id = ?"},
+		{"SELECT * FROM user WHERE name='it''s' AND note='a\\'b'", "SELECT * FROM user WHERE name=? AND note=?"},
+		{"SELECT * FROM \"user\" WHERE id=$1 AND score>-1.5e3", "SELECT * FROM \"user\" WHERE id=? AND score>-?"},
+		{"SELECT `col2`, t1.id FROM `user 1` t1", "SELECT `col2`, t1.id FROM `user 1` t1"},
+		{"SELECT * FROM user WHERE id IN (1, 2, 3)", "SELECT * FROM user WHERE id IN (...)"},
+		{"SELECT * FROM user WHERE id in (?,?)", "SELECT * FROM user WHERE id IN (...)"},
+		{"SELECT * FROM \"user\" WHERE id IN ($1,$2)", "SELECT * FROM \"user\" WHERE id IN (...)"},
+		{"SELECT * FROM \"user\" WHERE id IN (:1, :2, :3) AND name=:4", "SELECT * FROM \"user\" WHERE id IN (...) AND name=?"},
+		{"SELECT a::int FROM t", "SELECT a::int FROM t"},
+		{"INSERT INTO user (id,name) VALUES (?,?),(?,?), (3,'c')", "INSERT INTO user (id,name) VALUES (?,?)"},
+		{"SELECT 1 /* app=a */ -- comment\nFROM [dbo].[user]", "SELECT ? FROM [dbo].[user]"},
+		{"SELECT * FROM user WHERE data=0x1F", "SELECT * FROM user WHERE data=?"},
+		{"SELECT count( * ) FROM user", "SELECT count(*) FROM user"},
+	}
+	for _, kase := range kases {
+		assert.EqualValues(t, kase.fingerprint, Fingerprint(kase.sql), kase.sql)
+	}
+	assert.EqualValues(t, ID(Fingerprint("SELECT 1")), ID(Fingerprint("SELECT 2")))
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	for i := 1; i <= 100; i++ {
+		r.Observe("SELECT * FROM user WHERE id IN (1,2)", time.Duration(i)*time.Millisecond, 0, false)
+	}
+	r.AddRows("SELECT * FROM user WHERE id IN (1,2)", 5)
+	r.Observe("SELECT * FROM user WHERE id IN (1,2,3)", time.Millisecond, 0, true)
+	r.Observe("UPDATE user SET name='a'", 10*time.Second, 3, false)
+
+	stats := r.Snapshot()
+	assert.EqualValues(t, 2, len(stats))
+	assert.EqualValues(t, "UPDATE user SET name=?", stats[0].Fingerprint)
+	assert.EqualValues(t, 3, stats[0].Rows)
+
+	s := stats[1]
+	assert.EqualValues(t, "SELECT * FROM user WHERE id IN (...)", s.Fingerprint)
+	assert.EqualValues(t, 101, s.Calls)
+	assert.EqualValues(t, 1, s.Errors)
+	assert.EqualValues(t, 5, s.Rows)
+	assert.EqualValues(t, time.Millisecond, s.MinTime)
+	assert.EqualValues(t, 100*time.Millisecond, s.MaxTime)
+	assert.EqualValues(t, 5051*time.Millisecond, s.TotalTime)
+	assert.EqualValues(t, 5051*time.Millisecond/101, s.MeanTime)
+	assert.EqualValues(t, 99*time.Millisecond, s.P99Time)
+
+	assert.EqualValues(t, 1, len(r.Top(1)))
+
+	r.Reset()
+	assert.Empty(t, r.Snapshot())
+}
+
+func TestRegistryLimits(t *testing.T) {
+	r := NewRegistry().SetMaxEntries(2).SetMaxSamples(2)
+	r.Observe("SELECT a FROM t", time.Second, 0, false)
+	r.Observe("SELECT a FROM t", 3*time.Second, 0, false)
+	r.Observe("SELECT a FROM t", 2*time.Second, 0, false)
+	r.Observe("SELECT b FROM t", time.Second, 0, false)
+	r.Observe("SELECT c FROM t", time.Second, 0, false)
+
+	stats := r.Snapshot()
+	assert.EqualValues(t, 2, len(stats))
+	assert.EqualValues(t, "SELECT a FROM t", stats[0].Fingerprint)
+	// only the latest two samples are kept
+	assert.EqualValues(t, 3*time.Second, stats[0].P99Time)
+	assert.EqualValues(t, "SELECT c FROM t", stats[1].Fingerprint)
+}
+
+func TestHook(t *testing.T) {
+	r := NewRegistry()
+	hook := NewHook(r)
+
+	for _, sqlStr := range []string{"BEGIN TRANSACTION", "SELECT * FROM user", "COMMIT"} {
+		c := contexts.NewContextHook(context.Background(), sqlStr, nil)
+		c.End(context.Background(), nil, nil)
+		assert.NoError(t, hook.AfterProcess(c))
+		hook.AfterRows(c, 2)
+	}
+
+	stats := r.Snapshot()
+	assert.EqualValues(t, 1, len(stats))
+	assert.EqualValues(t, 1, stats[0].Calls)
+	assert.EqualValues(t, 2, stats[0].Rows)
+}
@@ -0,0 +1,224 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package querystats
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// default limitations of the registry
+const (
+	DefaultMaxEntries = 5000
+	DefaultMaxSamples = 1000
+)
+
+// QueryStat is the statistics of the statements with the same fingerprint
+type QueryStat struct {
+	ID          uint64
+	Fingerprint string
+	Calls       int64
+	Errors      int64
+	Rows        int64
+	TotalTime   time.Duration
+	MinTime     time.Duration
+	MaxTime     time.Duration
+	MeanTime    time.Duration
+	// P99Time is computed from the latest samples
+	P99Time   time.Duration
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+type entry struct {
+	stat    QueryStat
+	samples []time.Duration
+	next    int // the next index of samples to be overwritten
+}
+
+// Registry keeps the statistics of the statements grouped by the fingerprints
+type Registry struct {
+	mu         sync.Mutex
+	maxEntries int
+	maxSamples int
+	entries    map[uint64]*entry
+	// fingerprints caches the fingerprints of the raw statements
+	fingerprints map[string]uint64
+}
+
+// NewRegistry creates a registry
+func NewRegistry() *Registry {
+	return &Registry{
+		maxEntries:   DefaultMaxEntries,
+		maxSamples:   DefaultMaxSamples,
+		entries:      make(map[uint64]*entry),
+		fingerprints: make(map[string]uint64),
+	}
+}
+
+// SetMaxEntries sets the max number of the fingerprints kept, the least called one is
+// evicted when it's exceeded
+func (r *Registry) SetMaxEntries(max int) *Registry {
+	r.mu.Lock()
+	r.maxEntries = max
+	r.mu.Unlock()
+	return r
+}
+
+// SetMaxSamples sets the max number of the latest durations kept for every fingerprint to
+// compute the percentiles
+func (r *Registry) SetMaxSamples(max int) *Registry {
+	r.mu.Lock()
+	r.maxSamples = max
+	r.mu.Unlock()
+	return r
+}
+
+// get returns the entry of the statement, r.mu should be locked
+func (r *Registry) get(sqlStr string, now time.Time) *entry {
+	id, ok := r.fingerprints[sqlStr]
+	var fingerprint string
+	if !ok {
+		fingerprint = Fingerprint(sqlStr)
+		id = ID(fingerprint)
+		if r.maxEntries > 0 && len(r.fingerprints) >= r.maxEntries*4 {
+			r.fingerprints = make(map[string]uint64)
+		}
+		r.fingerprints[sqlStr] = id
+	}
+	e, ok := r.entries[id]
+	if ok {
+		return e
+	}
+	if fingerprint == "" {
+		fingerprint = Fingerprint(sqlStr)
+	}
+
+	if r.maxEntries > 0 && len(r.entries) >= r.maxEntries {
+		r.evict()
+	}
+	e = &entry{
+		stat: QueryStat{
+			ID:          id,
+			Fingerprint: fingerprint,
+			FirstSeen:   now,
+		},
+	}
+	r.entries[id] = e
+	return e
+}
+
+// evict removes the least called entry, r.mu should be locked
+func (r *Registry) evict() {
+	var (
+		minID    uint64
+		minCalls int64 = math.MaxInt64
+	)
+	for id, e := range r.entries {
+		if e.stat.Calls < minCalls {
+			minID, minCalls = id, e.stat.Calls
+		}
+	}
+	delete(r.entries, minID)
+}
+
+// Observe observes an execution of the statement
+func (r *Registry) Observe(sqlStr string, duration time.Duration, rows int64, failed bool) {
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e := r.get(sqlStr, now)
+	s := &e.stat
+	s.Calls++
+	if failed {
+		s.Errors++
+	}
+	s.Rows += rows
+	s.TotalTime += duration
+	if s.Calls == 1 || duration < s.MinTime {
+		s.MinTime = duration
+	}
+	if duration > s.MaxTime {
+		s.MaxTime = duration
+	}
+	s.LastSeen = now
+
+	if r.maxSamples > 0 {
+		if len(e.samples) < r.maxSamples {
+			e.samples = append(e.samples, duration)
+		} else {
+			e.samples[e.next] = duration
+			e.next = (e.next + 1) % len(e.samples)
+		}
+	}
+}
+
+// AddRows adds the rows returned by the statement which has been observed
+func (r *Registry) AddRows(sqlStr string, rows int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if id, ok := r.fingerprints[sqlStr]; ok {
+		if e, ok := r.entries[id]; ok {
+			e.stat.Rows += rows
+		}
+	}
+}
+
+func percentile(samples []time.Duration, p float64) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return sorted[idx]
+}
+
+// Snapshot returns the statistics ordered by the total time descending
+func (r *Registry) Snapshot() []QueryStat {
+	r.mu.Lock()
+	stats := make([]QueryStat, 0, len(r.entries))
+	for _, e := range r.entries {
+		s := e.stat
+		if s.Calls > 0 {
+			s.MeanTime = s.TotalTime / time.Duration(s.Calls)
+		}
+		s.P99Time = percentile(e.samples, 0.99)
+		stats = append(stats, s)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].TotalTime != stats[j].TotalTime {
+			return stats[i].TotalTime > stats[j].TotalTime
+		}
+		return stats[i].Fingerprint < stats[j].Fingerprint
+	})
+	return stats
+}
+
+// Top returns the n statistics with the most total time
+func (r *Registry) Top(n int) []QueryStat {
+	stats := r.Snapshot()
+	if n >= 0 && len(stats) > n {
+		stats = stats[:n]
+	}
+	return stats
+}
+
+// Reset clears the statistics
+func (r *Registry) Reset() {
+	r.mu.Lock()
+	r.entries = make(map[uint64]*entry)
+	r.fingerprints = make(map[string]uint64)
+	r.mu.Unlock()
+}
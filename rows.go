@@ -99,7 +99,11 @@ func (rows *Rows) Next() bool {
 	if rows.lastError == nil && rows.rows != nil {
 		hasNext := rows.rows.Next()
 		if !hasNext {
-			rows.lastError = sql.ErrNoRows
+			if err := rows.rows.Err(); err != nil {
+				rows.lastError = err
+			} else {
+				rows.lastError = sql.ErrNoRows
+			}
 		}
 		return hasNext
 	}
@@ -13,6 +13,7 @@ import (
 const (
 	IndexType = iota + 1
 	UniqueType
+	FulltextType
 )
 
 // Index represents a database index
@@ -30,12 +31,16 @@ func NewIndex(name string, indexType int) *Index {
 
 func (index *Index) XName(tableName string) string {
 	if !strings.HasPrefix(index.Name, "UQE_") &&
-		!strings.HasPrefix(index.Name, "IDX_") {
+		!strings.HasPrefix(index.Name, "IDX_") &&
+		!strings.HasPrefix(index.Name, "FTS_") {
 		tableParts := strings.Split(strings.Replace(tableName, `"`, "", -1), ".")
 		tableName = tableParts[len(tableParts)-1]
 		if index.Type == UniqueType {
 			return fmt.Sprintf("UQE_%v_%v", tableName, index.Name)
 		}
+		if index.Type == FulltextType {
+			return fmt.Sprintf("FTS_%v_%v", tableName, index.Name)
+		}
 		return fmt.Sprintf("IDX_%v_%v", tableName, index.Name)
 	}
 	return index.Name
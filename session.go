@@ -18,12 +18,14 @@ import (
 	"strings"
 	"time"
 
+	"github.com/laixyz/xormplus/builder"
 	"github.com/laixyz/xormplus/contexts"
 	"github.com/laixyz/xormplus/convert"
 	"github.com/laixyz/xormplus/core"
-	"github.com/laixyz/xormplus/internal/json"
+	"github.com/laixyz/xormplus/dialects"
 	"github.com/laixyz/xormplus/internal/statements"
 	"github.com/laixyz/xormplus/log"
+	"github.com/laixyz/xormplus/nplusone"
 	"github.com/laixyz/xormplus/schemas"
 )
 
@@ -84,7 +86,30 @@ type Session struct {
 	lastSQL     string
 	lastSQLArgs []interface{}
 
+	// dryRun records the SQL instead of executing it if it's not nil
+	dryRun *DryRunSQL
+
+	safeMode       bool
+	allowFullTable bool
+
+	// timeout is the timeout of every statement and every transaction
+	timeout   time.Duration
+	txCancel  context.CancelFunc
+	txRelease func()
+
+	// admitted is the number of the statements of the session holding its admission,
+	// releaseAdmission releases the admission when the last of them is done
+	admitted         int
+	releaseAdmission func()
+
+	// inTempTables are the temporary tables of the IN conditions in the transaction
+	inTempTables []string
+
+	// leakRecord records the session in the leak tracker of the engine
+	leakRecord *sessionRecord
+
 	ctx         context.Context
+	ctxBeforeTx context.Context
 	sessionType sessionType
 }
 
@@ -106,6 +131,9 @@ func newSession(engine *Engine) *Session {
 	} else {
 		ctx = engine.defaultContext
 	}
+	if engine.nPlusOne != nil && !nplusone.HasScope(ctx) {
+		ctx = nplusone.WithScope(ctx)
+	}
 
 	session := &Session{
 		ctx:    ctx,
@@ -135,10 +163,16 @@ func newSession(engine *Engine) *Session {
 		lastSQLArgs: make([]interface{}, 0),
 
 		sessionType: engineSession,
+		safeMode:    engine.safeMode,
+		timeout:     engine.queryTimeout,
 	}
+	session.statement.SetJSONHandler(engine.jsonHandler)
 	if engine.logSessionID {
 		session.ctx = context.WithValue(session.ctx, log.SessionKey, session)
 	}
+	if tracker := engine.getLeakTracker(); tracker != nil {
+		tracker.track(session)
+	}
 	return session
 }
 
@@ -161,6 +195,9 @@ func (session *Session) Close() error {
 		session.tx = nil
 		session.stmtCache = nil
 		session.isClosed = true
+		if session.leakRecord != nil {
+			session.engine.getLeakTracker().untrack(session)
+		}
 	}
 	return nil
 }
@@ -194,6 +231,7 @@ func (session *Session) IsClosed() bool {
 func (session *Session) resetStatement() {
 	if session.autoResetStatement {
 		session.statement.Reset()
+		session.allowFullTable = false
 	}
 }
 
@@ -241,7 +279,31 @@ func (session *Session) NoCascade() *Session {
 
 // ForUpdate Set Read/Write locking for UPDATE
 func (session *Session) ForUpdate() *Session {
-	session.statement.IsForUpdate = true
+	session.statement.ForUpdate()
+	return session
+}
+
+// ForShare Set shared locking on the rows read, i.e. FOR SHARE or LOCK IN SHARE MODE
+func (session *Session) ForShare() *Session {
+	session.statement.ForShare()
+	return session
+}
+
+// NoWait makes the locking fail immediately when the rows are locked by others
+func (session *Session) NoWait() *Session {
+	session.statement.Lock(dialects.LockNoWait)
+	return session
+}
+
+// SkipLocked skips the rows which are locked by others, FOR UPDATE is used if no lock set
+func (session *Session) SkipLocked() *Session {
+	session.statement.Lock(dialects.LockSkipLocked)
+	return session
+}
+
+// Lock sets the lock mode of the rows read, i.e. dialects.LockForUpdate|dialects.LockSkipLocked
+func (session *Session) Lock(mode dialects.LockMode) *Session {
+	session.statement.Lock(mode)
 	return session
 }
 
@@ -319,6 +381,20 @@ func (session *Session) Join(joinOperator string, tablename interface{}, conditi
 	return session
 }
 
+// With adds a common table expression which could be referred by Table, Join or
+// the conditions of the select statements, i.e. Find, Get, Iterate and Count
+func (session *Session) With(name string, cte *builder.Builder) *Session {
+	session.statement.With(name, cte, false)
+	return session
+}
+
+// WithRecursive adds a recursive common table expression, name could contain the
+// column list, i.e. "tree(id, parent_id)"
+func (session *Session) WithRecursive(name string, cte *builder.Builder) *Session {
+	session.statement.With(name, cte, true)
+	return session
+}
+
 // GroupBy Generate Group By statement
 func (session *Session) GroupBy(keys string) *Session {
 	session.statement.GroupBy(keys)
@@ -342,6 +418,7 @@ func (session *Session) canCache() bool {
 		session.statement.RawSQL != "" ||
 		!session.statement.UseCache ||
 		session.statement.IsForUpdate ||
+		session.statement.HasCTE() ||
 		session.tx != nil ||
 		len(session.statement.SelectStr) > 0 {
 		return false
@@ -349,13 +426,18 @@ func (session *Session) canCache() bool {
 	return true
 }
 
-func (session *Session) doPrepare(db *core.DB, sqlStr string) (stmt *core.Stmt, err error) {
+func (session *Session) doPrepare(ctx context.Context, db *core.DB, sqlStr string) (stmt *core.Stmt, err error) {
 	crc := crc32.ChecksumIEEE([]byte(sqlStr))
 	// TODO try hash(sqlStr+len(sqlStr))
 	var has bool
 	stmt, has = session.stmtCache[crc]
 	if !has {
-		stmt, err = db.PrepareContext(session.ctx, sqlStr)
+		// the comment is kept out of the key of the cache
+		prepareSQL := sqlStr
+		if commenter := session.engine.sqlCommenter; commenter != nil && commenter.CommentPrepared() {
+			prepareSQL = commenter.Comment(session.ctx, sqlStr)
+		}
+		stmt, err = db.PrepareContext(ctx, prepareSQL)
 		if err != nil {
 			return nil, err
 		}
@@ -410,7 +492,7 @@ func (session *Session) rows2Beans(rows *core.Rows, fields []string,
 			bean:    bean,
 		})
 	}
-	return nil
+	return rows.Err()
 }
 
 func (session *Session) row2Slice(rows *core.Rows, fields []string, bean interface{}) ([]interface{}, error) {
@@ -521,13 +603,13 @@ func (session *Session) slice2Bean(scanResults []interface{}, fields []string, b
 					continue
 				}
 				if fieldValue.CanAddr() {
-					err := json.DefaultJSONHandler.Unmarshal(bs, fieldValue.Addr().Interface())
+					err := session.statement.JSONHandler().Unmarshal(bs, fieldValue.Addr().Interface())
 					if err != nil {
 						return nil, err
 					}
 				} else {
 					x := reflect.New(fieldType)
-					err := json.DefaultJSONHandler.Unmarshal(bs, x.Interface())
+					err := session.statement.JSONHandler().Unmarshal(bs, x.Interface())
 					if err != nil {
 						return nil, err
 					}
@@ -551,13 +633,13 @@ func (session *Session) slice2Bean(scanResults []interface{}, fields []string, b
 			hasAssigned = true
 			if len(bs) > 0 {
 				if fieldValue.CanAddr() {
-					err := json.DefaultJSONHandler.Unmarshal(bs, fieldValue.Addr().Interface())
+					err := session.statement.JSONHandler().Unmarshal(bs, fieldValue.Addr().Interface())
 					if err != nil {
 						return nil, err
 					}
 				} else {
 					x := reflect.New(fieldType)
-					err := json.DefaultJSONHandler.Unmarshal(bs, x.Interface())
+					err := session.statement.JSONHandler().Unmarshal(bs, x.Interface())
 					if err != nil {
 						return nil, err
 					}
@@ -573,7 +655,7 @@ func (session *Session) slice2Bean(scanResults []interface{}, fields []string, b
 						hasAssigned = true
 						if col.SQLType.IsText() {
 							x := reflect.New(fieldType)
-							err := json.DefaultJSONHandler.Unmarshal(vv.Bytes(), x.Interface())
+							err := session.statement.JSONHandler().Unmarshal(vv.Bytes(), x.Interface())
 							if err != nil {
 								return nil, err
 							}
@@ -688,7 +770,7 @@ func (session *Session) slice2Bean(scanResults []interface{}, fields []string, b
 					hasAssigned = true
 					x := reflect.New(fieldType)
 					if len([]byte(vv.String())) > 0 {
-						err := json.DefaultJSONHandler.Unmarshal([]byte(vv.String()), x.Interface())
+						err := session.statement.JSONHandler().Unmarshal([]byte(vv.String()), x.Interface())
 						if err != nil {
 							return nil, err
 						}
@@ -698,7 +780,7 @@ func (session *Session) slice2Bean(scanResults []interface{}, fields []string, b
 					hasAssigned = true
 					x := reflect.New(fieldType)
 					if len(vv.Bytes()) > 0 {
-						err := json.DefaultJSONHandler.Unmarshal(vv.Bytes(), x.Interface())
+						err := session.statement.JSONHandler().Unmarshal(vv.Bytes(), x.Interface())
 						if err != nil {
 							return nil, err
 						}
@@ -834,7 +916,7 @@ func (session *Session) slice2Bean(scanResults []interface{}, fields []string, b
 			case schemas.Complex64Type:
 				var x complex64
 				if len([]byte(vv.String())) > 0 {
-					err := json.DefaultJSONHandler.Unmarshal([]byte(vv.String()), &x)
+					err := session.statement.JSONHandler().Unmarshal([]byte(vv.String()), &x)
 					if err != nil {
 						return nil, err
 					}
@@ -844,7 +926,7 @@ func (session *Session) slice2Bean(scanResults []interface{}, fields []string, b
 			case schemas.Complex128Type:
 				var x complex128
 				if len([]byte(vv.String())) > 0 {
-					err := json.DefaultJSONHandler.Unmarshal([]byte(vv.String()), &x)
+					err := session.statement.JSONHandler().Unmarshal([]byte(vv.String()), &x)
 					if err != nil {
 						return nil, err
 					}
@@ -897,6 +979,9 @@ func (session *Session) incrVersionFieldValue(fieldValue *reflect.Value) {
 
 // ContextHook sets the context on this session
 func (session *Session) Context(ctx context.Context) *Session {
+	if session.engine.nPlusOne != nil && !nplusone.HasScope(ctx) {
+		ctx = nplusone.WithScope(ctx)
+	}
 	session.ctx = ctx
 	return session
 }
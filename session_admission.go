@@ -0,0 +1,44 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xormplus
+
+import (
+	"context"
+
+	"github.com/laixyz/xormplus/admission"
+)
+
+// Priority sets the priority of the statements of the session in the admission controller
+// of the engine, i.e. admission.PriorityLow for the batch jobs. The default is the one
+// carried by the context or admission.PriorityNormal.
+func (session *Session) Priority(p admission.Priority) *Session {
+	session.ctx = admission.WithPriority(session.ctx, p)
+	return session
+}
+
+// admit waits until the statement is admitted by the admission controller of the engine,
+// a transaction is admitted once when it begins. The nested statements of the session,
+// i.e. the ones of the cacher executed while the rows of the ids are open, share the
+// admission of the session instead of waiting for it.
+func (session *Session) admit(ctx context.Context) (func(), error) {
+	if session.engine.admission == nil || !session.isAutoCommit {
+		return func() {}, nil
+	}
+	if session.admitted == 0 {
+		release, err := session.engine.admission.Acquire(ctx, admission.PriorityFromContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		session.releaseAdmission = release
+	}
+	session.admitted++
+	return func() {
+		session.admitted--
+		if session.admitted == 0 {
+			session.releaseAdmission()
+			session.releaseAdmission = nil
+		}
+	}, nil
+}
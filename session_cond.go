@@ -39,12 +39,20 @@ func (session *Session) ID(id interface{}) *Session {
 
 // In provides a query string like "id in (1, 2, 3)"
 func (session *Session) In(column string, args ...interface{}) *Session {
+	if cond := session.inTempTable(column, args, false); cond != nil {
+		session.statement.And(cond)
+		return session
+	}
 	session.statement.In(column, args...)
 	return session
 }
 
 // NotIn provides a query string like "id in (1, 2, 3)"
 func (session *Session) NotIn(column string, args ...interface{}) *Session {
+	if cond := session.inTempTable(column, args, true); cond != nil {
+		session.statement.And(cond)
+		return session
+	}
 	session.statement.NotIn(column, args...)
 	return session
 }
@@ -14,7 +14,6 @@ import (
 	"time"
 
 	"github.com/laixyz/xormplus/convert"
-	"github.com/laixyz/xormplus/internal/json"
 	"github.com/laixyz/xormplus/internal/utils"
 	"github.com/laixyz/xormplus/schemas"
 )
@@ -104,7 +103,7 @@ func (session *Session) bytes2Value(col *schemas.Column, fieldValue *reflect.Val
 	case reflect.Complex64, reflect.Complex128:
 		x := reflect.New(fieldType)
 		if len(data) > 0 {
-			err := json.DefaultJSONHandler.Unmarshal(data, x.Interface())
+			err := session.statement.JSONHandler().Unmarshal(data, x.Interface())
 			if err != nil {
 				return err
 			}
@@ -117,7 +116,7 @@ func (session *Session) bytes2Value(col *schemas.Column, fieldValue *reflect.Val
 		if col.SQLType.IsText() {
 			x := reflect.New(fieldType)
 			if len(data) > 0 {
-				err := json.DefaultJSONHandler.Unmarshal(data, x.Interface())
+				err := session.statement.JSONHandler().Unmarshal(data, x.Interface())
 				if err != nil {
 					return err
 				}
@@ -129,7 +128,7 @@ func (session *Session) bytes2Value(col *schemas.Column, fieldValue *reflect.Val
 			} else {
 				x := reflect.New(fieldType)
 				if len(data) > 0 {
-					err := json.DefaultJSONHandler.Unmarshal(data, x.Interface())
+					err := session.statement.JSONHandler().Unmarshal(data, x.Interface())
 					if err != nil {
 						return err
 					}
@@ -257,7 +256,7 @@ func (session *Session) bytes2Value(col *schemas.Column, fieldValue *reflect.Val
 		case schemas.Complex64Type.Kind():
 			var x complex64
 			if len(data) > 0 {
-				err := json.DefaultJSONHandler.Unmarshal(data, &x)
+				err := session.statement.JSONHandler().Unmarshal(data, &x)
 				if err != nil {
 					return err
 				}
@@ -267,7 +266,7 @@ func (session *Session) bytes2Value(col *schemas.Column, fieldValue *reflect.Val
 		case schemas.Complex128Type.Kind():
 			var x complex128
 			if len(data) > 0 {
-				err := json.DefaultJSONHandler.Unmarshal(data, &x)
+				err := session.statement.JSONHandler().Unmarshal(data, &x)
 				if err != nil {
 					return err
 				}
@@ -10,6 +10,7 @@ import (
 	"strconv"
 
 	"github.com/laixyz/xormplus/caches"
+	"github.com/laixyz/xormplus/internal/statements"
 	"github.com/laixyz/xormplus/schemas"
 )
 
@@ -110,108 +111,142 @@ func (session *Session) Delete(bean interface{}) (int64, error) {
 	if len(condSQL) == 0 && (pLimitN == nil || *pLimitN == 0) {
 		return 0, ErrNeedDeletedCond
 	}
+	if err := session.checkFullTable(condSQL, pLimitN != nil && *pLimitN > 0); err != nil {
+		return 0, err
+	}
 
 	var tableNameNoQuote = session.statement.TableName()
 	var tableName = session.engine.Quote(tableNameNoQuote)
 	var table = session.statement.RefTable
-	var deleteSQL string
-	if len(condSQL) > 0 {
-		deleteSQL = fmt.Sprintf("DELETE FROM %v WHERE %v", tableName, condSQL)
-	} else {
-		deleteSQL = fmt.Sprintf("DELETE FROM %v", tableName)
-	}
+	var realSQL string
+	if session.statement.HasJoin() {
+		if len(session.statement.OrderStr) > 0 || (pLimitN != nil && *pLimitN > 0) {
+			return 0, statements.ErrUnsupportedJoin
+		}
 
-	var orderSQL string
-	if len(session.statement.OrderStr) > 0 {
-		orderSQL += fmt.Sprintf(" ORDER BY %s", session.statement.OrderStr)
-	}
-	if pLimitN != nil && *pLimitN > 0 {
-		limitNValue := *pLimitN
-		orderSQL += fmt.Sprintf(" LIMIT %d", limitNValue)
-	}
+		if session.statement.GetUnscoped() || table.DeletedColumn() == nil { // tag "deleted" is disabled
+			realSQL, condArgs, err = session.statement.GenDeleteJoinSQL(condSQL, condArgs)
+		} else {
+			deletedColumn := table.DeletedColumn()
+			val, t := session.engine.nowTime(deletedColumn)
+			realSQL, condArgs, err = session.statement.GenUpdateJoinSQL(
+				[]string{session.engine.Quote(deletedColumn.Name) + " = ?"},
+				[]interface{}{val}, condSQL, condArgs)
 
-	if len(orderSQL) > 0 {
-		switch session.engine.dialect.URI().DBType {
-		case schemas.POSTGRES:
-			inSQL := fmt.Sprintf("ctid IN (SELECT ctid FROM %s%s)", tableName, orderSQL)
-			if len(condSQL) > 0 {
-				deleteSQL += " AND " + inSQL
-			} else {
-				deleteSQL += " WHERE " + inSQL
-			}
-		case schemas.SQLITE:
-			inSQL := fmt.Sprintf("rowid IN (SELECT rowid FROM %s%s)", tableName, orderSQL)
-			if len(condSQL) > 0 {
-				deleteSQL += " AND " + inSQL
-			} else {
-				deleteSQL += " WHERE " + inSQL
-			}
-			// TODO: how to handle delete limit on mssql?
-		case schemas.MSSQL:
-			return 0, ErrNotImplemented
-		default:
-			deleteSQL += orderSQL
+			var colName = deletedColumn.Name
+			session.afterClosures = append(session.afterClosures, func(bean interface{}) {
+				col := table.GetColumn(colName)
+				setColumnTime(bean, col, t)
+			})
+		}
+		if err != nil {
+			return 0, err
 		}
-	}
 
-	var realSQL string
-	argsForCache := make([]interface{}, 0, len(condArgs)*2)
-	if session.statement.GetUnscoped() || table.DeletedColumn() == nil { // tag "deleted" is disabled
-		realSQL = deleteSQL
-		copy(argsForCache, condArgs)
-		argsForCache = append(condArgs, argsForCache...)
+		if cacher := session.engine.GetCacher(tableNameNoQuote); cacher != nil && session.statement.UseCache {
+			session.engine.logger.Debugf("[cache] clear table: %v", tableNameNoQuote)
+			cacher.ClearIds(tableNameNoQuote)
+			cacher.ClearBeans(tableNameNoQuote)
+		}
 	} else {
-		// !oinume! sqlStrForCache and argsForCache is needed to behave as executing "DELETE FROM ..." for caches.
-		copy(argsForCache, condArgs)
-		argsForCache = append(condArgs, argsForCache...)
+		var deleteSQL string
+		if len(condSQL) > 0 {
+			deleteSQL = fmt.Sprintf("DELETE FROM %v WHERE %v", tableName, condSQL)
+		} else {
+			deleteSQL = fmt.Sprintf("DELETE FROM %v", tableName)
+		}
 
-		deletedColumn := table.DeletedColumn()
-		realSQL = fmt.Sprintf("UPDATE %v SET %v = ? WHERE %v",
-			session.engine.Quote(session.statement.TableName()),
-			session.engine.Quote(deletedColumn.Name),
-			condSQL)
+		var orderSQL string
+		if len(session.statement.OrderStr) > 0 {
+			orderSQL += fmt.Sprintf(" ORDER BY %s", session.statement.OrderStr)
+		}
+		if pLimitN != nil && *pLimitN > 0 {
+			limitNValue := *pLimitN
+			orderSQL += fmt.Sprintf(" LIMIT %d", limitNValue)
+		}
 
 		if len(orderSQL) > 0 {
 			switch session.engine.dialect.URI().DBType {
 			case schemas.POSTGRES:
 				inSQL := fmt.Sprintf("ctid IN (SELECT ctid FROM %s%s)", tableName, orderSQL)
 				if len(condSQL) > 0 {
-					realSQL += " AND " + inSQL
+					deleteSQL += " AND " + inSQL
 				} else {
-					realSQL += " WHERE " + inSQL
+					deleteSQL += " WHERE " + inSQL
 				}
 			case schemas.SQLITE:
 				inSQL := fmt.Sprintf("rowid IN (SELECT rowid FROM %s%s)", tableName, orderSQL)
 				if len(condSQL) > 0 {
-					realSQL += " AND " + inSQL
+					deleteSQL += " AND " + inSQL
 				} else {
-					realSQL += " WHERE " + inSQL
+					deleteSQL += " WHERE " + inSQL
 				}
 				// TODO: how to handle delete limit on mssql?
 			case schemas.MSSQL:
 				return 0, ErrNotImplemented
 			default:
-				realSQL += orderSQL
+				deleteSQL += orderSQL
 			}
 		}
 
-		// !oinume! Insert nowTime to the head of session.statement.Params
-		condArgs = append(condArgs, "")
-		paramsLen := len(condArgs)
-		copy(condArgs[1:paramsLen], condArgs[0:paramsLen-1])
+		argsForCache := make([]interface{}, 0, len(condArgs)*2)
+		if session.statement.GetUnscoped() || table.DeletedColumn() == nil { // tag "deleted" is disabled
+			realSQL = deleteSQL
+			copy(argsForCache, condArgs)
+			argsForCache = append(condArgs, argsForCache...)
+		} else {
+			// !oinume! sqlStrForCache and argsForCache is needed to behave as executing "DELETE FROM ..." for caches.
+			copy(argsForCache, condArgs)
+			argsForCache = append(condArgs, argsForCache...)
 
-		val, t := session.engine.nowTime(deletedColumn)
-		condArgs[0] = val
+			deletedColumn := table.DeletedColumn()
+			realSQL = fmt.Sprintf("UPDATE %v SET %v = ? WHERE %v",
+				session.engine.Quote(session.statement.TableName()),
+				session.engine.Quote(deletedColumn.Name),
+				condSQL)
 
-		var colName = deletedColumn.Name
-		session.afterClosures = append(session.afterClosures, func(bean interface{}) {
-			col := table.GetColumn(colName)
-			setColumnTime(bean, col, t)
-		})
-	}
+			if len(orderSQL) > 0 {
+				switch session.engine.dialect.URI().DBType {
+				case schemas.POSTGRES:
+					inSQL := fmt.Sprintf("ctid IN (SELECT ctid FROM %s%s)", tableName, orderSQL)
+					if len(condSQL) > 0 {
+						realSQL += " AND " + inSQL
+					} else {
+						realSQL += " WHERE " + inSQL
+					}
+				case schemas.SQLITE:
+					inSQL := fmt.Sprintf("rowid IN (SELECT rowid FROM %s%s)", tableName, orderSQL)
+					if len(condSQL) > 0 {
+						realSQL += " AND " + inSQL
+					} else {
+						realSQL += " WHERE " + inSQL
+					}
+					// TODO: how to handle delete limit on mssql?
+				case schemas.MSSQL:
+					return 0, ErrNotImplemented
+				default:
+					realSQL += orderSQL
+				}
+			}
+
+			// !oinume! Insert nowTime to the head of session.statement.Params
+			condArgs = append(condArgs, "")
+			paramsLen := len(condArgs)
+			copy(condArgs[1:paramsLen], condArgs[0:paramsLen-1])
 
-	if cacher := session.engine.GetCacher(tableNameNoQuote); cacher != nil && session.statement.UseCache {
-		session.cacheDelete(table, tableNameNoQuote, deleteSQL, argsForCache...)
+			val, t := session.engine.nowTime(deletedColumn)
+			condArgs[0] = val
+
+			var colName = deletedColumn.Name
+			session.afterClosures = append(session.afterClosures, func(bean interface{}) {
+				col := table.GetColumn(colName)
+				setColumnTime(bean, col, t)
+			})
+		}
+
+		if cacher := session.engine.GetCacher(tableNameNoQuote); cacher != nil && session.statement.UseCache {
+			session.cacheDelete(table, tableNameNoQuote, deleteSQL, argsForCache...)
+		}
 	}
 
 	session.statement.RefTable = table
@@ -0,0 +1,81 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xormplus
+
+import (
+	"errors"
+
+	"github.com/laixyz/xormplus/builder"
+)
+
+// DryRunSQL represents the SQL which would be sent to the database by an operation
+type DryRunSQL struct {
+	SQL      string
+	Args     []interface{}
+	BoundSQL string
+}
+
+var (
+	// errDryRun stops the operation once the SQL is generated
+	errDryRun = errors.New("Dry run")
+	// ErrNoSQLGenerated the dry run operation returned without generating any SQL
+	ErrNoSQLGenerated = errors.New("No SQL generated")
+)
+
+// DryRun generates the SQL of the operation without executing it. The operation is called
+// with this session, all the conditions, the soft delete, the version and the other tags are
+// applied as it's executed, and the first SQL which would be sent to the database is returned.
+//
+//	res, err := engine.Where("age > ?", 10).DryRun(func(session *Session) error {
+//		return session.Find(&users)
+//	})
+//
+// The caches are not used in the dry run, but the before processors of the beans are called.
+func (session *Session) DryRun(op func(*Session) error) (*DryRunSQL, error) {
+	if session.isAutoClose {
+		defer session.Close()
+	}
+	return session.dryRunOp(op)
+}
+
+func (session *Session) dryRunOp(op func(*Session) error) (*DryRunSQL, error) {
+	// the operation shouldn't close the session before the SQL is returned
+	isAutoClose := session.isAutoClose
+	session.isAutoClose = false
+	session.dryRun = &DryRunSQL{}
+	session.statement.UseCache = false
+	defer func() {
+		session.isAutoClose = isAutoClose
+		session.dryRun = nil
+		// the closures registered by the aborted operation shouldn't be applied to the next one
+		cleanupProcessorsClosures(&session.beforeClosures)
+		cleanupProcessorsClosures(&session.afterClosures)
+	}()
+
+	err := op(session)
+	if err == nil {
+		return nil, ErrNoSQLGenerated
+	}
+	if err != errDryRun {
+		return nil, err
+	}
+	return session.dryRun, nil
+}
+
+// recordDryRun records the SQL instead of executing it
+func (session *Session) recordDryRun(sqlStr string, args []interface{}) error {
+	boundSQL, err := builder.ConvertToBoundSQL(sqlStr, args)
+	if err != nil {
+		return err
+	}
+
+	session.queryPreprocess(&sqlStr, args...)
+	*session.dryRun = DryRunSQL{
+		SQL:      sqlStr,
+		Args:     args,
+		BoundSQL: boundSQL,
+	}
+	return errDryRun
+}
@@ -0,0 +1,509 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xormplus
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/laixyz/xormplus/schemas"
+)
+
+// PlanNode is a step of the query plan
+type PlanNode struct {
+	// Operation is the operation named by the database, i.e. SCAN, Seq Scan, ALL
+	Operation string
+	Table     string
+	Index     string
+	// Rows is the estimated rows, it's 0 if the database doesn't estimate it
+	Rows float64
+	// ActualRows is only available when the plan is analyzed
+	ActualRows float64
+	// Cost is the estimated cost, it's 0 if the database doesn't estimate it
+	Cost float64
+	// FullScan means all the rows of the table or the index are scanned
+	FullScan bool
+	Detail   string
+	Children []*PlanNode
+}
+
+// QueryPlan is the normalized plan of a query
+type QueryPlan struct {
+	SQL   string
+	Args  []interface{}
+	Raw   string
+	Nodes []*PlanNode
+}
+
+// Walk calls fn on all the nodes of the plan in depth-first order
+func (plan *QueryPlan) Walk(fn func(node *PlanNode)) {
+	var walk func(nodes []*PlanNode)
+	walk = func(nodes []*PlanNode) {
+		for _, node := range nodes {
+			fn(node)
+			walk(node.Children)
+		}
+	}
+	walk(plan.Nodes)
+}
+
+// Indexes returns the indexes used by the plan
+func (plan *QueryPlan) Indexes() []string {
+	var indexes []string
+	plan.Walk(func(node *PlanNode) {
+		if node.Index != "" {
+			indexes = append(indexes, node.Index)
+		}
+	})
+	return indexes
+}
+
+// UsesIndex returns true if the index is used by the plan
+func (plan *QueryPlan) UsesIndex(name string) bool {
+	for _, index := range plan.Indexes() {
+		if strings.EqualFold(index, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// FullScans returns the tables which are scanned fully
+func (plan *QueryPlan) FullScans() []string {
+	var tables []string
+	plan.Walk(func(node *PlanNode) {
+		if node.FullScan {
+			tables = append(tables, node.Table)
+		}
+	})
+	return tables
+}
+
+// String returns the plan as an indented tree
+func (plan *QueryPlan) String() string {
+	var buf strings.Builder
+	var write func(nodes []*PlanNode, depth int)
+	write = func(nodes []*PlanNode, depth int) {
+		for _, node := range nodes {
+			buf.WriteString(strings.Repeat("  ", depth))
+			buf.WriteString(node.Operation)
+			if node.Table != "" {
+				buf.WriteString(" on " + node.Table)
+			}
+			if node.Index != "" {
+				buf.WriteString(" using " + node.Index)
+			}
+			if node.Rows > 0 {
+				fmt.Fprintf(&buf, " rows=%g", node.Rows)
+			}
+			if node.Cost > 0 {
+				fmt.Fprintf(&buf, " cost=%g", node.Cost)
+			}
+			if node.FullScan {
+				buf.WriteString(" (full scan)")
+			}
+			buf.WriteString("\n")
+			write(node.Children, depth+1)
+		}
+	}
+	write(plan.Nodes, 0)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+var (
+	// ErrExplainNotSupported explain is not supported by the database
+	ErrExplainNotSupported = errors.New("Explain is not supported by this database")
+	// ErrNoIdleConnection there is no idle connection to explain the slow query
+	ErrNoIdleConnection = errors.New("No idle connection")
+)
+
+// Explain returns the plan of the query which would be executed by the operation, the
+// operation is called with this session like DryRun.
+//
+//	plan, err := engine.Where("name = ?", name).Explain(func(session *Session) error {
+//		return session.Find(&users)
+//	})
+func (session *Session) Explain(op func(*Session) error) (*QueryPlan, error) {
+	if session.isAutoClose {
+		defer session.Close()
+	}
+	return session.explain(op, false)
+}
+
+// ExplainAnalyze returns the plan of the query with the actual rows, it's only supported by
+// Postgres. NOTICE: the query is executed, so it should be called in a transaction which
+// will be rolled back if the operation modifies the data.
+func (session *Session) ExplainAnalyze(op func(*Session) error) (*QueryPlan, error) {
+	if session.isAutoClose {
+		defer session.Close()
+	}
+	return session.explain(op, true)
+}
+
+func (session *Session) explain(op func(*Session) error, analyze bool) (*QueryPlan, error) {
+	dbType := session.engine.dialect.URI().DBType
+	if analyze && dbType != schemas.POSTGRES {
+		return nil, ErrExplainNotSupported
+	}
+
+	res, err := session.dryRunOp(op)
+	if err != nil {
+		return nil, err
+	}
+
+	var conn explainConn = session.DB().DB
+	if session.tx != nil {
+		conn = session.tx.Tx
+	}
+	return explainQuery(session.ctx, dbType, conn, res.SQL, res.Args, analyze)
+}
+
+// explainConn is the connection to execute the explain queries, the hooks are not called
+// so that the hooks could explain the queries
+type explainConn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func explainQuery(ctx context.Context, dbType schemas.DBType, conn explainConn, sqlStr string, args []interface{}, analyze bool) (*QueryPlan, error) {
+	plan := &QueryPlan{SQL: sqlStr, Args: args}
+	var err error
+	switch dbType {
+	case schemas.SQLITE:
+		err = explainSQLite(ctx, conn, plan)
+	case schemas.MYSQL:
+		err = explainMySQL(ctx, conn, plan)
+	case schemas.POSTGRES:
+		err = explainPostgres(ctx, conn, plan, analyze)
+	case schemas.MSSQL:
+		err = explainMSSQL(ctx, conn, plan)
+	default:
+		return nil, ErrExplainNotSupported
+	}
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// queryExplain executes the explain query and returns the rows as strings
+func queryExplain(ctx context.Context, conn explainConn, sqlStr string, args ...interface{}) ([][]string, error) {
+	rows, err := conn.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanExplainRows(rows)
+}
+
+func scanExplainRows(rows *sql.Rows) ([][]string, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var results [][]string
+	for rows.Next() {
+		var values = make([]sql.NullString, len(cols))
+		var dest = make([]interface{}, len(cols))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		var row = make([]string, len(cols))
+		for i, v := range values {
+			row[i] = v.String
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
+
+// toFloat converts the numbers and the strings of numbers in explain results to float
+func toFloat(v interface{}) float64 {
+	switch t := v.(type) {
+	case float64:
+		return t
+	case string:
+		f, _ := strconv.ParseFloat(t, 64)
+		return f
+	}
+	return 0
+}
+
+var sqlitePlanRegexp = regexp.MustCompile(`^(SCAN|SEARCH)(?: TABLE)? (\S+)(?: AS \S+)?(?: USING (?:COVERING )?INDEX (\S+)| USING (?:INTEGER )?(PRIMARY KEY))?`)
+
+func explainSQLite(ctx context.Context, conn explainConn, plan *QueryPlan) error {
+	rows, err := queryExplain(ctx, conn, "EXPLAIN QUERY PLAN "+plan.SQL, plan.Args...)
+	if err != nil {
+		return err
+	}
+
+	var details = make([]string, 0, len(rows))
+	var nodes = make(map[string]*PlanNode, len(rows))
+	for _, row := range rows {
+		if len(row) < 4 {
+			return fmt.Errorf("unexpected explain result: %v", row)
+		}
+		detail := row[3]
+		details = append(details, detail)
+
+		node := &PlanNode{Operation: detail, Detail: detail}
+		if matches := sqlitePlanRegexp.FindStringSubmatch(detail); matches != nil && detail != "SCAN CONSTANT ROW" {
+			node.Operation = matches[1]
+			node.Table = matches[2]
+			node.Index = matches[3] + matches[4]
+			node.FullScan = matches[1] == "SCAN"
+		}
+		nodes[row[0]] = node
+
+		if parent, ok := nodes[row[1]]; ok {
+			parent.Children = append(parent.Children, node)
+		} else {
+			plan.Nodes = append(plan.Nodes, node)
+		}
+	}
+	plan.Raw = strings.Join(details, "\n")
+	return nil
+}
+
+func explainMySQL(ctx context.Context, conn explainConn, plan *QueryPlan) error {
+	rows, err := queryExplain(ctx, conn, "EXPLAIN FORMAT=JSON "+plan.SQL, plan.Args...)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 || len(rows[0]) == 0 {
+		return errors.New("no explain result")
+	}
+	plan.Raw = rows[0][0]
+
+	var v interface{}
+	if err := json.Unmarshal([]byte(plan.Raw), &v); err != nil {
+		return err
+	}
+	plan.Nodes = mysqlPlanNodes(v)
+	return nil
+}
+
+// mysqlPlanNodes converts the JSON plan of MySQL, the tables are the leaves and
+// the other objects like query_block and ordering_operation are the steps
+func mysqlPlanNodes(v interface{}) []*PlanNode {
+	var nodes []*PlanNode
+	switch t := v.(type) {
+	case []interface{}:
+		for _, e := range t {
+			nodes = append(nodes, mysqlPlanNodes(e)...)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			switch child := t[key].(type) {
+			case []interface{}:
+				nodes = append(nodes, mysqlPlanNodes(child)...)
+			case map[string]interface{}:
+				if key == "cost_info" {
+					continue
+				}
+				if key == "table" {
+					nodes = append(nodes, mysqlTableNode(child))
+					continue
+				}
+				node := &PlanNode{Operation: key, Children: mysqlPlanNodes(child)}
+				if costInfo, ok := child["cost_info"].(map[string]interface{}); ok {
+					node.Cost = toFloat(costInfo["query_cost"])
+				}
+				nodes = append(nodes, node)
+			}
+		}
+	}
+	return nodes
+}
+
+func mysqlTableNode(table map[string]interface{}) *PlanNode {
+	node := &PlanNode{
+		Rows:     toFloat(table["rows_examined_per_scan"]),
+		Children: mysqlPlanNodes(table),
+	}
+	node.Operation, _ = table["access_type"].(string)
+	node.Table, _ = table["table_name"].(string)
+	node.Index, _ = table["key"].(string)
+	node.FullScan = node.Operation == "ALL" || node.Operation == "index"
+	if costInfo, ok := table["cost_info"].(map[string]interface{}); ok {
+		node.Cost = toFloat(costInfo["prefix_cost"])
+	}
+	if cond, ok := table["attached_condition"].(string); ok {
+		node.Detail = cond
+	}
+	return node
+}
+
+func explainPostgres(ctx context.Context, conn explainConn, plan *QueryPlan, analyze bool) error {
+	explain := "EXPLAIN (FORMAT JSON) "
+	if analyze {
+		explain = "EXPLAIN (FORMAT JSON, ANALYZE) "
+	}
+	rows, err := queryExplain(ctx, conn, explain+plan.SQL, plan.Args...)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 || len(rows[0]) == 0 {
+		return errors.New("no explain result")
+	}
+	plan.Raw = rows[0][0]
+
+	var results []struct {
+		Plan map[string]interface{}
+	}
+	if err := json.Unmarshal([]byte(plan.Raw), &results); err != nil {
+		return err
+	}
+	for _, result := range results {
+		plan.Nodes = append(plan.Nodes, postgresPlanNode(result.Plan))
+	}
+	return nil
+}
+
+func postgresPlanNode(p map[string]interface{}) *PlanNode {
+	node := &PlanNode{
+		Rows:       toFloat(p["Plan Rows"]),
+		ActualRows: toFloat(p["Actual Rows"]),
+		Cost:       toFloat(p["Total Cost"]),
+	}
+	node.Operation, _ = p["Node Type"].(string)
+	node.Table, _ = p["Relation Name"].(string)
+	node.Index, _ = p["Index Name"].(string)
+	node.FullScan = node.Operation == "Seq Scan"
+	if cond, ok := p["Filter"].(string); ok {
+		node.Detail = cond
+	} else if cond, ok := p["Index Cond"].(string); ok {
+		node.Detail = cond
+	}
+	if children, ok := p["Plans"].([]interface{}); ok {
+		for _, child := range children {
+			if c, ok := child.(map[string]interface{}); ok {
+				node.Children = append(node.Children, postgresPlanNode(c))
+			}
+		}
+	}
+	return node
+}
+
+func explainMSSQL(ctx context.Context, conn explainConn, plan *QueryPlan) error {
+	// SHOWPLAN_XML is the option of connection, so all the queries should be sent by
+	// the same connection
+	if db, ok := conn.(*sql.DB); ok {
+		sqlConn, err := db.Conn(ctx)
+		if err != nil {
+			return err
+		}
+		defer sqlConn.Close()
+		conn = sqlConn
+	}
+
+	if _, err := conn.ExecContext(ctx, "SET SHOWPLAN_XML ON"); err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, "SET SHOWPLAN_XML OFF")
+
+	rows, err := conn.QueryContext(ctx, plan.SQL, plan.Args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	results, err := scanExplainRows(rows)
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 || len(results[0]) == 0 {
+		return errors.New("no explain result")
+	}
+	plan.Raw = results[0][0]
+
+	plan.Nodes, err = mssqlPlanNodes(plan.Raw)
+	return err
+}
+
+// mssqlPlanNodes converts the RelOp elements of the XML plan of MSSQL
+func mssqlPlanNodes(raw string) ([]*PlanNode, error) {
+	var roots []*PlanNode
+	var stack []*PlanNode
+	decoder := xml.NewDecoder(strings.NewReader(raw))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if err == io.EOF {
+				return roots, nil
+			}
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "RelOp":
+				node := &PlanNode{}
+				for _, attr := range t.Attr {
+					switch attr.Name.Local {
+					case "PhysicalOp":
+						node.Operation = attr.Value
+					case "LogicalOp":
+						node.Detail = attr.Value
+					case "EstimateRows":
+						node.Rows = toFloat(attr.Value)
+					case "EstimatedTotalSubtreeCost":
+						node.Cost = toFloat(attr.Value)
+					}
+				}
+				switch node.Operation {
+				case "Table Scan", "Clustered Index Scan", "Index Scan":
+					node.FullScan = true
+				}
+				if len(stack) > 0 {
+					parent := stack[len(stack)-1]
+					parent.Children = append(parent.Children, node)
+				} else {
+					roots = append(roots, node)
+				}
+				stack = append(stack, node)
+			case "Object":
+				if len(stack) == 0 {
+					continue
+				}
+				node := stack[len(stack)-1]
+				if node.Table != "" {
+					continue
+				}
+				for _, attr := range t.Attr {
+					switch attr.Name.Local {
+					case "Table":
+						node.Table = strings.Trim(attr.Value, "[]")
+					case "Index":
+						node.Index = strings.Trim(attr.Value, "[]")
+					}
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Local == "RelOp" && len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+}
@@ -270,7 +270,7 @@ func (session *Session) noCacheFind(table *schemas.Table, containerValue reflect
 			return err
 		}
 	}
-	return nil
+	return rows.Err()
 }
 
 func convertPKToValue(table *schemas.Table, dst interface{}, pk schemas.PK) error {
@@ -337,6 +337,9 @@ func (session *Session) cacheFind(t reflect.Type, sqlStr string, rowsSlicePtr in
 
 			ids = append(ids, pk)
 		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
 
 		session.engine.logger.Debugf("[cache] cache sql: %v, %v, %v, %v, %v", ids, tableName, sqlStr, newsql, args)
 		err = caches.PutCacheSql(cacher, ids, tableName, newsql, args)
@@ -407,6 +410,7 @@ func (session *Session) cacheFind(t reflect.Type, sqlStr string, rowsSlicePtr in
 			session.engine.tagParser,
 			session.engine.DatabaseTZ,
 		)
+		session.statement.SetJSONHandler(session.engine.jsonHandler)
 		if len(table.PrimaryKeys) == 1 {
 			ff := make([]interface{}, 0, len(ides))
 			for _, ie := range ides {
@@ -415,13 +419,15 @@ func (session *Session) cacheFind(t reflect.Type, sqlStr string, rowsSlicePtr in
 
 			session.In("`"+table.PrimaryKeys[0]+"`", ff...)
 		} else {
+			cols := make([]string, 0, len(table.PrimaryKeys))
+			for _, name := range table.PrimaryKeys {
+				cols = append(cols, "`"+name+"`")
+			}
+			vals := make([][]interface{}, 0, len(ides))
 			for _, ie := range ides {
-				cond := builder.NewCond()
-				for i, name := range table.PrimaryKeys {
-					cond = cond.And(builder.Eq{"`" + name + "`": ie[i]})
-				}
-				session.Or(cond)
+				vals = append(vals, ie)
 			}
+			session.And(builder.InTuple(cols, vals))
 		}
 
 		err = session.NoCache().Table(tableName).find(beans)
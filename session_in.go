@@ -0,0 +1,146 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xormplus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/laixyz/xormplus/builder"
+	"github.com/laixyz/xormplus/schemas"
+)
+
+// inTempTableSeq generates the names of the temporary tables of the IN conditions
+var inTempTableSeq uint64
+
+// inTempTableColumn returns the column type of the temporary table of the values, false
+// will be returned if the values are neither all integers nor all strings
+func inTempTableColumn(dbType schemas.DBType, vals []interface{}) (string, bool) {
+	var ints, maxLen int
+	for _, val := range vals {
+		switch t := val.(type) {
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			ints++
+		case string:
+			if len(t) > maxLen {
+				maxLen = len(t)
+			}
+		default:
+			return "", false
+		}
+	}
+	if ints > 0 && ints < len(vals) {
+		return "", false
+	}
+
+	switch {
+	case ints > 0 && dbType == schemas.SQLITE:
+		return "INTEGER", true
+	case ints > 0:
+		return "BIGINT", true
+	case dbType == schemas.MYSQL && maxLen <= 16383:
+		return fmt.Sprintf("VARCHAR(%d)", maxLen+1), true
+	case dbType == schemas.MSSQL && maxLen <= 4000:
+		return fmt.Sprintf("NVARCHAR(%d)", maxLen+1), true
+	case dbType == schemas.POSTGRES, dbType == schemas.SQLITE:
+		return "TEXT", true
+	}
+	return "", false
+}
+
+// inTempTable loads the values of the IN condition into a temporary table if the strategy
+// of the dialect is builder.InTempTable. The session should hold a transaction so that the
+// table is created and queried on the same connection, the table is dropped when the
+// transaction is committed or rolled back. It returns nil if the values are not loaded.
+func (session *Session) inTempTable(column string, args []interface{}, not bool) builder.Cond {
+	dbType := session.engine.dialect.URI().DBType
+	threshold, strategy := builder.InLimit(string(dbType))
+	if strategy != builder.InTempTable || session.isAutoCommit {
+		return nil
+	}
+	vals, ok := builder.FlattenInValues(args...)
+	if !ok || len(vals) <= threshold {
+		return nil
+	}
+	colType, ok := inTempTableColumn(dbType, vals)
+	if !ok {
+		return nil
+	}
+
+	name := fmt.Sprintf("xorm_in_%d", atomic.AddUint64(&inTempTableSeq, 1))
+	var createSQL string
+	switch dbType {
+	case schemas.MYSQL:
+		createSQL = "CREATE TEMPORARY TABLE %s (v %s)"
+	case schemas.POSTGRES:
+		createSQL = "CREATE TEMPORARY TABLE %s (v %s) ON COMMIT DROP"
+	case schemas.SQLITE:
+		createSQL = "CREATE TEMP TABLE %s (v %s)"
+	case schemas.MSSQL:
+		name = "#" + name
+		createSQL = "CREATE TABLE %s (v %s)"
+	default:
+		return nil
+	}
+	tableName := session.engine.Quote(name)
+	if err := session.execInTx(fmt.Sprintf(createSQL, tableName, colType)); err != nil {
+		session.statement.LastError = err
+		return nil
+	}
+	session.inTempTables = append(session.inTempTables, tableName)
+
+	// the rows are inserted in batches within the parameter limitation
+	var batch = threshold
+	if batch > 1000 {
+		batch = 1000
+	}
+	for start := 0; start < len(vals); start += batch {
+		end := start + batch
+		if end > len(vals) {
+			end = len(vals)
+		}
+		values := strings.Repeat("(?),", end-start)
+		insertSQL := fmt.Sprintf("INSERT INTO %s (v) VALUES %s", tableName, values[:len(values)-1])
+		if err := session.execInTx(insertSQL, vals[start:end]...); err != nil {
+			session.statement.LastError = err
+			return nil
+		}
+	}
+
+	op := " IN "
+	if not {
+		op = " NOT IN "
+	}
+	return builder.Expr(session.engine.Quote(column) + op + "(SELECT v FROM " + tableName + ")")
+}
+
+// execInTx executes the statement in the transaction without resetting the statement
+// of the session which is being built
+func (session *Session) execInTx(sqlStr string, args ...interface{}) error {
+	for _, filter := range session.engine.dialect.Filters() {
+		sqlStr = filter.Do(sqlStr)
+	}
+	return session.withStatementContext(sqlStr, func(ctx context.Context) error {
+		_, err := session.tx.ExecContext(ctx, sqlStr, args...)
+		return err
+	})
+}
+
+// dropInTempTables drops the temporary tables of the IN conditions of the transaction
+func (session *Session) dropInTempTables() error {
+	var dropSQL = "DROP TABLE %s"
+	if session.engine.dialect.URI().DBType == schemas.MYSQL {
+		dropSQL = "DROP TEMPORARY TABLE %s"
+	}
+	for len(session.inTempTables) > 0 {
+		if err := session.execInTx(fmt.Sprintf(dropSQL, session.inTempTables[0])); err != nil {
+			return err
+		}
+		session.inTempTables = session.inTempTables[1:]
+	}
+	return nil
+}
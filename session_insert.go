@@ -12,6 +12,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/laixyz/xormplus/builder"
 	"github.com/laixyz/xormplus/internal/utils"
 	"github.com/laixyz/xormplus/schemas"
 )
@@ -474,6 +475,38 @@ func (session *Session) InsertOne(bean interface{}) (int64, error) {
 	return session.innerInsert(bean)
 }
 
+// InsertSelect inserts the records returned by query into target, target could be a table name
+// or a bean. Use Cols to indicate the inserted columns, otherwise all the columns are inserted.
+func (session *Session) InsertSelect(target interface{}, query *builder.Builder) (int64, error) {
+	if session.isAutoClose {
+		defer session.Close()
+	}
+
+	if session.statement.LastError != nil {
+		return 0, session.statement.LastError
+	}
+
+	if err := session.statement.SetTable(target); err != nil {
+		return 0, err
+	}
+
+	tableName := session.statement.TableName()
+	sqlStr, args, err := session.statement.GenInsertSelectSQL(query)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := session.cacheInsert(tableName); err != nil {
+		return 0, err
+	}
+
+	res, err := session.exec(sqlStr, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
 func (session *Session) cacheInsert(table string) error {
 	if !session.statement.UseCache {
 		return nil
@@ -5,6 +5,7 @@
 package xormplus
 
 import (
+	"database/sql"
 	"reflect"
 
 	"github.com/laixyz/xormplus/internal/utils"
@@ -54,7 +55,10 @@ func (session *Session) Iterate(bean interface{}, fun IterFunc) error {
 		}
 		i++
 	}
-	return err
+	if err := rows.Err(); err != sql.ErrNoRows {
+		return err
+	}
+	return nil
 }
 
 // BufferSize sets the buffersize for iterate
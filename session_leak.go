@@ -0,0 +1,291 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xormplus
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+)
+
+// the kinds of the leaks
+const (
+	// LeakOpenTooLong represents a session is open longer than the threshold
+	LeakOpenTooLong = "open_too_long"
+	// LeakUncommittedTx represents a session holds a transaction longer than the threshold
+	LeakUncommittedTx = "uncommitted_transaction"
+	// LeakGarbageCollected represents a session is garbage collected without being closed
+	LeakGarbageCollected = "garbage_collected"
+)
+
+// leakCheckInterval is the interval of checking the sessions open too long
+const leakCheckInterval = time.Second
+
+// OpenRows is a query result not closed
+type OpenRows struct {
+	Created time.Time
+	// Stack is the stack where the query is executed
+	Stack string
+}
+
+// OpenSession is a session not closed
+type OpenSession struct {
+	ID            uint64
+	Created       time.Time
+	InTransaction bool
+	// TxStarted is the time when the transaction began, it's zero out of the transaction
+	TxStarted time.Time
+	// Stack is the stack where the session is created
+	Stack string
+	Rows  []OpenRows
+}
+
+// Leak is a session suspected to be leaked
+type Leak struct {
+	Kind    string
+	Session OpenSession
+}
+
+func (l *Leak) String() string {
+	return fmt.Sprintf("Session leak (%s): session %d created %v ago with %d open rows, created at:\n%s",
+		l.Kind, l.Session.ID, time.Since(l.Session.Created).Round(time.Millisecond), len(l.Session.Rows), l.Session.Stack)
+}
+
+// sessionRecord records an open session, it doesn't refer to the session so the session
+// could be garbage collected
+type sessionRecord struct {
+	id        uint64
+	created   time.Time
+	stack     string
+	inTx      bool
+	txStarted time.Time
+	rowsSeq   uint64
+	rows      map[uint64]OpenRows
+	reported  map[string]bool
+}
+
+// LeakTracker tracks the open sessions and rows to find the ones not closed
+type LeakTracker struct {
+	mu        sync.Mutex
+	threshold time.Duration
+	seq       uint64
+	sessions  map[uint64]*sessionRecord
+	onLeak    func(*Leak)
+	stopOnce  sync.Once
+	stop      chan struct{}
+}
+
+func newLeakTracker(threshold time.Duration) *LeakTracker {
+	t := &LeakTracker{
+		threshold: threshold,
+		sessions:  make(map[uint64]*sessionRecord),
+		stop:      make(chan struct{}),
+	}
+	go t.run()
+	return t
+}
+
+// SetThreshold sets the duration after which an open session is reported, 0 means the
+// sessions are only reported when they are garbage collected
+func (t *LeakTracker) SetThreshold(threshold time.Duration) *LeakTracker {
+	t.mu.Lock()
+	t.threshold = threshold
+	t.mu.Unlock()
+	return t
+}
+
+// OnLeak sets the function called when a leak is found, it's called once per kind per
+// session and it could be called by the finalizer goroutine
+func (t *LeakTracker) OnLeak(fn func(*Leak)) *LeakTracker {
+	t.mu.Lock()
+	t.onLeak = fn
+	t.mu.Unlock()
+	return t
+}
+
+func (t *LeakTracker) run() {
+	ticker := time.NewTicker(leakCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			t.Check()
+		case <-t.stop:
+			return
+		}
+	}
+}
+
+// close stops checking the sessions in background
+func (t *LeakTracker) close() {
+	t.stopOnce.Do(func() {
+		close(t.stop)
+	})
+}
+
+// snapshot returns a copy of the record, t.mu should be locked
+func (r *sessionRecord) snapshot() OpenSession {
+	s := OpenSession{
+		ID:            r.id,
+		Created:       r.created,
+		InTransaction: r.inTx,
+		TxStarted:     r.txStarted,
+		Stack:         r.stack,
+		Rows:          make([]OpenRows, 0, len(r.rows)),
+	}
+	for _, rows := range r.rows {
+		s.Rows = append(s.Rows, rows)
+	}
+	sort.Slice(s.Rows, func(i, j int) bool {
+		return s.Rows[i].Created.Before(s.Rows[j].Created)
+	})
+	return s
+}
+
+// OpenSessions returns the open sessions ordered by the creation
+func (t *LeakTracker) OpenSessions() []OpenSession {
+	t.mu.Lock()
+	sessions := make([]OpenSession, 0, len(t.sessions))
+	for _, r := range t.sessions {
+		sessions = append(sessions, r.snapshot())
+	}
+	t.mu.Unlock()
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].ID < sessions[j].ID
+	})
+	return sessions
+}
+
+// Check reports the sessions open longer than the threshold and the transactions not
+// committed within the threshold which are not reported and returns them
+func (t *LeakTracker) Check() []Leak {
+	t.mu.Lock()
+	var leaks []Leak
+	if t.threshold > 0 {
+		now := time.Now()
+		for _, r := range t.sessions {
+			var kind string
+			if r.inTx && now.Sub(r.txStarted) >= t.threshold {
+				kind = LeakUncommittedTx
+			} else if now.Sub(r.created) >= t.threshold {
+				kind = LeakOpenTooLong
+			} else {
+				continue
+			}
+			if r.reported[kind] {
+				continue
+			}
+			r.reported[kind] = true
+			leaks = append(leaks, Leak{Kind: kind, Session: r.snapshot()})
+		}
+	}
+	onLeak := t.onLeak
+	t.mu.Unlock()
+
+	sort.Slice(leaks, func(i, j int) bool {
+		return leaks[i].Session.ID < leaks[j].Session.ID
+	})
+	if onLeak != nil {
+		for i := range leaks {
+			onLeak(&leaks[i])
+		}
+	}
+	return leaks
+}
+
+// track records the session and sets a finalizer to report it if it's garbage collected
+// without being closed. The sessions referred by their contexts, i.e. when the session
+// IDs are logged, are not garbage collected and not reported by the finalizer.
+func (t *LeakTracker) track(session *Session) {
+	r := &sessionRecord{
+		created:  time.Now(),
+		stack:    string(debug.Stack()),
+		rows:     make(map[uint64]OpenRows),
+		reported: make(map[string]bool),
+	}
+	t.mu.Lock()
+	t.seq++
+	r.id = t.seq
+	t.sessions[r.id] = r
+	t.mu.Unlock()
+
+	session.leakRecord = r
+	runtime.SetFinalizer(session, func(session *Session) {
+		if !session.isClosed {
+			t.finalize(r)
+		}
+	})
+}
+
+func (t *LeakTracker) finalize(r *sessionRecord) {
+	t.mu.Lock()
+	if _, ok := t.sessions[r.id]; !ok {
+		t.mu.Unlock()
+		return
+	}
+	delete(t.sessions, r.id)
+	leak := Leak{Kind: LeakGarbageCollected, Session: r.snapshot()}
+	onLeak := t.onLeak
+	t.mu.Unlock()
+
+	if onLeak != nil {
+		onLeak(&leak)
+	}
+}
+
+// untrack removes the closed session
+func (t *LeakTracker) untrack(session *Session) {
+	t.mu.Lock()
+	delete(t.sessions, session.leakRecord.id)
+	t.mu.Unlock()
+	runtime.SetFinalizer(session, nil)
+}
+
+func (t *LeakTracker) setInTransaction(r *sessionRecord, inTx bool) {
+	t.mu.Lock()
+	r.inTx = inTx
+	if inTx {
+		r.txStarted = time.Now()
+	} else {
+		r.txStarted = time.Time{}
+	}
+	t.mu.Unlock()
+}
+
+// trackRows records the rows of the session and returns the function removing them
+func (t *LeakTracker) trackRows(r *sessionRecord) func() {
+	rows := OpenRows{
+		Created: time.Now(),
+		Stack:   string(debug.Stack()),
+	}
+	t.mu.Lock()
+	r.rowsSeq++
+	id := r.rowsSeq
+	r.rows[id] = rows
+	t.mu.Unlock()
+	return func() {
+		t.mu.Lock()
+		delete(r.rows, id)
+		t.mu.Unlock()
+	}
+}
+
+// trackTx records whether the session holds a transaction
+func (session *Session) trackTx(inTx bool) {
+	if session.leakRecord != nil {
+		session.engine.getLeakTracker().setInTransaction(session.leakRecord, inTx)
+	}
+}
+
+// trackRows records the rows of the session and returns the function removing them
+func (session *Session) trackRows() func() {
+	if session.leakRecord == nil {
+		return func() {}
+	}
+	return session.engine.getLeakTracker().trackRows(session.leakRecord)
+}
@@ -143,6 +143,9 @@ func rows2Strings(rows *core.Rows) (resultsSlice []map[string]string, err error)
 		}
 		resultsSlice = append(resultsSlice, result)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
 	return resultsSlice, nil
 }
@@ -159,6 +162,9 @@ func rows2SliceString(rows *core.Rows) (resultsSlice [][]string, err error) {
 		}
 		resultsSlice = append(resultsSlice, record)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
 	return resultsSlice, nil
 }
@@ -232,6 +238,9 @@ func rows2Interfaces(rows *core.Rows) (resultsSlice []map[string]interface{}, er
 		}
 		resultsSlice = append(resultsSlice, result)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
 	return resultsSlice, nil
 }
@@ -5,6 +5,7 @@
 package xormplus
 
 import (
+	"context"
 	"database/sql"
 	"reflect"
 
@@ -20,17 +21,57 @@ func (session *Session) queryPreprocess(sqlStr *string, paramStr ...interface{})
 	session.lastSQLArgs = paramStr
 }
 
+// commentSQL appends the comment of the SQL commenter of the engine if there is
+func (session *Session) commentSQL(sqlStr string) string {
+	if session.engine.sqlCommenter == nil {
+		return sqlStr
+	}
+	return session.engine.sqlCommenter.Comment(session.ctx, sqlStr)
+}
+
 func (session *Session) queryRows(sqlStr string, args ...interface{}) (*core.Rows, error) {
 	defer session.resetStatement()
 	if session.statement.LastError != nil {
 		return nil, session.statement.LastError
 	}
+	if session.dryRun != nil {
+		return nil, session.recordDryRun(sqlStr, args)
+	}
+	if err := session.checkRawSQL(sqlStr); err != nil {
+		return nil, err
+	}
 
 	session.queryPreprocess(&sqlStr, args...)
 
 	session.lastSQL = sqlStr
 	session.lastSQLArgs = args
 
+	ctx, cancel := session.statementContext()
+	release, err := session.admit(ctx)
+	if err != nil {
+		cancel()
+		return nil, timeoutError(ctx, sqlStr, err)
+	}
+	rows, err := session.doQueryRows(ctx, sqlStr, args...)
+	if err != nil {
+		release()
+		cancel()
+		return nil, timeoutError(ctx, sqlStr, err)
+	}
+	// the connection is held until the rows are closed
+	untrack := session.trackRows()
+	rows.SetCancel(func() {
+		untrack()
+		release()
+		cancel()
+	})
+	rows.SetErrWrapper(func(err error) error {
+		return timeoutError(ctx, sqlStr, err)
+	})
+	return rows, nil
+}
+
+func (session *Session) doQueryRows(ctx context.Context, sqlStr string, args ...interface{}) (*core.Rows, error) {
 	if session.isAutoCommit {
 		var db *core.DB
 		if session.sessionType == groupSession {
@@ -41,26 +82,26 @@ func (session *Session) queryRows(sqlStr string, args ...interface{}) (*core.Row
 
 		if session.prepareStmt {
 			// don't clear stmt since session will cache them
-			stmt, err := session.doPrepare(db, sqlStr)
+			stmt, err := session.doPrepare(ctx, db, sqlStr)
 			if err != nil {
 				return nil, err
 			}
 
-			rows, err := stmt.QueryContext(session.ctx, args...)
+			rows, err := stmt.QueryContext(ctx, args...)
 			if err != nil {
 				return nil, err
 			}
 			return rows, nil
 		}
 
-		rows, err := db.QueryContext(session.ctx, sqlStr, args...)
+		rows, err := db.QueryContext(ctx, session.commentSQL(sqlStr), args...)
 		if err != nil {
 			return nil, err
 		}
 		return rows, nil
 	}
 
-	rows, err := session.tx.QueryContext(session.ctx, sqlStr, args...)
+	rows, err := session.tx.QueryContext(ctx, session.commentSQL(sqlStr), args...)
 	if err != nil {
 		return nil, err
 	}
@@ -119,6 +160,9 @@ func rows2maps(rows *core.Rows) (resultsSlice []map[string][]byte, err error) {
 		}
 		resultsSlice = append(resultsSlice, result)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
 	return resultsSlice, nil
 }
@@ -135,30 +179,48 @@ func (session *Session) queryBytes(sqlStr string, args ...interface{}) ([]map[st
 
 func (session *Session) exec(sqlStr string, args ...interface{}) (sql.Result, error) {
 	defer session.resetStatement()
+	if session.dryRun != nil {
+		return nil, session.recordDryRun(sqlStr, args)
+	}
 
 	session.queryPreprocess(&sqlStr, args...)
 
 	session.lastSQL = sqlStr
 	session.lastSQLArgs = args
 
+	ctx, cancel := session.statementContext()
+	defer cancel()
+	release, err := session.admit(ctx)
+	if err != nil {
+		return nil, timeoutError(ctx, sqlStr, err)
+	}
+	defer release()
+	res, err := session.doExec(ctx, sqlStr, args...)
+	if err != nil {
+		return nil, timeoutError(ctx, sqlStr, err)
+	}
+	return res, nil
+}
+
+func (session *Session) doExec(ctx context.Context, sqlStr string, args ...interface{}) (sql.Result, error) {
 	if !session.isAutoCommit {
-		return session.tx.ExecContext(session.ctx, sqlStr, args...)
+		return session.tx.ExecContext(ctx, session.commentSQL(sqlStr), args...)
 	}
 
 	if session.prepareStmt {
-		stmt, err := session.doPrepare(session.DB(), sqlStr)
+		stmt, err := session.doPrepare(ctx, session.DB(), sqlStr)
 		if err != nil {
 			return nil, err
 		}
 
-		res, err := stmt.ExecContext(session.ctx, args...)
+		res, err := stmt.ExecContext(ctx, args...)
 		if err != nil {
 			return nil, err
 		}
 		return res, nil
 	}
 
-	return session.DB().ExecContext(session.ctx, sqlStr, args...)
+	return session.DB().ExecContext(ctx, session.commentSQL(sqlStr), args...)
 }
 
 // Exec raw sql
@@ -175,6 +237,9 @@ func (session *Session) Exec(sqlOrArgs ...interface{}) (sql.Result, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := session.checkRawSQL(sqlStr); err != nil {
+		return nil, err
+	}
 
 	return session.exec(sqlStr, args...)
 }
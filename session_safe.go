@@ -0,0 +1,180 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xormplus
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ErrFullTableRejected represents an update or a delete without condition is rejected in safe mode
+	ErrFullTableRejected = errors.New("Update or delete without condition is rejected in safe mode, call AllowFullTable to execute it")
+	// ErrSelectWithoutLimit represents a select without limit on a large table is rejected in safe mode
+	ErrSelectWithoutLimit = errors.New("Select without limit on a large table is rejected in safe mode, call AllowFullTable to execute it")
+)
+
+// SafeMode enables or disables the safe mode of the session, the default is the one of the engine
+func (session *Session) SafeMode(enable ...bool) *Session {
+	session.safeMode = len(enable) == 0 || enable[0]
+	return session
+}
+
+// AllowFullTable allows the next statement to update, delete or select the whole table in safe mode
+func (session *Session) AllowFullTable() *Session {
+	session.allowFullTable = true
+	return session
+}
+
+// checkFullTable rejects the update or the delete whose conditions are empty or only the
+// soft deleted condition if it's not limited
+func (session *Session) checkFullTable(condSQL string, limited bool) error {
+	if !session.safeMode || session.allowFullTable || limited {
+		return nil
+	}
+	if condSQL == "" {
+		return ErrFullTableRejected
+	}
+	if table := session.statement.RefTable; table != nil && table.Deleted != "" {
+		if col := table.DeletedColumn(); col != nil {
+			deletedSQL, _, err := session.statement.GenCondSQL(session.statement.CondDeleted(col))
+			if err != nil {
+				return err
+			}
+			if condSQL == deletedSQL || condSQL == "("+deletedSQL+")" {
+				return ErrFullTableRejected
+			}
+		}
+	}
+	return nil
+}
+
+// checkRawSQL rejects the raw statements updating, deleting or selecting the whole table
+func (session *Session) checkRawSQL(sqlStr string) error {
+	if !session.safeMode || session.allowFullTable {
+		return nil
+	}
+	info := classifySQL(sqlStr)
+	switch info.kind {
+	case "UPDATE", "DELETE":
+		if !info.hasWhere && !info.hasLimit {
+			return ErrFullTableRejected
+		}
+	case "TRUNCATE":
+		return ErrFullTableRejected
+	case "SELECT":
+		if info.hasLimit || info.aggregate || len(session.engine.largeTables) == 0 {
+			return nil
+		}
+		for _, table := range info.tables {
+			if session.engine.largeTables[strings.ToLower(table)] {
+				return ErrSelectWithoutLimit
+			}
+		}
+	}
+	return nil
+}
+
+type sqlInfo struct {
+	kind      string
+	hasWhere  bool
+	hasLimit  bool
+	aggregate bool
+	tables    []string
+}
+
+var (
+	sqlTableRegexp     = regexp.MustCompile("(?i)\\b(?:FROM|JOIN)\\s+((?:[`\"\\[]?[\\w$]+[`\"\\]]?\\.)?[`\"\\[]?[\\w$]+[`\"\\]]?)")
+	sqlAggregateRegexp = regexp.MustCompile(`(?i)^SELECT\s+(?:COUNT|SUM|AVG|MIN|MAX)\s*\(`)
+)
+
+// classifySQL classifies the statement by the top level keywords out of the parentheses,
+// the quotes and the comments
+func classifySQL(sqlStr string) sqlInfo {
+	var top strings.Builder
+	var depth int
+	for i := 0; i < len(sqlStr); i++ {
+		c := sqlStr[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := strings.IndexByte(sqlStr[i+1:], c)
+			if end < 0 {
+				i = len(sqlStr)
+			} else {
+				if depth == 0 && c != '\'' {
+					top.WriteString(sqlStr[i : i+end+2])
+				}
+				i += end + 1
+			}
+			top.WriteByte(' ')
+		case c == '-' && strings.HasPrefix(sqlStr[i:], "--"):
+			end := strings.IndexByte(sqlStr[i:], '\n')
+			if end < 0 {
+				end = len(sqlStr) - i
+			}
+			i += end
+			top.WriteByte(' ')
+		case c == '/' && strings.HasPrefix(sqlStr[i:], "/*"):
+			end := strings.Index(sqlStr[i+2:], "*/")
+			if end < 0 {
+				i = len(sqlStr)
+			} else {
+				i += end + 3
+			}
+			top.WriteByte(' ')
+		case c == '(':
+			depth++
+			top.WriteString(" (")
+		case c == ')':
+			depth--
+			top.WriteString(") ")
+		case depth == 0:
+			top.WriteByte(c)
+		}
+	}
+
+	s := strings.TrimSpace(top.String())
+	fields := strings.Fields(strings.ToUpper(s))
+	var info sqlInfo
+	if len(fields) == 0 {
+		return info
+	}
+	info.kind = fields[0]
+	if info.kind == "WITH" {
+		// the main statement of the CTE
+		for _, f := range fields[1:] {
+			switch f {
+			case "SELECT", "UPDATE", "DELETE", "INSERT":
+				info.kind = f
+			}
+			if info.kind != "WITH" {
+				break
+			}
+		}
+	}
+	for i, f := range fields {
+		switch f {
+		case "WHERE":
+			info.hasWhere = true
+		case "LIMIT", "TOP", "ROWNUM":
+			info.hasLimit = true
+		case "FETCH":
+			if i+1 < len(fields) && (fields[i+1] == "FIRST" || fields[i+1] == "NEXT") {
+				info.hasLimit = true
+			}
+		}
+	}
+	info.aggregate = info.kind == "SELECT" && sqlAggregateRegexp.MatchString(strings.TrimSpace(sqlStr)) &&
+		!strings.Contains(strings.ToUpper(s), "GROUP BY")
+	for _, m := range sqlTableRegexp.FindAllStringSubmatch(s, -1) {
+		table := m[1]
+		if i := strings.LastIndexByte(table, '.'); i >= 0 {
+			table = table[i+1:]
+		}
+		info.tables = append(info.tables, strings.Trim(table, "`\"[]"))
+	}
+	return info
+}
@@ -6,12 +6,14 @@ package xormplus
 
 import (
 	"bufio"
+	"context"
 	"database/sql"
 	"fmt"
 	"io"
 	"os"
 	"strings"
 
+	"github.com/laixyz/xormplus/dialects"
 	"github.com/laixyz/xormplus/internal/utils"
 	"github.com/laixyz/xormplus/schemas"
 )
@@ -23,7 +25,9 @@ func (session *Session) Ping() error {
 	}
 
 	session.engine.logger.Infof("PING DATABASE %v", session.engine.DriverName())
-	return session.DB().PingContext(session.ctx)
+	return session.withStatementContext("PING", func(ctx context.Context) error {
+		return session.DB().PingContext(ctx)
+	})
 }
 
 // CreateTable create a table according a bean
@@ -64,7 +68,10 @@ func (session *Session) createIndexes(bean interface{}) error {
 		return err
 	}
 
-	sqls := session.statement.GenIndexSQL()
+	sqls, err := session.statement.GenIndexSQL()
+	if err != nil {
+		return err
+	}
 	for _, sqlStr := range sqls {
 		_, err := session.exec(sqlStr)
 		if err != nil {
@@ -134,7 +141,7 @@ func (session *Session) dropTable(beanOrTableName interface{}) error {
 	tableName := session.engine.TableName(beanOrTableName)
 	sqlStr, checkIfExist := session.engine.dialect.DropTableSQL(session.engine.TableName(tableName, true))
 	if !checkIfExist {
-		exist, err := session.engine.dialect.IsTableExist(session.getQueryer(), session.ctx, tableName)
+		exist, err := session.isTableExist(tableName)
 		if err != nil {
 			return err
 		}
@@ -159,8 +166,12 @@ func (session *Session) IsTableExist(beanOrTableName interface{}) (bool, error)
 	return session.isTableExist(tableName)
 }
 
-func (session *Session) isTableExist(tableName string) (bool, error) {
-	return session.engine.dialect.IsTableExist(session.getQueryer(), session.ctx, tableName)
+func (session *Session) isTableExist(tableName string) (exist bool, err error) {
+	err = session.withStatementContext("IsTableExist "+tableName, func(ctx context.Context) error {
+		exist, err = session.engine.dialect.IsTableExist(session.getQueryer(), ctx, tableName)
+		return err
+	})
+	return
 }
 
 // IsTableEmpty if table have any records
@@ -186,18 +197,19 @@ func (session *Session) isTableEmpty(tableName string) (bool, error) {
 }
 
 // find if index is exist according cols
-func (session *Session) isIndexExist2(tableName string, cols []string, unique bool) (bool, error) {
-	indexes, err := session.engine.dialect.GetIndexes(session.getQueryer(), session.ctx, tableName)
+func (session *Session) isIndexExist2(tableName string, cols []string, indexType int) (bool, error) {
+	var indexes map[string]*schemas.Index
+	err := session.withStatementContext("GetIndexes "+tableName, func(ctx context.Context) (err error) {
+		indexes, err = session.engine.dialect.GetIndexes(session.getQueryer(), ctx, tableName)
+		return
+	})
 	if err != nil {
 		return false, err
 	}
 
 	for _, index := range indexes {
 		if utils.SliceEq(index.Cols, cols) {
-			if unique {
-				return index.Type == schemas.UniqueType, nil
-			}
-			return index.Type == schemas.IndexType, nil
+			return index.Type == indexType, nil
 		}
 	}
 	return false, nil
@@ -212,6 +224,9 @@ func (session *Session) addColumn(colName string) error {
 
 func (session *Session) addIndex(tableName, idxName string) error {
 	index := session.statement.RefTable.Indexes[idxName]
+	if err := dialects.CheckIndex(session.engine.dialect, index); err != nil {
+		return err
+	}
 	sqlStr := session.engine.dialect.CreateIndexSQL(tableName, index)
 	_, err := session.exec(sqlStr)
 	return err
@@ -233,7 +248,11 @@ func (session *Session) Sync2(beans ...interface{}) error {
 		defer session.Close()
 	}
 
-	tables, err := engine.dialect.GetTables(session.getQueryer(), session.ctx)
+	var tables []*schemas.Table
+	err := session.withStatementContext("GetTables", func(ctx context.Context) (err error) {
+		tables, err = engine.dialect.GetTables(session.getQueryer(), ctx)
+		return
+	})
 	if err != nil {
 		return err
 	}
@@ -415,7 +434,7 @@ func (session *Session) Sync2(beans ...interface{}) error {
 				session.statement.RefTable = table
 				session.statement.SetTableName(tbNameWithSchema)
 				err = session.addUnique(tbNameWithSchema, name)
-			} else if index.Type == schemas.IndexType {
+			} else if index.Type == schemas.IndexType || index.Type == schemas.FulltextType {
 				session.statement.RefTable = table
 				session.statement.SetTableName(tbNameWithSchema)
 				err = session.addIndex(tbNameWithSchema, name)
@@ -0,0 +1,75 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xormplus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TimeoutError represents a statement or a transaction exceeds its deadline. The drivers
+// report the cancelled statements differently, so the error is detected by the context
+// and wraps the error returned by the driver.
+type TimeoutError struct {
+	SQL string
+	Err error
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("Query timeout: %s: %v", e.SQL, e.Err)
+}
+
+// Unwrap returns the error returned by the driver
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// Timeout implements the interface of the timeout errors like net.Error
+func (e *TimeoutError) Timeout() bool {
+	return true
+}
+
+// IsTimeout returns whether the error is a *TimeoutError
+func IsTimeout(err error) bool {
+	var e *TimeoutError
+	return errors.As(err, &e)
+}
+
+// Timeout sets the timeout of every statement of the session and of the transaction from
+// Begin to Commit, 0 means no timeout. The default is the one of the engine.
+func (session *Session) Timeout(timeout time.Duration) *Session {
+	session.timeout = timeout
+	return session
+}
+
+// statementContext returns the context of a statement with the deadline of the timeout
+func (session *Session) statementContext() (context.Context, context.CancelFunc) {
+	if session.timeout <= 0 {
+		return session.ctx, func() {}
+	}
+	return context.WithTimeout(session.ctx, session.timeout)
+}
+
+// withStatementContext runs fn with the context of a statement which isn't executed by
+// queryRows or exec, i.e. the ones of the dialects, and wraps the error as a *TimeoutError
+// if the deadline is exceeded. sqlStr describes the statement in the error.
+func (session *Session) withStatementContext(sqlStr string, fn func(ctx context.Context) error) error {
+	ctx, cancel := session.statementContext()
+	defer cancel()
+	return timeoutError(ctx, sqlStr, fn(ctx))
+}
+
+// timeoutError wraps err as a *TimeoutError if the deadline of ctx is exceeded
+func timeoutError(ctx context.Context, sqlStr string, err error) error {
+	if err == nil || ctx.Err() != context.DeadlineExceeded {
+		return err
+	}
+	if _, ok := err.(*TimeoutError); ok {
+		return err
+	}
+	return &TimeoutError{SQL: sqlStr, Err: err}
+}
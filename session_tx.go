@@ -4,16 +4,37 @@
 
 package xormplus
 
+import (
+	"context"
+	"database/sql"
+)
+
 // Begin a transaction
 func (session *Session) Begin() error {
 	if session.isAutoCommit {
-		tx, err := session.DB().BeginTx(session.ctx, nil)
+		ctx := session.ctx
+		if session.timeout > 0 {
+			ctx, session.txCancel = context.WithTimeout(ctx, session.timeout)
+		}
+		release, err := session.admit(ctx)
 		if err != nil {
-			return err
+			session.cancelTx()
+			return timeoutError(ctx, "BEGIN TRANSACTION", err)
+		}
+		session.txRelease = release
+		tx, err := session.DB().BeginTx(ctx, nil)
+		if err != nil {
+			session.cancelTx()
+			return timeoutError(ctx, "BEGIN TRANSACTION", err)
 		}
 		session.isAutoCommit = false
 		session.isCommitedOrRollbacked = false
 		session.tx = tx
+		// the statements in the transaction are executed with the context of the
+		// transaction, so the hooks could relate them to the transaction
+		session.ctxBeforeTx = session.ctx
+		session.ctx = tx.Context()
+		session.trackTx(true)
 
 		session.saveLastSQL("BEGIN TRANSACTION")
 	}
@@ -23,11 +44,24 @@ func (session *Session) Begin() error {
 // Rollback When using transaction, you can rollback if any error
 func (session *Session) Rollback() error {
 	if !session.isAutoCommit && !session.isCommitedOrRollbacked {
+		// a failed drop doesn't stop the rollback, the tables are dropped with the
+		// connection at last
+		_ = session.dropInTempTables()
+		session.inTempTables = nil
+
 		session.saveLastSQL("ROLL BACK")
 		session.isCommitedOrRollbacked = true
 		session.isAutoCommit = true
+		session.restoreCtx()
+		session.trackTx(false)
+		defer session.cancelTx()
 
-		return session.tx.Rollback()
+		err := session.tx.Rollback()
+		if err == sql.ErrTxDone && session.tx.Context().Err() == context.DeadlineExceeded {
+			// the transaction has been rolled back when its deadline exceeded
+			return nil
+		}
+		return err
 	}
 	return nil
 }
@@ -35,12 +69,19 @@ func (session *Session) Rollback() error {
 // Commit When using transaction, Commit will commit all operations.
 func (session *Session) Commit() error {
 	if !session.isAutoCommit && !session.isCommitedOrRollbacked {
+		if err := session.dropInTempTables(); err != nil {
+			return err
+		}
+
 		session.saveLastSQL("COMMIT")
 		session.isCommitedOrRollbacked = true
 		session.isAutoCommit = true
+		session.restoreCtx()
+		session.trackTx(false)
+		defer session.cancelTx()
 
 		if err := session.tx.Commit(); err != nil {
-			return err
+			return timeoutError(session.tx.Context(), "COMMIT", err)
 		}
 
 		// handle processors after tx committed
@@ -84,3 +125,23 @@ func (session *Session) Commit() error {
 	}
 	return nil
 }
+
+// cancelTx releases the deadline and the admission of the transaction
+func (session *Session) cancelTx() {
+	if session.txRelease != nil {
+		session.txRelease()
+		session.txRelease = nil
+	}
+	if session.txCancel != nil {
+		session.txCancel()
+		session.txCancel = nil
+	}
+}
+
+// restoreCtx restores the context of the session before the transaction
+func (session *Session) restoreCtx() {
+	if session.ctxBeforeTx != nil {
+		session.ctx = session.ctxBeforeTx
+		session.ctxBeforeTx = nil
+	}
+}
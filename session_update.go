@@ -13,6 +13,7 @@ import (
 
 	"github.com/laixyz/xormplus/builder"
 	"github.com/laixyz/xormplus/caches"
+	"github.com/laixyz/xormplus/internal/statements"
 	"github.com/laixyz/xormplus/internal/utils"
 	"github.com/laixyz/xormplus/schemas"
 )
@@ -225,13 +226,13 @@ func (session *Session) Update(bean interface{}, condiBean ...interface{}) (int6
 	// for update action to like "column = column + ?"
 	incColumns := session.statement.IncrColumns
 	for i, colName := range incColumns.ColNames {
-		colNames = append(colNames, session.engine.Quote(colName)+" = "+session.engine.Quote(colName)+" + ?")
+		colNames = append(colNames, session.engine.Quote(colName)+" = "+session.statement.QuoteJoinColumn(colName)+" + ?")
 		args = append(args, incColumns.Args[i])
 	}
 	// for update action to like "column = column - ?"
 	decColumns := session.statement.DecrColumns
 	for i, colName := range decColumns.ColNames {
-		colNames = append(colNames, session.engine.Quote(colName)+" = "+session.engine.Quote(colName)+" - ?")
+		colNames = append(colNames, session.engine.Quote(colName)+" = "+session.statement.QuoteJoinColumn(colName)+" - ?")
 		args = append(args, decColumns.Args[i])
 	}
 	// for update action to like "column = expression"
@@ -250,6 +251,13 @@ func (session *Session) Update(bean interface{}, condiBean ...interface{}) (int6
 			}
 			colNames = append(colNames, session.engine.Quote(colName)+"=("+subQuery+")")
 			args = append(args, subArgs...)
+		case builder.Cond:
+			exprQuery, exprArgs, err := session.statement.GenCondSQL(tp)
+			if err != nil {
+				return 0, err
+			}
+			colNames = append(colNames, session.engine.Quote(colName)+"="+exprQuery)
+			args = append(args, exprArgs...)
 		default:
 			colNames = append(colNames, session.engine.Quote(colName)+"=?")
 			args = append(args, exprColumns.Args[i])
@@ -274,7 +282,7 @@ func (session *Session) Update(bean interface{}, condiBean ...interface{}) (int6
 				}
 				if k == reflect.Struct {
 					var err error
-					autoCond, err = session.statement.BuildConds(session.statement.RefTable, condiBean[0], true, true, false, true, false)
+					autoCond, err = session.statement.BuildConds(session.statement.RefTable, condiBean[0], true, true, false, true, session.statement.HasJoin())
 					if err != nil {
 						return 0, err
 					}
@@ -316,8 +324,9 @@ func (session *Session) Update(bean interface{}, condiBean ...interface{}) (int6
 		}
 
 		if verValue != nil {
-			cond = cond.And(builder.Eq{session.engine.Quote(table.Version): verValue.Interface()})
-			colNames = append(colNames, session.engine.Quote(table.Version)+" = "+session.engine.Quote(table.Version)+" + 1")
+			var verColName = session.statement.QuoteJoinColumn(table.Version)
+			cond = cond.And(builder.Eq{verColName: verValue.Interface()})
+			colNames = append(colNames, session.engine.Quote(table.Version)+" = "+verColName+" + 1")
 		}
 	}
 
@@ -329,52 +338,40 @@ func (session *Session) Update(bean interface{}, condiBean ...interface{}) (int6
 	if err != nil {
 		return 0, err
 	}
-
-	if len(condSQL) > 0 {
-		condSQL = "WHERE " + condSQL
-	}
-
-	if st.OrderStr != "" {
-		condSQL = condSQL + fmt.Sprintf(" ORDER BY %v", st.OrderStr)
+	if err := session.checkFullTable(condSQL, st.LimitN != nil && *st.LimitN > 0); err != nil {
+		return 0, err
 	}
 
 	var tableName = session.statement.TableName()
-	// TODO: Oracle support needed
-	var top string
-	if st.LimitN != nil {
-		limitValue := *st.LimitN
-		switch session.engine.dialect.URI().DBType {
-		case schemas.MYSQL:
-			condSQL = condSQL + fmt.Sprintf(" LIMIT %d", limitValue)
-		case schemas.SQLITE:
-			tempCondSQL := condSQL + fmt.Sprintf(" LIMIT %d", limitValue)
-			cond = cond.And(builder.Expr(fmt.Sprintf("rowid IN (SELECT rowid FROM %v %v)",
-				session.engine.Quote(tableName), tempCondSQL), condArgs...))
-			condSQL, condArgs, err = session.statement.GenCondSQL(cond)
-			if err != nil {
-				return 0, err
-			}
-			if len(condSQL) > 0 {
-				condSQL = "WHERE " + condSQL
-			}
-		case schemas.POSTGRES:
-			tempCondSQL := condSQL + fmt.Sprintf(" LIMIT %d", limitValue)
-			cond = cond.And(builder.Expr(fmt.Sprintf("CTID IN (SELECT CTID FROM %v %v)",
-				session.engine.Quote(tableName), tempCondSQL), condArgs...))
-			condSQL, condArgs, err = session.statement.GenCondSQL(cond)
-			if err != nil {
-				return 0, err
-			}
+	if session.statement.HasJoin() {
+		if st.OrderStr != "" || st.LimitN != nil {
+			return 0, statements.ErrUnsupportedJoin
+		}
+		sqlStr, condArgs, err = session.statement.GenUpdateJoinSQL(colNames, args, condSQL, condArgs)
+		if err != nil {
+			return 0, err
+		}
+		args = nil
+	} else {
+		if len(condSQL) > 0 {
+			condSQL = "WHERE " + condSQL
+		}
 
-			if len(condSQL) > 0 {
-				condSQL = "WHERE " + condSQL
-			}
-		case schemas.MSSQL:
-			if st.OrderStr != "" && table != nil && len(table.PrimaryKeys) == 1 {
-				cond = builder.Expr(fmt.Sprintf("%s IN (SELECT TOP (%d) %s FROM %v%v)",
-					table.PrimaryKeys[0], limitValue, table.PrimaryKeys[0],
-					session.engine.Quote(tableName), condSQL), condArgs...)
+		if st.OrderStr != "" {
+			condSQL = condSQL + fmt.Sprintf(" ORDER BY %v", st.OrderStr)
+		}
 
+		// TODO: Oracle support needed
+		var top string
+		if st.LimitN != nil {
+			limitValue := *st.LimitN
+			switch session.engine.dialect.URI().DBType {
+			case schemas.MYSQL:
+				condSQL = condSQL + fmt.Sprintf(" LIMIT %d", limitValue)
+			case schemas.SQLITE:
+				tempCondSQL := condSQL + fmt.Sprintf(" LIMIT %d", limitValue)
+				cond = cond.And(builder.Expr(fmt.Sprintf("rowid IN (SELECT rowid FROM %v %v)",
+					session.engine.Quote(tableName), tempCondSQL), condArgs...))
 				condSQL, condArgs, err = session.statement.GenCondSQL(cond)
 				if err != nil {
 					return 0, err
@@ -382,30 +379,56 @@ func (session *Session) Update(bean interface{}, condiBean ...interface{}) (int6
 				if len(condSQL) > 0 {
 					condSQL = "WHERE " + condSQL
 				}
-			} else {
-				top = fmt.Sprintf("TOP (%d) ", limitValue)
+			case schemas.POSTGRES:
+				tempCondSQL := condSQL + fmt.Sprintf(" LIMIT %d", limitValue)
+				cond = cond.And(builder.Expr(fmt.Sprintf("CTID IN (SELECT CTID FROM %v %v)",
+					session.engine.Quote(tableName), tempCondSQL), condArgs...))
+				condSQL, condArgs, err = session.statement.GenCondSQL(cond)
+				if err != nil {
+					return 0, err
+				}
+
+				if len(condSQL) > 0 {
+					condSQL = "WHERE " + condSQL
+				}
+			case schemas.MSSQL:
+				if st.OrderStr != "" && table != nil && len(table.PrimaryKeys) == 1 {
+					cond = builder.Expr(fmt.Sprintf("%s IN (SELECT TOP (%d) %s FROM %v%v)",
+						table.PrimaryKeys[0], limitValue, table.PrimaryKeys[0],
+						session.engine.Quote(tableName), condSQL), condArgs...)
+
+					condSQL, condArgs, err = session.statement.GenCondSQL(cond)
+					if err != nil {
+						return 0, err
+					}
+					if len(condSQL) > 0 {
+						condSQL = "WHERE " + condSQL
+					}
+				} else {
+					top = fmt.Sprintf("TOP (%d) ", limitValue)
+				}
 			}
 		}
-	}
 
-	var tableAlias = session.engine.Quote(tableName)
-	var fromSQL string
-	if session.statement.TableAlias != "" {
-		switch session.engine.dialect.URI().DBType {
-		case schemas.MSSQL:
-			fromSQL = fmt.Sprintf("FROM %s %s ", tableAlias, session.statement.TableAlias)
-			tableAlias = session.statement.TableAlias
-		default:
-			tableAlias = fmt.Sprintf("%s AS %s", tableAlias, session.statement.TableAlias)
+		var tableAlias = session.engine.Quote(tableName)
+		var fromSQL string
+		if session.statement.TableAlias != "" {
+			switch session.engine.dialect.URI().DBType {
+			case schemas.MSSQL:
+				fromSQL = fmt.Sprintf("FROM %s %s ", tableAlias, session.statement.TableAlias)
+				tableAlias = session.statement.TableAlias
+			default:
+				tableAlias = fmt.Sprintf("%s AS %s", tableAlias, session.statement.TableAlias)
+			}
 		}
-	}
 
-	sqlStr = fmt.Sprintf("UPDATE %v%v SET %v %v%v",
-		top,
-		tableAlias,
-		strings.Join(colNames, ", "),
-		fromSQL,
-		condSQL)
+		sqlStr = fmt.Sprintf("UPDATE %v%v SET %v %v%v",
+			top,
+			tableAlias,
+			strings.Join(colNames, ", "),
+			fromSQL,
+			condSQL)
+	}
 
 	res, err := session.exec(sqlStr, append(args, condArgs...)...)
 	if err != nil {
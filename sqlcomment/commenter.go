@@ -0,0 +1,146 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package sqlcomment appends a comment like /* app=..,route=..,trace_id=..,file=..:line */
+// to the statements, so the statements in the slow logs of the database could be related
+// to the services. The values come from the context and the caller stack.
+package sqlcomment
+
+import (
+	"context"
+	"strings"
+
+	"github.com/laixyz/xormplus/internal/utils"
+)
+
+// the keys of the comment
+const (
+	KeyApp     = "app"
+	KeyRoute   = "route"
+	KeyTraceID = "trace_id"
+	KeyFile    = "file"
+)
+
+type tagsKey struct{}
+
+type tag struct {
+	key, value string
+}
+
+// WithTag returns a context which carries the tag, the tags are appended to the comments
+// of the statements executed with the context
+func WithTag(ctx context.Context, key, value string) context.Context {
+	tags, _ := ctx.Value(tagsKey{}).([]tag)
+	tags = append(tags[:len(tags):len(tags)], tag{key, value})
+	return context.WithValue(ctx, tagsKey{}, tags)
+}
+
+// WithRoute returns a context which carries the route
+func WithRoute(ctx context.Context, route string) context.Context {
+	return WithTag(ctx, KeyRoute, route)
+}
+
+// WithTraceID returns a context which carries the trace ID
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return WithTag(ctx, KeyTraceID, traceID)
+}
+
+// Extractor extracts the value of a tag from the context, i.e. the trace ID of the span
+type Extractor func(ctx context.Context) string
+
+type extractor struct {
+	key string
+	fn  Extractor
+}
+
+// Commenter appends the comments to the statements
+type Commenter struct {
+	app             string
+	caller          bool
+	extractors      []extractor
+	commentPrepared bool
+}
+
+// NewCommenter creates a commenter, the caller is added to the comments by default
+func NewCommenter(app string) *Commenter {
+	return &Commenter{
+		app:    app,
+		caller: true,
+	}
+}
+
+// SetCaller sets whether to add the file and the line of the caller to the comments
+func (c *Commenter) SetCaller(caller bool) *Commenter {
+	c.caller = caller
+	return c
+}
+
+// AddExtractor adds a tag whose value is extracted from the context, the tag is omitted if
+// the value is empty
+func (c *Commenter) AddExtractor(key string, fn Extractor) *Commenter {
+	c.extractors = append(c.extractors, extractor{key, fn})
+	return c
+}
+
+// SetCommentPrepared sets whether to comment the prepared statements. The prepared
+// statements are cached by the SQL without comment, so the comment of a prepared
+// statement is the one when it's prepared. They are not commented by default.
+func (c *Commenter) SetCommentPrepared(commentPrepared bool) *Commenter {
+	c.commentPrepared = commentPrepared
+	return c
+}
+
+// CommentPrepared returns whether to comment the prepared statements
+func (c *Commenter) CommentPrepared() bool {
+	return c.commentPrepared
+}
+
+// valueReplacer escapes the values, the placeholders and the quotes are also escaped since
+// some drivers replace the placeholders without parsing the comments
+var valueReplacer = strings.NewReplacer("%", "%25", ",", "%2C", "=", "%3D", "*/", "*%2F",
+	"?", "%3F", "'", "%27", "\n", "%0A", "\r", "%0D")
+
+// Comment returns the SQL with the comment appended
+func (c *Commenter) Comment(ctx context.Context, sqlStr string) string {
+	var buf strings.Builder
+	add := func(key, value string) {
+		if value == "" {
+			return
+		}
+		if buf.Len() > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(valueReplacer.Replace(key))
+		buf.WriteByte('=')
+		buf.WriteString(valueReplacer.Replace(value))
+	}
+
+	add(KeyApp, c.app)
+	tags, _ := ctx.Value(tagsKey{}).([]tag)
+	// route and trace_id are in front of the other tags
+	for _, key := range []string{KeyRoute, KeyTraceID} {
+		for i := len(tags) - 1; i >= 0; i-- {
+			if tags[i].key == key {
+				add(key, tags[i].value)
+				break
+			}
+		}
+	}
+	for _, e := range c.extractors {
+		add(e.key, e.fn(ctx))
+	}
+	for _, t := range tags {
+		if t.key != KeyRoute && t.key != KeyTraceID {
+			add(t.key, t.value)
+		}
+	}
+	if c.caller {
+		add(KeyFile, utils.Caller())
+	}
+
+	if buf.Len() == 0 {
+		return sqlStr
+	}
+	return strings.TrimRight(sqlStr, " \t\r\n;") + " /* " + buf.String() + " */"
+}
@@ -0,0 +1,38 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlcomment
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComment(t *testing.T) {
+	ctx := WithTraceID(context.Background(), "abc")
+	ctx = WithTag(ctx, "user", "a,b=c*/")
+	ctx = WithRoute(ctx, "/users?id=1")
+
+	c := NewCommenter("api").SetCaller(false)
+	assert.EqualValues(t, "SELECT 1 /* app=api,route=/users%3Fid%3D1,trace_id=abc,user=a%2Cb%3Dc*%2F */",
+		c.Comment(ctx, "SELECT 1;"))
+
+	// the tags could be overridden
+	assert.EqualValues(t, "SELECT 1 /* app=api,route=/orders,trace_id=abc,user=a%2Cb%3Dc*%2F */",
+		c.Comment(WithRoute(ctx, "/orders"), "SELECT 1"))
+
+	c.AddExtractor("span", func(ctx context.Context) string { return "1" })
+	assert.EqualValues(t, "SELECT 1 /* app=api,span=1 */", c.Comment(context.Background(), "SELECT 1"))
+
+	assert.EqualValues(t, "SELECT 1", NewCommenter("").SetCaller(false).Comment(context.Background(), "SELECT 1"))
+}
+
+func TestCommentCaller(t *testing.T) {
+	sqlStr := NewCommenter("").Comment(context.Background(), "SELECT 1")
+	assert.True(t, strings.HasPrefix(sqlStr, "SELECT 1 /* file="), sqlStr)
+	assert.Contains(t, sqlStr, "commenter_test.go:")
+}
@@ -247,6 +247,8 @@ func (parser *Parser) Parse(v reflect.Value) (*schemas.Table, error) {
 					ctx.indexNames[col.Name] = schemas.UniqueType
 				} else if ctx.isIndex {
 					ctx.indexNames[col.Name] = schemas.IndexType
+				} else if ctx.isFulltext {
+					ctx.indexNames[col.Name] = schemas.FulltextType
 				}
 
 				for indexName, indexType := range ctx.indexNames {
@@ -11,6 +11,7 @@ import (
 	"github.com/laixyz/xormplus/caches"
 	"github.com/laixyz/xormplus/dialects"
 	"github.com/laixyz/xormplus/names"
+	"github.com/laixyz/xormplus/schemas"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -80,3 +81,34 @@ func TestUnexportField(t *testing.T) {
 		assert.NotEqual(t, "public", col.Name)
 	}
 }
+
+func TestParseFulltextIndex(t *testing.T) {
+	parser := NewParser(
+		"xorm",
+		dialects.QueryDialect("mysql"),
+		names.SnakeMapper{},
+		names.SnakeMapper{},
+		caches.NewManager(),
+	)
+
+	type FulltextStruct struct {
+		Id          int64
+		Title       string `xorm:"fulltext(search)"`
+		Description string `xorm:"text fulltext(search)"`
+		Summary     string `xorm:"fulltext"`
+	}
+	table, err := parser.Parse(reflect.ValueOf(new(FulltextStruct)))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, len(table.Indexes))
+
+	index := table.Indexes["search"]
+	assert.NotNil(t, index)
+	assert.EqualValues(t, schemas.FulltextType, index.Type)
+	assert.EqualValues(t, []string{"title", "description"}, index.Cols)
+	assert.EqualValues(t, "FTS_fulltext_struct_search", index.XName(table.Name))
+
+	index = table.Indexes["summary"]
+	assert.NotNil(t, index)
+	assert.EqualValues(t, schemas.FulltextType, index.Type)
+	assert.EqualValues(t, []string{"summary"}, index.Cols)
+}
@@ -44,6 +44,7 @@ type Context struct {
 	fieldValue      reflect.Value
 	isIndex         bool
 	isUnique        bool
+	isFulltext      bool
 	indexNames      map[string]int
 	parser          *Parser
 	hasCacheTag     bool
@@ -73,6 +74,7 @@ var (
 		"NOTNULL":  NotNullTagHandler,
 		"INDEX":    IndexTagHandler,
 		"UNIQUE":   UniqueTagHandler,
+		"FULLTEXT": FulltextTagHandler,
 		"CACHE":    CacheTagHandler,
 		"NOCACHE":  NoCacheTagHandler,
 		"COMMENT":  CommentTagHandler,
@@ -215,6 +217,16 @@ func UniqueTagHandler(ctx *Context) error {
 	return nil
 }
 
+// FulltextTagHandler describes full-text index tag handler
+func FulltextTagHandler(ctx *Context) error {
+	if len(ctx.params) > 0 {
+		ctx.indexNames[ctx.params[0]] = schemas.FulltextType
+	} else {
+		ctx.isFulltext = true
+	}
+	return nil
+}
+
 // CommentTagHandler add comment to column
 func CommentTagHandler(ctx *Context) error {
 	if len(ctx.params) > 0 {
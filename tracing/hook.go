@@ -0,0 +1,142 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/laixyz/xormplus/contexts"
+)
+
+type (
+	stmtSpanKey struct{}
+	txSpanKey   struct{}
+)
+
+// Hook is a hook which starts a span for every statement and every transaction
+type Hook struct {
+	tracer Tracer
+	system string
+	dbName string
+}
+
+var _ contexts.Hook = &Hook{}
+
+// NewHook creates a tracing hook, system is the database type like mysql and dbName is
+// the name of the database
+func NewHook(tracer Tracer, system, dbName string) *Hook {
+	return &Hook{
+		tracer: tracer,
+		system: system,
+		dbName: dbName,
+	}
+}
+
+func (h *Hook) start(ctx context.Context, name string) (context.Context, Span) {
+	ctx, span := h.tracer.Start(ctx, name)
+	span.SetAttribute(AttrSystem, h.system)
+	if h.dbName != "" {
+		span.SetAttribute(AttrName, h.dbName)
+	}
+	return ctx, span
+}
+
+// BeforeProcess implements contexts.Hook
+func (h *Hook) BeforeProcess(c *contexts.ContextHook) (context.Context, error) {
+	if c.SQL == "BEGIN TRANSACTION" {
+		// the context of the transaction is kept until commit or rollback
+		ctx, span := h.start(c.Ctx, SpanTransaction)
+		return context.WithValue(ctx, txSpanKey{}, span), nil
+	}
+
+	op := Operation(c.SQL)
+	ctx, span := h.start(c.Ctx, op)
+	span.SetAttribute(AttrOperation, op)
+	span.SetAttribute(AttrStatement, NormalizeSQL(c.SQL))
+	return context.WithValue(ctx, stmtSpanKey{}, span), nil
+}
+
+// AfterProcess implements contexts.Hook
+func (h *Hook) AfterProcess(c *contexts.ContextHook) error {
+	if c.SQL == "BEGIN TRANSACTION" {
+		// the transaction span is ended at commit or rollback unless it's failed to begin
+		if span, ok := c.Ctx.Value(txSpanKey{}).(Span); ok && c.Err != nil {
+			span.RecordError(c.Err)
+			span.End()
+		}
+		return nil
+	}
+
+	if span, ok := c.Ctx.Value(stmtSpanKey{}).(Span); ok {
+		if c.Result != nil {
+			if rows, err := c.Result.RowsAffected(); err == nil {
+				span.SetAttribute(AttrRowsAffected, rows)
+			}
+		}
+		if c.Err != nil && !errors.Is(c.Err, sql.ErrNoRows) {
+			span.RecordError(c.Err)
+		}
+		span.End()
+	}
+
+	switch c.SQL {
+	case "COMMIT", "ROLLBACK":
+		if span, ok := c.Ctx.Value(txSpanKey{}).(Span); ok {
+			span.SetAttribute(AttrTxStatus, strings.ToLower(c.SQL))
+			if c.Err != nil {
+				span.RecordError(c.Err)
+			}
+			span.End()
+		}
+	}
+	return nil
+}
+
+// Operation returns the upper case of the first keyword of the SQL, i.e. SELECT
+func Operation(sqlStr string) string {
+	sqlStr = strings.TrimLeft(sqlStr, " \t\r\n(")
+	end := strings.IndexAny(sqlStr, " \t\r\n(;")
+	if end < 0 {
+		end = len(sqlStr)
+	}
+	if end == 0 {
+		return "SQL"
+	}
+	return strings.ToUpper(sqlStr[:end])
+}
+
+// NormalizeSQL collapses the whitespaces of the SQL out of the quotes
+func NormalizeSQL(sqlStr string) string {
+	var buf strings.Builder
+	buf.Grow(len(sqlStr))
+	var quote byte
+	var space bool
+	for i := 0; i < len(sqlStr); i++ {
+		c := sqlStr[i]
+		if quote != 0 {
+			buf.WriteByte(c)
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case ' ', '\t', '\r', '\n':
+			space = true
+			continue
+		case '\'', '"', '`':
+			quote = c
+		}
+		if space && buf.Len() > 0 {
+			buf.WriteByte(' ')
+		}
+		space = false
+		buf.WriteByte(c)
+	}
+	return buf.String()
+}
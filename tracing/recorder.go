@@ -0,0 +1,112 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tracing
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type recordedSpanKey struct{}
+
+// RecordedSpan is a span recorded by Recorder
+type RecordedSpan struct {
+	recorder *Recorder
+
+	ID         int
+	ParentID   int // 0 if it's a root span
+	Name       string
+	Attributes map[string]interface{}
+	Errors     []error
+	StartTime  time.Time
+	EndTime    time.Time
+	Ended      bool
+}
+
+var _ Span = &RecordedSpan{}
+
+// SetAttribute implements Span
+func (s *RecordedSpan) SetAttribute(key string, value interface{}) {
+	s.recorder.mu.Lock()
+	s.Attributes[key] = value
+	s.recorder.mu.Unlock()
+}
+
+// RecordError implements Span
+func (s *RecordedSpan) RecordError(err error) {
+	s.recorder.mu.Lock()
+	s.Errors = append(s.Errors, err)
+	s.recorder.mu.Unlock()
+}
+
+// End implements Span
+func (s *RecordedSpan) End() {
+	s.recorder.mu.Lock()
+	if !s.Ended {
+		s.EndTime = time.Now()
+		s.Ended = true
+	}
+	s.recorder.mu.Unlock()
+}
+
+// Recorder is a Tracer which records the spans in memory
+type Recorder struct {
+	mu     sync.Mutex
+	lastID int
+	spans  []*RecordedSpan
+}
+
+var _ Tracer = &Recorder{}
+
+// NewRecorder creates a recorder
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Start implements Tracer
+func (r *Recorder) Start(ctx context.Context, name string) (context.Context, Span) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastID++
+	span := &RecordedSpan{
+		recorder:   r,
+		ID:         r.lastID,
+		Name:       name,
+		Attributes: make(map[string]interface{}),
+		StartTime:  time.Now(),
+	}
+	if parent, ok := ctx.Value(recordedSpanKey{}).(*RecordedSpan); ok && parent.recorder == r {
+		span.ParentID = parent.ID
+	}
+	r.spans = append(r.spans, span)
+	return context.WithValue(ctx, recordedSpanKey{}, span), span
+}
+
+// Spans returns copies of the recorded spans in the order of starting
+func (r *Recorder) Spans() []RecordedSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	spans := make([]RecordedSpan, 0, len(r.spans))
+	for _, span := range r.spans {
+		s := *span
+		s.Attributes = make(map[string]interface{}, len(span.Attributes))
+		for k, v := range span.Attributes {
+			s.Attributes[k] = v
+		}
+		s.Errors = append([]error(nil), span.Errors...)
+		spans = append(spans, s)
+	}
+	return spans
+}
+
+// Reset clears the recorded spans
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	r.spans = nil
+	r.mu.Unlock()
+}
@@ -0,0 +1,40 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package tracing traces the statements and the transactions of xorm. A span is started
+// for every statement and every transaction, the spans are parented from the context
+// passed by Session.Context. The backend, i.e. OpenTelemetry, could be plugged in by
+// implementing Tracer, and Recorder records the spans in memory.
+package tracing
+
+import "context"
+
+// the attributes of the spans
+const (
+	AttrSystem       = "db.system"
+	AttrName         = "db.name"
+	AttrStatement    = "db.statement"
+	AttrOperation    = "db.operation"
+	AttrRowsAffected = "db.rows_affected"
+	AttrTxStatus     = "db.transaction.status"
+)
+
+// the names of the spans of the transactions
+const (
+	SpanTransaction = "TRANSACTION"
+)
+
+// Tracer starts the spans
+type Tracer interface {
+	// Start starts a span which is a child of the span in ctx if there is, and returns
+	// a context which carries the span
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is an operation traced
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
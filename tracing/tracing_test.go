@@ -0,0 +1,86 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/laixyz/xormplus/contexts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeSQL(t *testing.T) {
+	assert.EqualValues(t, "SELECT * FROM user WHERE name='a  b'",
+		NormalizeSQL("  SELECT *\n\tFROM   user\nWHERE name='a  b'  "))
+	assert.EqualValues(t, "SELECT", Operation(" select id FROM user"))
+	assert.EqualValues(t, "SELECT", Operation("(SELECT 1)"))
+	assert.EqualValues(t, "SQL", Operation(""))
+}
+
+type testResult int64
+
+func (r testResult) LastInsertId() (int64, error) { return 0, nil }
+func (r testResult) RowsAffected() (int64, error) { return int64(r), nil }
+
+func process(ctx context.Context, hook *Hook, sqlStr string, result sql.Result, err error) context.Context {
+	c := contexts.NewContextHook(ctx, sqlStr, nil)
+	ctx, _ = hook.BeforeProcess(c)
+	c.End(ctx, result, err)
+	hook.AfterProcess(c)
+	return ctx
+}
+
+func TestHook(t *testing.T) {
+	recorder := NewRecorder()
+	hook := NewHook(recorder, "sqlite3", "test")
+
+	ctx, root := recorder.Start(context.Background(), "request")
+	txCtx := process(ctx, hook, "BEGIN TRANSACTION", nil, nil)
+	process(txCtx, hook, "UPDATE  user\nSET name=?", testResult(2), nil)
+	process(txCtx, hook, "SELECT * FROM user", nil, sql.ErrNoRows)
+	process(txCtx, hook, "INSERT INTO user", nil, errors.New("UNIQUE constraint failed"))
+	process(txCtx, hook, "ROLLBACK", nil, nil)
+	root.End()
+
+	spans := recorder.Spans()
+	assert.EqualValues(t, 6, len(spans))
+	for _, span := range spans {
+		assert.True(t, span.Ended, span.Name)
+	}
+
+	tx := spans[1]
+	assert.EqualValues(t, SpanTransaction, tx.Name)
+	assert.EqualValues(t, spans[0].ID, tx.ParentID)
+	assert.EqualValues(t, "rollback", tx.Attributes[AttrTxStatus])
+	assert.EqualValues(t, "sqlite3", tx.Attributes[AttrSystem])
+	assert.EqualValues(t, "test", tx.Attributes[AttrName])
+
+	update := spans[2]
+	assert.EqualValues(t, "UPDATE", update.Name)
+	assert.EqualValues(t, tx.ID, update.ParentID)
+	assert.EqualValues(t, "UPDATE user SET name=?", update.Attributes[AttrStatement])
+	assert.EqualValues(t, 2, update.Attributes[AttrRowsAffected])
+
+	assert.Empty(t, spans[3].Errors)
+	assert.EqualValues(t, 1, len(spans[4].Errors))
+	assert.EqualValues(t, "ROLLBACK", spans[5].Name)
+	assert.EqualValues(t, tx.ID, spans[5].ParentID)
+}
+
+func TestHookBeginFailed(t *testing.T) {
+	recorder := NewRecorder()
+	hook := NewHook(recorder, "mysql", "")
+
+	process(context.Background(), hook, "BEGIN TRANSACTION", nil, errors.New("bad connection"))
+	spans := recorder.Spans()
+	assert.EqualValues(t, 1, len(spans))
+	assert.True(t, spans[0].Ended)
+	assert.EqualValues(t, 1, len(spans[0].Errors))
+	_, ok := spans[0].Attributes[AttrName]
+	assert.False(t, ok)
+}